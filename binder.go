@@ -0,0 +1,109 @@
+package tsq
+
+import "reflect"
+
+// ScanBinder is implemented by generated table pointers to bind scan
+// destinations without per-column closures. TSQ uses it when every selected
+// column is a plain column of the table and falls back to the column field
+// pointers otherwise.
+type ScanBinder interface {
+	// ScanDest returns one field pointer per physical column name, in order.
+	// It reports false when any name is not a column of the table.
+	ScanDest(cols []string) ([]any, bool)
+}
+
+// MutationBinder is implemented by generated table pointers to expose field
+// values without reflection during Insert, Update, and the chunked helpers.
+type MutationBinder interface {
+	// MutationValues returns the current field values in Cols() order.
+	MutationValues() []any
+}
+
+// plainColumn marks columns that scan straight into the owner field named by
+// Name, which is what ScanBinder implementations switch on.
+type plainColumn interface {
+	plainColumnName() (string, bool)
+}
+
+func (c columnImpl[O, T]) plainColumnName() (string, bool) {
+	if c.buildErr != nil || c.transformed || c.aggregate || c.name == "" || c.fieldPointer == nil {
+		return "", false
+	}
+
+	return c.name, true
+}
+
+// scanBinderColumns returns the column names handed to ScanBinder.ScanDest, or
+// nil when O does not implement ScanBinder or any column is a projection.
+func scanBinderColumns[O Owner](cols []BoundColumn[O]) []string {
+	if _, ok := any((*O)(nil)).(ScanBinder); !ok || len(cols) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(cols))
+	for _, col := range cols {
+		plain, ok := col.(plainColumn)
+		if !ok {
+			return nil
+		}
+
+		name, ok := plain.plainColumnName()
+		if !ok {
+			return nil
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// scanDest binds holder's scan destinations, preferring the generated
+// ScanBinder fast path.
+func (q *Query[O]) scanDest(holder *O) ([]any, error) {
+	if q.scanCols != nil {
+		if binder, ok := any(holder).(ScanBinder); ok {
+			if dest, ok := binder.ScanDest(q.scanCols); ok {
+				return dest, nil
+			}
+		}
+	}
+
+	return buildScanDest(q.selectCols, holder)
+}
+
+// collectBoundMutationFields builds mutation fields from MutationValues. Only
+// the primary-key and version fields keep an addressable reflect.Value, since
+// those are the fields TSQ writes back after the statement runs.
+func collectBoundMutationFields(dst Table, binder MutationBinder) ([]mutationField, bool) {
+	cols := dst.Cols()
+
+	values := binder.MutationValues()
+	if len(values) != len(cols) {
+		return nil, false
+	}
+
+	pkColumns := dst.PrimaryKeys()
+	versionColumn := dst.VersionColumn()
+
+	fields := make([]mutationField, 0, len(cols))
+	for i, col := range cols {
+		if isNilValue(col) {
+			return nil, false
+		}
+
+		field := mutationField{column: col.Name(), arg: values[i], hasArg: true}
+		if field.column == versionColumn || (len(pkColumns) == 1 && field.column == pkColumns[0]) {
+			ptr, err := mutationFieldPointer(col, dst)
+			if err != nil {
+				return nil, false
+			}
+
+			field.value = reflect.ValueOf(ptr).Elem()
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, true
+}
@@ -0,0 +1,217 @@
+package tsq
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+// wideRow mirrors a generated table with sixteen columns. wideRowReflection
+// switches its binder methods off so both paths run against the same schema.
+type wideRow struct {
+	ID                             int64
+	S1, S2, S3, S4, S5, S6, S7     string
+	N1, N2, N3, N4, N5, N6, N7, N8 int64
+}
+
+var wideRowReflection bool
+
+func (wideRow) TSQOwner() {}
+
+func (wideRow) Table() string { return "wide_rows" }
+
+func (wideRow) Cols() []SQLColumn { return SQLColumns(wideRowColumns...) }
+
+func (wideRow) SearchColumns() []SearchColumn { return nil }
+
+func (wideRow) PrimaryKeys() []string { return []string{"id"} }
+
+func (wideRow) AutoIncrement() bool { return true }
+
+func (wideRow) VersionColumn() string { return "" }
+
+func (t *wideRow) ScanDest(cols []string) ([]any, bool) {
+	if wideRowReflection {
+		return nil, false
+	}
+
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "id":
+			dest[i] = &t.ID
+		case "s1":
+			dest[i] = &t.S1
+		case "s2":
+			dest[i] = &t.S2
+		case "s3":
+			dest[i] = &t.S3
+		case "s4":
+			dest[i] = &t.S4
+		case "s5":
+			dest[i] = &t.S5
+		case "s6":
+			dest[i] = &t.S6
+		case "s7":
+			dest[i] = &t.S7
+		case "n1":
+			dest[i] = &t.N1
+		case "n2":
+			dest[i] = &t.N2
+		case "n3":
+			dest[i] = &t.N3
+		case "n4":
+			dest[i] = &t.N4
+		case "n5":
+			dest[i] = &t.N5
+		case "n6":
+			dest[i] = &t.N6
+		case "n7":
+			dest[i] = &t.N7
+		case "n8":
+			dest[i] = &t.N8
+		default:
+			return nil, false
+		}
+	}
+
+	return dest, true
+}
+
+func (t *wideRow) MutationValues() []any {
+	if wideRowReflection {
+		return nil
+	}
+
+	return []any{
+		t.ID, t.S1, t.S2, t.S3, t.S4, t.S5, t.S6, t.S7,
+		t.N1, t.N2, t.N3, t.N4, t.N5, t.N6, t.N7, t.N8,
+	}
+}
+
+var wideRowColumns = []BoundColumn[wideRow]{
+	NewCol("id", "id", func(t *wideRow) *int64 { return &t.ID }),
+	NewCol("s1", "s1", func(t *wideRow) *string { return &t.S1 }),
+	NewCol("s2", "s2", func(t *wideRow) *string { return &t.S2 }),
+	NewCol("s3", "s3", func(t *wideRow) *string { return &t.S3 }),
+	NewCol("s4", "s4", func(t *wideRow) *string { return &t.S4 }),
+	NewCol("s5", "s5", func(t *wideRow) *string { return &t.S5 }),
+	NewCol("s6", "s6", func(t *wideRow) *string { return &t.S6 }),
+	NewCol("s7", "s7", func(t *wideRow) *string { return &t.S7 }),
+	NewCol("n1", "n1", func(t *wideRow) *int64 { return &t.N1 }),
+	NewCol("n2", "n2", func(t *wideRow) *int64 { return &t.N2 }),
+	NewCol("n3", "n3", func(t *wideRow) *int64 { return &t.N3 }),
+	NewCol("n4", "n4", func(t *wideRow) *int64 { return &t.N4 }),
+	NewCol("n5", "n5", func(t *wideRow) *int64 { return &t.N5 }),
+	NewCol("n6", "n6", func(t *wideRow) *int64 { return &t.N6 }),
+	NewCol("n7", "n7", func(t *wideRow) *int64 { return &t.N7 }),
+	NewCol("n8", "n8", func(t *wideRow) *int64 { return &t.N8 }),
+}
+
+func newWideRowEngine(b *testing.B) *Runtime {
+	b.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		b.Fatalf("open sqlite: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = db.Close()
+	})
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(context.Background(), `CREATE TABLE wide_rows (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		s1 TEXT, s2 TEXT, s3 TEXT, s4 TEXT, s5 TEXT, s6 TEXT, s7 TEXT,
+		n1 INTEGER, n2 INTEGER, n3 INTEGER, n4 INTEGER, n5 INTEGER, n6 INTEGER, n7 INTEGER, n8 INTEGER
+	)`); err != nil {
+		b.Fatalf("create wide_rows table: %v", err)
+	}
+
+	return newRuntimeWithDB(db, SQLiteDialect{})
+}
+
+func newWideRows(n int) []*wideRow {
+	rows := make([]*wideRow, n)
+	for i := range rows {
+		s := fmt.Sprintf("value-%d", i)
+		v := int64(i)
+		rows[i] = &wideRow{
+			S1: s, S2: s, S3: s, S4: s, S5: s, S6: s, S7: s,
+			N1: v, N2: v, N3: v, N4: v, N5: v, N6: v, N7: v, N8: v,
+		}
+	}
+
+	return rows
+}
+
+// BenchmarkChunkedInsert100k_Reflection measures ChunkedInsert of 100k wide rows through reflection
+func BenchmarkChunkedInsert100k_Reflection(b *testing.B) {
+	benchmarkChunkedInsert100k(b, true)
+}
+
+// BenchmarkChunkedInsert100k_Binder measures ChunkedInsert of 100k wide rows through MutationBinder
+func BenchmarkChunkedInsert100k_Binder(b *testing.B) {
+	benchmarkChunkedInsert100k(b, false)
+}
+
+func benchmarkChunkedInsert100k(b *testing.B, reflection bool) {
+	wideRowReflection = reflection
+	b.Cleanup(func() {
+		wideRowReflection = false
+	})
+
+	db := newWideRowEngine(b)
+	ctx := context.Background()
+	options := &ChunkedInsertOptions{ChunkSize: 50}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		rows := newWideRows(100_000)
+		if _, err := db.ExecContext(ctx, `DELETE FROM wide_rows`); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := ChunkedInsert(ctx, db, rows, options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkListWide_Reflection measures List of 1k wide rows through column field pointers
+func BenchmarkListWide_Reflection(b *testing.B) {
+	benchmarkListWide(b, true)
+}
+
+// BenchmarkListWide_Binder measures List of 1k wide rows through ScanBinder
+func BenchmarkListWide_Binder(b *testing.B) {
+	benchmarkListWide(b, false)
+}
+
+func benchmarkListWide(b *testing.B, reflection bool) {
+	db := newWideRowEngine(b)
+	ctx := context.Background()
+
+	if err := ChunkedInsert(ctx, db, newWideRows(1000), &ChunkedInsertOptions{ChunkSize: 500}); err != nil {
+		b.Fatal(err)
+	}
+
+	wideRowReflection = reflection
+	b.Cleanup(func() {
+		wideRowReflection = false
+	})
+
+	query := mustBuild(Select(wideRowColumns...).From(wideRowColumns[0].Table()))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := query.List(ctx, db); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
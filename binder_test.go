@@ -0,0 +1,187 @@
+package tsq
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+type boundUser struct {
+	ID      int64
+	Name    string
+	Email   string
+	Version int64
+}
+
+var (
+	boundUserScanDestCalls       int
+	boundUserMutationValuesCalls int
+)
+
+func (boundUser) TSQOwner() {}
+
+func (boundUser) Table() string { return "users" }
+
+func (boundUser) Cols() []SQLColumn { return SQLColumns(boundUserColumns...) }
+
+func (boundUser) SearchColumns() []SearchColumn { return nil }
+
+func (boundUser) PrimaryKeys() []string { return []string{"id"} }
+
+func (boundUser) AutoIncrement() bool { return true }
+
+func (boundUser) VersionColumn() string { return "version" }
+
+func (t *boundUser) ScanDest(cols []string) ([]any, bool) {
+	boundUserScanDestCalls++
+
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "id":
+			dest[i] = &t.ID
+		case "name":
+			dest[i] = &t.Name
+		case "email":
+			dest[i] = &t.Email
+		case "version":
+			dest[i] = &t.Version
+		default:
+			return nil, false
+		}
+	}
+
+	return dest, true
+}
+
+func (t *boundUser) MutationValues() []any {
+	boundUserMutationValuesCalls++
+
+	return []any{t.ID, t.Name, t.Email, t.Version}
+}
+
+var (
+	boundUserID      = NewCol("id", "id", func(t *boundUser) *int64 { return &t.ID })
+	boundUserName    = NewCol("name", "name", func(t *boundUser) *string { return &t.Name })
+	boundUserEmail   = NewCol("email", "email", func(t *boundUser) *string { return &t.Email })
+	boundUserVersion = NewCol("version", "version", func(t *boundUser) *int64 { return &t.Version })
+
+	boundUserColumns = []BoundColumn[boundUser]{boundUserID, boundUserName, boundUserEmail, boundUserVersion}
+)
+
+func newBoundUserEngine(t testing.TB) *Runtime {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(context.Background(), `CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL UNIQUE,
+		version INTEGER NOT NULL
+	)`); err != nil {
+		t.Fatalf("create users table: %v", err)
+	}
+
+	return newRuntimeWithDB(db, SQLiteDialect{})
+}
+
+func TestMutationBinderDrivesInsertAndUpdate(t *testing.T) {
+	db := newBoundUserEngine(t)
+	ctx := context.Background()
+	boundUserMutationValuesCalls = 0
+
+	user := &boundUser{Name: "alice", Email: "alice@example.com"}
+	if err := Insert(ctx, db, user); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if user.ID == 0 {
+		t.Fatal("expected Insert to assign the generated primary key")
+	}
+
+	user.Name = "alice2"
+	if err := Update(ctx, db, user); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if user.Version != 1 {
+		t.Fatalf("expected Update to bump the version through the bound field, got %d", user.Version)
+	}
+
+	var name string
+	var version int64
+	if err := db.QueryRowContext(ctx, `SELECT name, version FROM users WHERE id = ?`, user.ID).Scan(&name, &version); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+	if name != "alice2" || version != 1 {
+		t.Fatalf("expected updated row, got name=%q version=%d", name, version)
+	}
+
+	if boundUserMutationValuesCalls != 2 {
+		t.Fatalf("expected MutationValues to serve both mutations, got %d calls", boundUserMutationValuesCalls)
+	}
+}
+
+func TestScanBinderDrivesPlainColumnScans(t *testing.T) {
+	db := newBoundUserEngine(t)
+	ctx := context.Background()
+
+	if err := ChunkedInsert(ctx, db, []*boundUser{
+		{Name: "alice", Email: "alice@example.com"},
+		{Name: "bob", Email: "bob@example.com"},
+	}); err != nil {
+		t.Fatalf("ChunkedInsert() error = %v", err)
+	}
+
+	query := mustBuild(Select(boundUserColumns...).From(boundUserID.Table()))
+	if strings.Join(query.scanCols, ",") != "id,name,email,version" {
+		t.Fatalf("expected plain columns to enable ScanBinder, got %v", query.scanCols)
+	}
+
+	boundUserScanDestCalls = 0
+
+	rows, err := query.List(ctx, db)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "alice" || rows[1].Email != "bob@example.com" {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+	if boundUserScanDestCalls != 2 {
+		t.Fatalf("expected one ScanDest call per row, got %d", boundUserScanDestCalls)
+	}
+}
+
+func TestScanBinderFallsBackForTransformedColumns(t *testing.T) {
+	db := newBoundUserEngine(t)
+	ctx := context.Background()
+
+	if err := Insert(ctx, db, &boundUser{Name: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+
+	query := mustBuild(Select[boundUser](boundUserID, boundUserName.Upper()).From(boundUserID.Table()))
+	if query.scanCols != nil {
+		t.Fatalf("expected transformed columns to disable ScanBinder, got %v", query.scanCols)
+	}
+
+	boundUserScanDestCalls = 0
+
+	rows, err := query.List(ctx, db)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "ALICE" {
+		t.Fatalf("unexpected rows: %#v", rows)
+	}
+	if boundUserScanDestCalls != 0 {
+		t.Fatalf("expected the field-pointer fallback, got %d ScanDest calls", boundUserScanDestCalls)
+	}
+}
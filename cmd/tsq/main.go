@@ -24,8 +24,11 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.AddCommand(cmd.DiffCmd)
 	rootCmd.AddCommand(cmd.FmtCmd)
 	rootCmd.AddCommand(cmd.GenCmd)
+	rootCmd.AddCommand(cmd.IntrospectCmd)
+	rootCmd.AddCommand(cmd.MigrateCmd)
 	rootCmd.AddCommand(cmd.VersionCmd)
 }
 
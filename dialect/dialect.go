@@ -113,6 +113,9 @@ type DDLColumnSpec struct {
 	// NativeType is the column type exactly as reported by the database.
 	// It is populated by InspectTableColumns and is empty on declared specs.
 	NativeType string
+	// RenamedFrom is the previous column name declared by the db tag option
+	// was:<old_name>. Reconcile renames that column instead of dropping it.
+	RenamedFrom string
 }
 
 type IndexDefinition struct {
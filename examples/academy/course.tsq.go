@@ -79,6 +79,54 @@ var Course__Cols = []tsq.BoundColumn[Course]{
 	Course_TrackID,
 }
 
+// ScanDest implements tsq.ScanBinder for Course without per-column closures.
+func (t *Course) ScanDest(cols []string) ([]any, bool) {
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "created_at":
+			dest[i] = &t.CreatedAt
+		case "id":
+			dest[i] = &t.ID
+		case "instructor_id":
+			dest[i] = &t.InstructorID
+		case "level":
+			dest[i] = &t.Level
+		case "list_price_cents":
+			dest[i] = &t.ListPriceCents
+		case "prerequisite_id":
+			dest[i] = &t.PrerequisiteID
+		case "published":
+			dest[i] = &t.Published
+		case "summary":
+			dest[i] = &t.Summary
+		case "title":
+			dest[i] = &t.Title
+		case "track_id":
+			dest[i] = &t.TrackID
+		default:
+			return nil, false
+		}
+	}
+	return dest, true
+}
+
+// MutationValues implements tsq.MutationBinder for Course in Course__Cols order.
+func (t *Course) MutationValues() []any {
+	return []any{
+		t.CreatedAt,
+		t.ID,
+		t.InstructorID,
+		t.Level,
+		t.ListPriceCents,
+		t.PrerequisiteID,
+		t.Published,
+		t.Summary,
+		t.Title,
+		t.TrackID,
+	}
+}
+
 // =============================================================================
 // Query by Primary Key
 // =============================================================================
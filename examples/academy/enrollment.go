@@ -11,6 +11,7 @@ import "database/sql/driver"
 //	created_at,
 //	updated_at,
 //	deleted_at,
+//	http=true,
 //	idx=[
 //		{fields=["LearnerID", "CourseID"]},
 //		{fields=["CourseID"]},
@@ -81,6 +81,54 @@ var Enrollment__Cols = []tsq.BoundColumn[Enrollment]{
 	Enrollment_Version,
 }
 
+// ScanDest implements tsq.ScanBinder for Enrollment without per-column closures.
+func (t *Enrollment) ScanDest(cols []string) ([]any, bool) {
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "course_id":
+			dest[i] = &t.CourseID
+		case "created_at":
+			dest[i] = &t.CreatedAt
+		case "deleted_at":
+			dest[i] = &t.DeletedAt
+		case "fee_cents":
+			dest[i] = &t.FeeCents
+		case "learner_id":
+			dest[i] = &t.LearnerID
+		case "score":
+			dest[i] = &t.Score
+		case "status":
+			dest[i] = &t.Status
+		case "uid":
+			dest[i] = &t.UID
+		case "updated_at":
+			dest[i] = &t.UpdatedAt
+		case "version":
+			dest[i] = &t.Version
+		default:
+			return nil, false
+		}
+	}
+	return dest, true
+}
+
+// MutationValues implements tsq.MutationBinder for Enrollment in Enrollment__Cols order.
+func (t *Enrollment) MutationValues() []any {
+	return []any{
+		t.CourseID,
+		t.CreatedAt,
+		t.DeletedAt,
+		t.FeeCents,
+		t.LearnerID,
+		t.Score,
+		t.Status,
+		t.UID,
+		t.UpdatedAt,
+		t.Version,
+	}
+}
+
 // =============================================================================
 // Query by Primary Key
 // =============================================================================
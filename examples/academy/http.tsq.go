@@ -0,0 +1,339 @@
+// Code generated by tsq-v4.5.0. DO NOT EDIT.
+
+package academy
+
+import (
+	tsqsql "database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tmoeish/tsq/v4"
+)
+
+// tsqHTTPMaxBodyBytes caps the JSON request body read by the generated handlers.
+const tsqHTTPMaxBodyBytes = 1 << 20
+
+// =============================================================================
+// Enrollment HTTP Handlers
+// =============================================================================
+
+// EnrollmentHandler serves list/get/create/update/delete endpoints for Enrollment.
+type EnrollmentHandler struct {
+	db tsq.SQLExecutor
+}
+
+// NewEnrollmentHandler returns a handler that runs its queries against db.
+func NewEnrollmentHandler(db tsq.SQLExecutor) *EnrollmentHandler {
+	return &EnrollmentHandler{db: db}
+}
+
+// Register mounts the handlers on mux under prefix, for example "/enrollment":
+//
+//	GET    prefix       paged list (page, size, order_by, order, keyword)
+//	POST   prefix       create
+//	GET    prefix/{id}  get by primary key
+//	PUT    prefix/{id}  update the JSON fields present in the body
+//	DELETE prefix/{id}  soft delete
+func (h *EnrollmentHandler) Register(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.HandleFunc("GET "+prefix, h.List)
+	mux.HandleFunc("POST "+prefix, h.Create)
+	mux.HandleFunc("GET "+prefix+"/{id}", h.Get)
+	mux.HandleFunc("PUT "+prefix+"/{id}", h.Update)
+	mux.HandleFunc("DELETE "+prefix+"/{id}", h.Delete)
+}
+
+// List writes one page of active Enrollment records selected by the query string.
+func (h *EnrollmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := QueryActiveEnrollment.Page(r.Context(), h.db, tsq.NewPageRequest(r.URL.Query()))
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, page)
+}
+
+// Get writes the Enrollment identified by the {id} path value.
+func (h *EnrollmentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, record)
+}
+
+// Create inserts a Enrollment decoded from the request body.
+// Server-managed fields sent by the client are ignored.
+func (h *EnrollmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	record := new(Enrollment)
+	if err := tsqHTTPDecode(w, r, record); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	var zero Enrollment
+	record.UID = zero.UID
+	record.Version = zero.Version
+	record.CreatedAt = zero.CreatedAt
+	record.DeletedAt = zero.DeletedAt
+
+	if err := record.Insert(r.Context(), h.db); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusCreated, record)
+}
+
+// Update applies the JSON fields in the request body to the Enrollment identified
+// by the {id} path value.
+// Send back the Version value that was read to have concurrent edits rejected with 409.
+func (h *EnrollmentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	loaded := *record
+	if err := tsqHTTPDecode(w, r, record); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	record.UID = loaded.UID
+	record.CreatedAt = loaded.CreatedAt
+	record.DeletedAt = loaded.DeletedAt
+
+	if err := record.Update(r.Context(), h.db); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, record)
+}
+
+// Delete soft-deletes the Enrollment identified by the {id} path value.
+func (h *EnrollmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	// An active record has no deletion time yet, so SoftDelete stamps the current time.
+	if err := record.SoftDelete(r.Context(), h.db, record.DeletedAt); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *EnrollmentHandler) load(r *http.Request) (*Enrollment, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil, &tsqHTTPStatusError{status: http.StatusBadRequest, err: fmt.Errorf("invalid id %q", r.PathValue("id"))}
+	}
+
+	return QueryActiveEnrollmentByUID.GetOrErr(r.Context(), h.db, id)
+}
+
+// =============================================================================
+// Track HTTP Handlers
+// =============================================================================
+
+// TrackHandler serves list/get/create/update/delete endpoints for Track.
+type TrackHandler struct {
+	db tsq.SQLExecutor
+}
+
+// NewTrackHandler returns a handler that runs its queries against db.
+func NewTrackHandler(db tsq.SQLExecutor) *TrackHandler {
+	return &TrackHandler{db: db}
+}
+
+// Register mounts the handlers on mux under prefix, for example "/track":
+//
+//	GET    prefix       paged list (page, size, order_by, order, keyword)
+//	POST   prefix       create
+//	GET    prefix/{id}  get by primary key
+//	PUT    prefix/{id}  update the JSON fields present in the body
+//	DELETE prefix/{id}  delete
+func (h *TrackHandler) Register(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mux.HandleFunc("GET "+prefix, h.List)
+	mux.HandleFunc("POST "+prefix, h.Create)
+	mux.HandleFunc("GET "+prefix+"/{id}", h.Get)
+	mux.HandleFunc("PUT "+prefix+"/{id}", h.Update)
+	mux.HandleFunc("DELETE "+prefix+"/{id}", h.Delete)
+}
+
+// List writes one page of Track records selected by the query string.
+func (h *TrackHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := QueryTrack.Page(r.Context(), h.db, tsq.NewPageRequest(r.URL.Query()))
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, page)
+}
+
+// Get writes the Track identified by the {id} path value.
+func (h *TrackHandler) Get(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, record)
+}
+
+// Create inserts a Track decoded from the request body.
+// Server-managed fields sent by the client are ignored.
+func (h *TrackHandler) Create(w http.ResponseWriter, r *http.Request) {
+	record := new(Track)
+	if err := tsqHTTPDecode(w, r, record); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	var zero Track
+	record.ID = zero.ID
+	record.CreatedAt = zero.CreatedAt
+
+	if err := record.Insert(r.Context(), h.db); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusCreated, record)
+}
+
+// Update applies the JSON fields in the request body to the Track identified
+// by the {id} path value.
+func (h *TrackHandler) Update(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	loaded := *record
+	if err := tsqHTTPDecode(w, r, record); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	record.ID = loaded.ID
+	record.CreatedAt = loaded.CreatedAt
+
+	if err := record.Update(r.Context(), h.db); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	tsqHTTPWriteJSON(w, http.StatusOK, record)
+}
+
+// Delete removes the Track identified by the {id} path value.
+func (h *TrackHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	record, err := h.load(r)
+	if err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	if err := record.Delete(r.Context(), h.db); err != nil {
+		tsqHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TrackHandler) load(r *http.Request) (*Track, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil, &tsqHTTPStatusError{status: http.StatusBadRequest, err: fmt.Errorf("invalid id %q", r.PathValue("id"))}
+	}
+
+	return QueryTrackByID.GetOrErr(r.Context(), h.db, id)
+}
+
+// =============================================================================
+// Shared Helpers
+// =============================================================================
+
+// tsqHTTPStatusError carries the HTTP status for errors raised by the handlers themselves.
+type tsqHTTPStatusError struct {
+	status int
+	err    error
+}
+
+func (e *tsqHTTPStatusError) Error() string { return e.err.Error() }
+
+func (e *tsqHTTPStatusError) Unwrap() error { return e.err }
+
+// tsqHTTPDecode decodes a single JSON object into dst, rejecting fields dst does not declare.
+func tsqHTTPDecode(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, tsqHTTPMaxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &tsqHTTPStatusError{status: http.StatusRequestEntityTooLarge, err: err}
+		}
+
+		return &tsqHTTPStatusError{status: http.StatusBadRequest, err: fmt.Errorf("decode request body: %w", err)}
+	}
+
+	if err := dec.Decode(new(json.RawMessage)); !errors.Is(err, io.EOF) {
+		return &tsqHTTPStatusError{status: http.StatusBadRequest, err: errors.New("request body must contain a single JSON object")}
+	}
+
+	return nil
+}
+
+// tsqHTTPError maps err to a status code and writes it as {"error": "..."}.
+// Unexpected errors are reported as 500 without exposing their message.
+func tsqHTTPError(w http.ResponseWriter, err error) {
+	status, message := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+
+	var statusErr *tsqHTTPStatusError
+	if errors.As(err, &statusErr) {
+		status, message = statusErr.status, statusErr.Error()
+	} else {
+		switch {
+		case errors.Is(err, tsqsql.ErrNoRows):
+			status, message = http.StatusNotFound, http.StatusText(http.StatusNotFound)
+		case tsq.IsOptimisticLockError(err):
+			status, message = http.StatusConflict, "the record was changed by someone else; reload it and retry"
+		case tsq.IsDuplicateKeyError(err):
+			status, message = http.StatusConflict, "a record with the same key already exists"
+		case errors.Is(err, &tsq.ErrUnknownSortField{}),
+			errors.Is(err, &tsq.ErrAmbiguousSortField{}),
+			errors.Is(err, &tsq.ErrOrderCountMismatch{}):
+			status, message = http.StatusBadRequest, err.Error()
+		}
+	}
+
+	tsqHTTPWriteJSON(w, status, map[string]string{"error": message})
+}
+
+func tsqHTTPWriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,96 @@
+package academy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedHTTPHandlers(t *testing.T) {
+	rt, cleanup, err := OpenSQLiteExampleDB()
+	if err != nil {
+		t.Fatalf("open example db: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	mux := http.NewServeMux()
+	NewEnrollmentHandler(rt).Register(mux, "/enrollments")
+	NewTrackHandler(rt).Register(mux, "/tracks/")
+
+	do := func(method, target, body string, wantStatus int, out any) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != wantStatus {
+			t.Fatalf("%s %s: status = %d, want %d; body: %s", method, target, rec.Code, wantStatus, rec.Body)
+		}
+
+		if out != nil {
+			if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+				t.Fatalf("%s %s: decode response: %v; body: %s", method, target, err, rec.Body)
+			}
+		}
+	}
+
+	var created Enrollment
+	do(http.MethodPost, "/enrollments", `{"uid": 999, "learner_id": 1, "course_id": 1, "score": 70, "version": 7, "deleted_at": 1, "created_at": "2001-01-01T00:00:00Z"}`, http.StatusCreated, &created)
+
+	if created.UID == 0 || created.UID == 999 || created.Score != 70 {
+		t.Fatalf("expected an auto-increment uid and the posted score, got %+v", created)
+	}
+
+	if created.Version != 0 || created.DeletedAt != 0 || created.CreatedAt.Year() == 2001 {
+		t.Fatalf("expected the managed fields sent by the client to be ignored, got %+v", created)
+	}
+
+	path := "/enrollments/" + strconv.FormatInt(created.UID, 10)
+
+	var updated Enrollment
+	do(http.MethodPut, path, `{"score": 95, "version": `+strconv.FormatInt(created.Version, 10)+`}`, http.StatusOK, &updated)
+
+	if updated.Score != 95 || updated.LearnerID != 1 || updated.Version != created.Version+1 {
+		t.Fatalf("expected a partial update that bumps the version, got %+v", updated)
+	}
+
+	// 用读到的旧版本号再写一次：乐观锁冲突。
+	do(http.MethodPut, path, `{"score": 10, "version": `+strconv.FormatInt(created.Version, 10)+`}`, http.StatusConflict, nil)
+	do(http.MethodPut, path, `{"grade": "A"}`, http.StatusBadRequest, nil)
+	do(http.MethodPost, "/enrollments", `{"score": 1} {"score": 2}`, http.StatusBadRequest, nil)
+
+	var page struct {
+		Total int64        `json:"total"`
+		Data  []Enrollment `json:"data"`
+	}
+	do(http.MethodGet, "/enrollments?size=1&order_by=uid&order=desc", "", http.StatusOK, &page)
+
+	if page.Total == 0 || len(page.Data) != 1 || page.Data[0].UID != created.UID {
+		t.Fatalf("expected the newest enrollment first, got %+v", page)
+	}
+
+	do(http.MethodGet, "/enrollments?order_by=no_such_column", "", http.StatusBadRequest, nil)
+	do(http.MethodGet, "/enrollments/not-a-number", "", http.StatusBadRequest, nil)
+
+	do(http.MethodDelete, path, "", http.StatusNoContent, nil)
+	do(http.MethodGet, path, "", http.StatusNotFound, nil)
+
+	var track Track
+	do(http.MethodPost, "/tracks", `{"name": "HTTP handlers", "description": "generated", "skill_items": []}`, http.StatusCreated, &track)
+
+	trackPath := "/tracks/" + strconv.FormatInt(track.ID, 10)
+	do(http.MethodGet, trackPath, "", http.StatusOK, &track)
+
+	if track.Name != "HTTP handlers" || !track.CreatedAt.Valid {
+		t.Fatalf("expected the created track, got %+v", track)
+	}
+
+	do(http.MethodPost, "/tracks", `{"name": "HTTP handlers", "description": "again", "skill_items": []}`, http.StatusConflict, nil)
+
+	do(http.MethodDelete, trackPath, "", http.StatusNoContent, nil)
+	do(http.MethodDelete, trackPath, "", http.StatusNotFound, nil)
+}
@@ -72,6 +72,42 @@ var Instructor__Cols = []tsq.BoundColumn[Instructor]{
 	Instructor_Specialty,
 }
 
+// ScanDest implements tsq.ScanBinder for Instructor without per-column closures.
+func (t *Instructor) ScanDest(cols []string) ([]any, bool) {
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "bio":
+			dest[i] = &t.Bio
+		case "created_at":
+			dest[i] = &t.CreatedAt
+		case "email":
+			dest[i] = &t.Email
+		case "id":
+			dest[i] = &t.ID
+		case "name":
+			dest[i] = &t.Name
+		case "specialty":
+			dest[i] = &t.Specialty
+		default:
+			return nil, false
+		}
+	}
+	return dest, true
+}
+
+// MutationValues implements tsq.MutationBinder for Instructor in Instructor__Cols order.
+func (t *Instructor) MutationValues() []any {
+	return []any{
+		t.Bio,
+		t.CreatedAt,
+		t.Email,
+		t.ID,
+		t.Name,
+		t.Specialty,
+	}
+}
+
 // =============================================================================
 // Query by Primary Key
 // =============================================================================
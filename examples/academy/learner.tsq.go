@@ -70,6 +70,39 @@ var Learner__Cols = []tsq.BoundColumn[Learner]{
 	Learner_Name,
 }
 
+// ScanDest implements tsq.ScanBinder for Learner without per-column closures.
+func (t *Learner) ScanDest(cols []string) ([]any, bool) {
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "company":
+			dest[i] = &t.Company
+		case "created_at":
+			dest[i] = &t.CreatedAt
+		case "email":
+			dest[i] = &t.Email
+		case "id":
+			dest[i] = &t.ID
+		case "name":
+			dest[i] = &t.Name
+		default:
+			return nil, false
+		}
+	}
+	return dest, true
+}
+
+// MutationValues implements tsq.MutationBinder for Learner in Learner__Cols order.
+func (t *Learner) MutationValues() []any {
+	return []any{
+		t.Company,
+		t.CreatedAt,
+		t.Email,
+		t.ID,
+		t.Name,
+	}
+}
+
 // =============================================================================
 // Query by Primary Key
 // =============================================================================
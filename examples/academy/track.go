@@ -8,6 +8,7 @@ import "encoding/json"
 //	name="track",
 //	pk="ID",
 //	created_at,
+//	http=true,
 //	ux=[
 //		{fields=["Name"]},
 //	],
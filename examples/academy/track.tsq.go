@@ -70,6 +70,39 @@ var Track__Cols = []tsq.BoundColumn[Track]{
 	Track_SkillItems,
 }
 
+// ScanDest implements tsq.ScanBinder for Track without per-column closures.
+func (t *Track) ScanDest(cols []string) ([]any, bool) {
+	dest := make([]any, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "created_at":
+			dest[i] = &t.CreatedAt
+		case "description":
+			dest[i] = &t.Description
+		case "id":
+			dest[i] = &t.ID
+		case "name":
+			dest[i] = &t.Name
+		case "skill_items":
+			dest[i] = &t.SkillItems
+		default:
+			return nil, false
+		}
+	}
+	return dest, true
+}
+
+// MutationValues implements tsq.MutationBinder for Track in Track__Cols order.
+func (t *Track) MutationValues() []any {
+	return []any{
+		t.CreatedAt,
+		t.Description,
+		t.ID,
+		t.Name,
+		t.SkillItems,
+	}
+}
+
 // =============================================================================
 // Query by Primary Key
 // =============================================================================
@@ -27,6 +27,12 @@ type ErrOptimisticLockConflict struct {
 	actual   int64
 }
 
+// NewErrOptimisticLockConflict constructs an ErrOptimisticLockConflict for table.
+// Executors and fakes outside this package use it to report the same error as Update and Delete.
+func NewErrOptimisticLockConflict(table string, expected int, actual int64) *ErrOptimisticLockConflict {
+	return &ErrOptimisticLockConflict{table: table, expected: expected, actual: actual}
+}
+
 // Error implements error.
 func (e *ErrOptimisticLockConflict) Error() string {
 	if e == nil {
@@ -56,3 +62,33 @@ func (e *ErrOptimisticLockConflict) Is(target error) bool {
 
 	return ok
 }
+
+// ErrDuplicateKey reports that a write collided with an existing primary key or
+// unique index. Database drivers report their own errors instead; IsDuplicateKeyError
+// recognizes both.
+type ErrDuplicateKey struct {
+	table string
+	index string
+}
+
+// NewErrDuplicateKey constructs an ErrDuplicateKey for index on table.
+func NewErrDuplicateKey(table, index string) *ErrDuplicateKey {
+	return &ErrDuplicateKey{table: table, index: index}
+}
+
+// Error implements error.
+func (e *ErrDuplicateKey) Error() string {
+	if e == nil || e.table == "" {
+		return "duplicate key"
+	}
+
+	return fmt.Sprintf("duplicate key on %s: %s", e.table, e.index)
+}
+
+// Is reports whether target is a duplicate key error.
+func (e *ErrDuplicateKey) Is(target error) bool {
+	var errDuplicateKey *ErrDuplicateKey
+	ok := errors.As(target, &errDuplicateKey)
+
+	return ok
+}
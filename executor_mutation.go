@@ -12,6 +12,16 @@ import (
 type mutationField struct {
 	column string
 	value  reflect.Value
+	arg    any  // arg snapshots the value reported by MutationBinder.
+	hasArg bool // hasArg reports whether arg replaces value.Interface().
+}
+
+func (f mutationField) argValue() any {
+	if f.hasArg {
+		return f.arg
+	}
+
+	return f.value.Interface()
 }
 
 type mutationRecord struct {
@@ -178,7 +188,7 @@ func insertBatch(ctx context.Context, exec SQLExecutor, records []mutationRecord
 
 		for _, field := range recordFields {
 			placeholders = append(placeholders, nextBindVar(exec, &argIndex))
-			args = append(args, field.value.Interface())
+			args = append(args, field.argValue())
 		}
 
 		valueClauses = append(valueClauses, "("+strings.Join(placeholders, ", ")+")")
@@ -196,6 +206,8 @@ func insertBatch(ctx context.Context, exec SQLExecutor, records []mutationRecord
 		return err
 	}
 
+	invalidateResultCache(exec, records[0].tableName)
+
 	assignBatchInsertIDs(exec, records, result, len(insertFields) != len(records[0].fields))
 
 	return nil
@@ -266,7 +278,7 @@ func updateBatch(ctx context.Context, exec SQLExecutor, records []mutationRecord
 			clause.WriteString(" THEN ")
 			clause.WriteString(nextBindVar(exec, &argIndex))
 
-			args = append(args, record.pkField.value.Interface(), recordField.value.Interface())
+			args = append(args, record.pkField.value.Interface(), recordField.argValue())
 		}
 
 		clause.WriteString(" ELSE ")
@@ -298,6 +310,8 @@ func updateBatch(ctx context.Context, exec SQLExecutor, records []mutationRecord
 		return 0, err
 	}
 
+	invalidateResultCache(exec, records[0].tableName)
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
@@ -352,6 +366,8 @@ func deleteBatch(ctx context.Context, exec SQLExecutor, records []mutationRecord
 		return 0, err
 	}
 
+	invalidateResultCache(exec, records[0].tableName)
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
@@ -50,6 +50,12 @@ func mutationMetadata(dst Table) (mutationRecord, error) {
 }
 
 func collectMutationFields(dst Table) ([]mutationField, error) {
+	if binder, ok := dst.(MutationBinder); ok {
+		if fields, ok := collectBoundMutationFields(dst, binder); ok {
+			return fields, nil
+		}
+	}
+
 	fields := make([]mutationField, 0, len(dst.Cols()))
 	for _, col := range dst.Cols() {
 		if isNilValue(col) {
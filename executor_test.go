@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"testing"
@@ -456,6 +457,22 @@ func TestIsOptimisticLockError(t *testing.T) {
 	}
 }
 
+func TestIsDuplicateKeyError(t *testing.T) {
+	err := fmt.Errorf("insert: %w", NewErrDuplicateKey("learner", "ux_learner_email"))
+	if !IsDuplicateKeyError(err) {
+		t.Fatal("expected wrapped ErrDuplicateKey to be detected")
+	}
+	if got := err.Error(); got != "insert: duplicate key on learner: ux_learner_email" {
+		t.Fatalf("unexpected message %q", got)
+	}
+	if !IsDuplicateKeyError(&fakeSQLiteCodeError{code: sqliteConstraintUniqueCode}) {
+		t.Fatal("expected SQLite unique violations to be detected")
+	}
+	if IsDuplicateKeyError(&ErrOptimisticLockConflict{}) {
+		t.Fatal("expected optimistic lock conflicts to be ignored")
+	}
+}
+
 func TestIsRetryableNetworkError(t *testing.T) {
 	if !IsRetryableNetworkError(driver.ErrBadConn) {
 		t.Fatal("expected driver.ErrBadConn to be retryable")
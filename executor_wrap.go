@@ -7,6 +7,9 @@ type wrappedExecutor struct {
 	SQLExecutor
 	dialect dialect.Dialect
 	runtime *Runtime
+
+	// resultTx defers result cache invalidation to commit for WithTx executors.
+	resultTx *resultCacheTx
 }
 
 func (w wrappedExecutor) tsqDialect() dialect.Dialect {
@@ -12,6 +12,7 @@ require (
 	golang.org/x/term v0.43.0
 	golang.org/x/tools v0.49.0
 	gopkg.in/nullbio/null.v6 v6.0.0-20161116030900-40264a2e6b79
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.51.0
 	mvdan.cc/gofumpt v0.11.0
 )
@@ -40,7 +41,6 @@ require (
 	golang.org/x/sys v0.47.0 // indirect
 	golang.org/x/text v0.37.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	modernc.org/libc v1.72.3 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
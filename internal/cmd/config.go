@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tmoeish/tsq/v4/internal/parser"
+)
+
+const genConfigFilename = "tsq.yaml"
+
+// genConfig 是 tsq.yaml。tsq gen 从包目录开始逐级向上找，直到模块根目录，用最近的一份，
+// 所以 monorepo 里的每个包都可以带自己的配置，不带的包共用模块根目录那份。
+type genConfig struct {
+	// Dialects 是要输出 SQL 文件的方言；tsq.json 总是记录全部方言的历史。
+	Dialects  []string           `yaml:"dialects"`
+	DDL       genDDLConfig       `yaml:"ddl"`
+	Naming    genNamingConfig    `yaml:"naming"`
+	Templates genTemplatesConfig `yaml:"templates"`
+	// Emit 是默认生成的可选输出，与 --emit 相同；命令行给了 --emit 时以命令行为准。
+	Emit  []string       `yaml:"emit"`
+	Proto genProtoConfig `yaml:"proto"`
+
+	// path 是配置文件路径，没有配置文件时为空。
+	path string
+}
+
+type genDDLConfig struct {
+	// Dir 是 SQL 文件、migrations 目录和 tsq.json 所在目录，相对于包目录。
+	Dir string `yaml:"dir"`
+	// Files 按方言改聚合 SQL 文件的文件名。
+	Files      map[string]string `yaml:"files"`
+	Migrations string            `yaml:"migrations"`
+	StringSize int               `yaml:"string_size"`
+	// Types 把 Go 类型（如 github.com/shopspring/decimal.Decimal）映射成 DDL 列类型，
+	// 相当于给这些字段默认加上 db 标签选项 type:。
+	Types map[string]string `yaml:"types"`
+}
+
+type genNamingConfig struct {
+	Columns string `yaml:"columns"`
+	Indexes string `yaml:"indexes"`
+}
+
+// genProtoConfig 是 --emit proto 的选项。
+type genProtoConfig struct {
+	// Package 是 .proto 的 package，默认是 Go 包名。
+	Package string `yaml:"package"`
+	// GoPackage 是 protoc-gen-go 生成代码的导入路径，可以写成 "path;name" 指定包名；默认是
+	// <包导入路径>/<包名>pb。转换函数从这里导入消息类型。
+	GoPackage string `yaml:"go_package"`
+}
+
+// genTemplatesConfig 的路径相对于 tsq.yaml 所在目录。
+type genTemplatesConfig struct {
+	Table  string `yaml:"table"`
+	Result string `yaml:"result"`
+}
+
+// loadGenConfig 找到并读取 packageDir 适用的 tsq.yaml；找不到时返回零值配置。
+func loadGenConfig(packageDir string) (*genConfig, error) {
+	dir, err := filepath.Abs(packageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		filename := filepath.Join(dir, genConfigFilename)
+
+		content, err := os.ReadFile(filename)
+		if err == nil {
+			return parseGenConfig(filename, content)
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		// 模块根目录之外的 tsq.yaml 属于别的项目。
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return &genConfig{}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return &genConfig{}, nil
+		}
+
+		dir = parent
+	}
+}
+
+func parseGenConfig(filename string, content []byte) (*genConfig, error) {
+	config := &genConfig{path: filename}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filename, err)
+	}
+
+	return config, nil
+}
+
+func (c *genConfig) validate() error {
+	known := make([]string, 0, len(ddlDialects))
+	for _, dialect := range ddlDialects {
+		known = append(known, ddlDialectName(dialect))
+	}
+
+	for _, name := range c.Dialects {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("dialects: unknown dialect %q: use %s", name, strings.Join(known, ", "))
+		}
+	}
+
+	if filepath.IsAbs(c.DDL.Dir) {
+		return fmt.Errorf("ddl.dir must be relative to the package directory, got %s", c.DDL.Dir)
+	}
+
+	seen := make(map[string]string, len(c.DDL.Files))
+	for dialect, name := range c.DDL.Files {
+		if !slices.Contains(known, dialect) {
+			return fmt.Errorf("ddl.files: unknown dialect %q: use %s", dialect, strings.Join(known, ", "))
+		}
+
+		if name != filepath.Base(name) || !strings.HasSuffix(name, ".sql") {
+			return fmt.Errorf("ddl.files.%s must be a file name ending in .sql, got %q", dialect, name)
+		}
+
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("ddl.files: %s and %s both write %s", other, dialect, name)
+		}
+
+		seen[name] = dialect
+	}
+
+	if err := validateDDLMigrationsFormat(c.DDL.Migrations); err != nil {
+		return fmt.Errorf("ddl.migrations: %w", err)
+	}
+
+	if c.DDL.StringSize < 0 {
+		return fmt.Errorf("ddl.string_size must be positive, got %d", c.DDL.StringSize)
+	}
+
+	for goType, sqlType := range c.DDL.Types {
+		if strings.TrimSpace(sqlType) == "" {
+			return fmt.Errorf("ddl.types: %s maps to an empty column type", goType)
+		}
+	}
+
+	if err := c.naming().Validate(); err != nil {
+		return fmt.Errorf("naming: %w", err)
+	}
+
+	if err := validateGenOutputs(c.Emit); err != nil {
+		return fmt.Errorf("emit: %w", err)
+	}
+
+	if c.Proto.Package != "" && !protoPackagePattern.MatchString(c.Proto.Package) {
+		return fmt.Errorf("proto.package must be a dotted protobuf package name, got %q", c.Proto.Package)
+	}
+
+	if c.Proto.GoPackage != "" {
+		if name := resolveProtoGoPackage(c, genPluginPackage{}).name; !token.IsIdentifier(name) {
+			return fmt.Errorf("proto.go_package: %q is not a Go package name; write it as \"path;name\"", name)
+		}
+	}
+
+	return nil
+}
+
+func (c *genConfig) naming() parser.Naming {
+	return parser.Naming{Columns: c.Naming.Columns, Indexes: c.Naming.Indexes}
+}
+
+// resolvePath 把 tsq.yaml 里的相对路径换成相对于配置文件所在目录的路径。
+func (c *genConfig) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || c.path == "" {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(c.path), path)
+}
+
+// ddlDir 返回包目录对应的 DDL 输出目录。
+func (c *genConfig) ddlDir(packageDir string) string {
+	if c.DDL.Dir == "" {
+		return packageDir
+	}
+
+	return filepath.Join(packageDir, c.DDL.Dir)
+}
+
+// emitsDialect 报告是否输出该方言的 SQL 文件；没有配置 dialects 时输出全部方言。
+func (c *genConfig) emitsDialect(name string) bool {
+	return len(c.Dialects) == 0 || slices.Contains(c.Dialects, name)
+}
+
+// ddlFilename 返回该方言聚合 SQL 文件的文件名。
+func (c *genConfig) ddlFilename(name string) string {
+	if filename := c.DDL.Files[name]; filename != "" {
+		return filename
+	}
+
+	return name + ".sql"
+}
+
+func (c *genConfig) typeOptions() ddlTypeOptions {
+	return ddlTypeOptions{stringSize: c.DDL.StringSize, types: c.DDL.Types}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGenConfigUsesNearestFileWithinModule(t *testing.T) {
+	root := t.TempDir()
+	module := filepath.Join(root, "module")
+	pkg := filepath.Join(module, "internal", "db")
+
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(root, genConfigFilename), "dialects: [mysql]\n")
+	writeTestFile(t, filepath.Join(module, "go.mod"), "module example.com/configtest\n")
+
+	// 模块根目录之外的 tsq.yaml 不生效。
+	config, err := loadGenConfig(pkg)
+	if err != nil || config.path != "" || !config.emitsDialect("sqlite") {
+		t.Fatalf("expected no configuration, got %+v, %v", config, err)
+	}
+
+	writeTestFile(t, filepath.Join(module, genConfigFilename), "dialects: [postgres]\ntemplates:\n  table: tpl/table.tmpl\n")
+
+	config, err = loadGenConfig(pkg)
+	if err != nil {
+		t.Fatalf("loadGenConfig() error = %v", err)
+	}
+
+	if config.emitsDialect("sqlite") || !config.emitsDialect("postgres") {
+		t.Fatalf("expected the module tsq.yaml to apply, got %+v", config)
+	}
+
+	if got, want := config.resolvePath(config.Templates.Table), filepath.Join(module, "tpl", "table.tmpl"); got != want {
+		t.Fatalf("template path = %s, want %s", got, want)
+	}
+
+	// 包目录里的配置整份替换上层配置，不做合并。
+	writeTestFile(t, filepath.Join(pkg, genConfigFilename), "ddl:\n  dir: schema\n")
+
+	config, err = loadGenConfig(pkg)
+	if err != nil || !config.emitsDialect("sqlite") || config.ddlDir(pkg) != filepath.Join(pkg, "schema") {
+		t.Fatalf("expected the package tsq.yaml to win, got %+v, %v", config, err)
+	}
+}
+
+func TestParseGenConfigRejectsInvalidSettings(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		want    string
+	}{
+		{"dialect: [sqlite]\n", "field dialect not found"},
+		{"dialects: [oracle]\n", `unknown dialect "oracle"`},
+		{"ddl:\n  files:\n    sqlite: db/sqlite.sql\n", "must be a file name ending in .sql"},
+		{"ddl:\n  files:\n    sqlite: schema.sql\n    mysql: schema.sql\n", "both write schema.sql"},
+		{"ddl:\n  migrations: flyway\n", "unsupported migrations format"},
+		{"ddl:\n  string_size: -1\n", "string_size must be positive"},
+		{"ddl:\n  types:\n    time.Duration: \"\"\n", "maps to an empty column type"},
+		{"naming:\n  columns: kebab\n", "unknown column naming"},
+	} {
+		if _, err := parseGenConfig(genConfigFilename, []byte(tc.content)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("parseGenConfig(%q) error = %v, want %q", tc.content, err, tc.want)
+		}
+	}
+}
+
+func TestGenCmdAppliesProjectConfiguration(t *testing.T) {
+	t.Cleanup(func() {
+		v = false
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, genConfigFilename), `dialects: [postgres]
+ddl:
+  dir: schema
+  files:
+    postgres: schema.pg.sql
+  string_size: 100
+  types:
+    example.com/gentest.Money: NUMERIC(20,4)
+naming:
+  columns: camelCase
+  indexes: "{table}_{fields}_{kind}"
+`)
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+type Money struct {
+	Units int64
+	Nanos int32
+}
+
+// @TABLE(name="users", ux=[{fields=["EmailAddress"]}])
+type User struct {
+	ID           int64  `+"`db:\"id\"`"+`
+	EmailAddress string `+"`db:\",size:64\"`"+`
+	FirstName    string `+"`db:\",allow_destructive\"`"+`
+	Balance      Money  `+"`db:\"balance\"`"+`
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stderr := new(bytes.Buffer)
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(stderr)
+	GenCmd.SetArgs([]string{"."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	if got := stderr.String(); !strings.Contains(got, "postgres="+filepath.Join("schema", "schema.pg.sql")) || strings.Contains(got, "sqlite=") {
+		t.Fatalf("expected guidance for the configured file only, got:\n%s", got)
+	}
+
+	for _, name := range []string{"sqlite.sql", "mysql.sql", "postgres.sql", ddlStateFilename} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected no %s beside the package, got err=%v", name, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "schema", ddlStateFilename)); err != nil {
+		t.Fatalf("expected tsq.json under ddl.dir: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "schema", "schema.pg.sql"))
+	if err != nil {
+		t.Fatalf("read configured DDL file: %v", err)
+	}
+
+	ddl := string(content)
+	for _, part := range []string{
+		`"emailAddress" VARCHAR(64) NOT NULL`,
+		`"firstName" VARCHAR(100) NOT NULL`,
+		`"balance" NUMERIC(20,4) NOT NULL`,
+		`"users_email_address_ux"`,
+	} {
+		if !strings.Contains(ddl, part) {
+			t.Fatalf("expected DDL to contain %q, got:\n%s", part, ddl)
+		}
+	}
+}
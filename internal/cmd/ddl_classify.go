@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ddlChangeClass 描述一条 DDL 变更上线时的风险等级。
+type ddlChangeClass string
+
+const (
+	// ddlChangeSafe 只改元数据或新增对象，不锁表、不丢数据。
+	ddlChangeSafe ddlChangeClass = "safe"
+	// ddlChangeBlocking 会长时间锁表、重写或重建表，或者在已有数据上可能失败，但不丢数据。
+	ddlChangeBlocking ddlChangeClass = "blocking"
+	// ddlChangeDestructive 会丢失已有数据。
+	ddlChangeDestructive ddlChangeClass = "destructive"
+)
+
+// ddlChangeAssessment 是一条变更的分类结果，同时也是 JSON 报告里的一项。
+type ddlChangeAssessment struct {
+	Table  string         `json:"table"`
+	Change string         `json:"change"`
+	Class  ddlChangeClass `json:"class"`
+	Reason string         `json:"reason,omitempty"`
+	// Acknowledged 表示破坏性变更已经由 @TABLE(allow_destructive=true)、@TABLE(allow_drop=[...])
+	// 或字段的 allow_destructive 标签选项确认过，不需要 --allow-destructive。
+	Acknowledged bool `json:"acknowledged,omitempty"`
+}
+
+type ddlChangeReport struct {
+	Package string                `json:"package"`
+	Changes []ddlChangeAssessment `json:"changes"`
+	Summary ddlChangeReportCounts `json:"summary"`
+	// Refused 表示不带 --allow-destructive 时 tsq gen 会拒绝记录这次变更。
+	Refused bool `json:"refused"`
+}
+
+type ddlChangeReportCounts struct {
+	Safe        int `json:"safe"`
+	Blocking    int `json:"blocking"`
+	Destructive int `json:"destructive"`
+}
+
+// classifyDDLChanges 按 tsq.json 记录行的顺序给每条变更分类。
+func classifyDDLChanges(changes ddlChangeSet) []ddlChangeAssessment {
+	var result []ddlChangeAssessment
+
+	for _, tableName := range changes.Tables {
+		ops := append([]ddlChange(nil), changes.ByTable[tableName]...)
+		sort.SliceStable(ops, func(i, j int) bool {
+			return compareDDLChanges(ops[i], ops[j]) < 0
+		})
+
+		for _, op := range ops {
+			line, _ := classifyDDLRecordLine(op)
+			if line == "" {
+				continue
+			}
+
+			class, reason := classifyDDLChange(op)
+			result = append(result, ddlChangeAssessment{
+				Table:        tableName,
+				Change:       line,
+				Class:        class,
+				Reason:       reason,
+				Acknowledged: class == ddlChangeDestructive && ddlChangeDestructiveAcknowledged(op),
+			})
+		}
+	}
+
+	return result
+}
+
+// classifyDDLChange 给出与方言无关的分类：按最保守的方言判断，例如 SQLite 改列一律重建表。
+func classifyDDLChange(op ddlChange) (ddlChangeClass, string) {
+	switch op.kind {
+	case ddlChangeCreateTable, ddlChangeRenameTable, ddlChangeRenameColumn:
+		return ddlChangeSafe, ""
+	case ddlChangeDropTable:
+		return ddlChangeDestructive, "drops the table and its rows"
+	case ddlChangeDropColumn:
+		return ddlChangeDestructive, "drops the column and its values"
+	case ddlChangeAddColumn:
+		if op.newColumn.PrimaryKey || op.newColumn.AutoIncrement {
+			return ddlChangeBlocking, "adding a primary key column rewrites the table"
+		}
+
+		if !op.newColumn.Nullable && op.newColumn.Default == "" {
+			return ddlChangeBlocking, "NOT NULL without a default fails or backfills on existing rows"
+		}
+
+		return ddlChangeSafe, ""
+	case ddlChangeAlterColumn:
+		return classifyDDLAlterColumn(*op.oldColumn, *op.newColumn)
+	case ddlChangeAddIndex:
+		if op.newIndex.Unique {
+			return ddlChangeBlocking, "builds the index under a table lock and fails on duplicate rows"
+		}
+
+		return ddlChangeBlocking, "builds the index under a table lock"
+	case ddlChangeDropIndex:
+		if op.oldIndex.Unique {
+			return ddlChangeBlocking, "removes a uniqueness guarantee that queries may rely on"
+		}
+
+		return ddlChangeBlocking, "queries relying on the index lose it"
+	default:
+		return ddlChangeSafe, ""
+	}
+}
+
+func classifyDDLAlterColumn(before, after ddlSnapshotColumn) (ddlChangeClass, string) {
+	switch {
+	case before.PrimaryKey != after.PrimaryKey || before.AutoIncrement != after.AutoIncrement:
+		return ddlChangeDestructive, "changes the primary key"
+	case before.RawType != after.RawType:
+		return ddlChangeDestructive, "converts to an explicit SQL type tsq cannot compare"
+	case before.Kind != after.Kind:
+		return ddlChangeDestructive, fmt.Sprintf("converts %s to %s", before.Kind, after.Kind)
+	case before.Unsigned != after.Unsigned:
+		return ddlChangeDestructive, "changes signedness"
+	case after.Bits < before.Bits:
+		return ddlChangeDestructive, fmt.Sprintf("narrows %d-bit values to %d bits", before.Bits, after.Bits)
+	case after.Size < before.Size:
+		return ddlChangeDestructive, fmt.Sprintf("truncates values longer than %d", after.Size)
+	case before.Nullable && !after.Nullable:
+		return ddlChangeBlocking, "NOT NULL fails on existing NULL values"
+	default:
+		return ddlChangeBlocking, "rewrites the column; sqlite rebuilds the table"
+	}
+}
+
+func ddlChangeDestructiveAcknowledged(op ddlChange) bool {
+	return ddlChangeTableAcknowledged(op) || ddlChangeDropAcknowledged(op) || ddlChangeColumnAcknowledged(op)
+}
+
+func ddlChangeTableAcknowledged(op ddlChange) bool {
+	return op.newTable != nil && op.newTable.AllowDestructive
+}
+
+// ddlChangeDropAcknowledged 判断删除的列是否列在 @TABLE(allow_drop=[...]) 里；字段已经删掉，没有标签可写。
+func ddlChangeDropAcknowledged(op ddlChange) bool {
+	return op.kind == ddlChangeDropColumn && op.newTable != nil && slices.Contains(op.newTable.AllowDrop, op.oldColumn.Name)
+}
+
+func ddlChangeColumnAcknowledged(op ddlChange) bool {
+	return op.newColumn != nil && op.newColumn.AllowDestructive
+}
+
+// unusedDDLDestructiveAcknowledgements 列出这次没有确认任何破坏性变更的注解。这些注解留在代码里会
+// 悄悄放行以后的破坏性变更，所以每次 tsq gen 都提醒删掉。
+func unusedDDLDestructiveAcknowledgements(snapshot ddlSnapshot, changes ddlChangeSet) []string {
+	usedTables := make(map[string]bool)
+	usedDrops := make(map[string]bool)
+	usedColumns := make(map[string]bool)
+
+	for _, tableName := range changes.Tables {
+		for _, op := range changes.ByTable[tableName] {
+			if class, _ := classifyDDLChange(op); class != ddlChangeDestructive {
+				continue
+			}
+
+			if ddlChangeTableAcknowledged(op) {
+				usedTables[tableName] = true
+			}
+
+			if ddlChangeDropAcknowledged(op) {
+				usedDrops[tableName+"."+op.oldColumn.Name] = true
+			}
+
+			if ddlChangeColumnAcknowledged(op) {
+				usedColumns[tableName+"."+op.newColumn.Name] = true
+			}
+		}
+	}
+
+	var result []string
+
+	for _, table := range snapshot.Tables {
+		if table.AllowDestructive && !usedTables[table.Name] {
+			result = append(result, fmt.Sprintf("%s: @TABLE(allow_destructive=true) acknowledges no destructive change; remove it", table.Name))
+		}
+
+		for _, column := range table.AllowDrop {
+			if !usedDrops[table.Name+"."+column] {
+				result = append(result, fmt.Sprintf("%s: allow_drop entry %s matches no dropped column; remove it", table.Name, column))
+			}
+		}
+
+		for _, column := range table.Columns {
+			if column.AllowDestructive && !usedColumns[table.Name+"."+column.Name] {
+				result = append(result, fmt.Sprintf("%s: db tag option allow_destructive on %s acknowledges no destructive change; remove it", table.Name, column.Name))
+			}
+		}
+	}
+
+	return result
+}
+
+// unacknowledgedDestructiveDDLChanges 返回需要 --allow-destructive 才能记录的变更。
+func unacknowledgedDestructiveDDLChanges(assessments []ddlChangeAssessment) []ddlChangeAssessment {
+	var result []ddlChangeAssessment
+
+	for _, item := range assessments {
+		if item.Class == ddlChangeDestructive && !item.Acknowledged {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func newDDLDestructiveChangeError(items []ddlChangeAssessment) error {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("  %s: %s (%s)", item.Table, item.Change, item.Reason))
+	}
+
+	return fmt.Errorf(
+		"refusing to record destructive schema changes:\n%s\n"+
+			"rerun with --allow-destructive, or acknowledge them with the db tag option allow_destructive on the field, "+
+			"@TABLE(allow_drop=[\"<column>\"]) for a dropped column, or @TABLE(allow_destructive=true)",
+		strings.Join(lines, "\n"),
+	)
+}
+
+func buildDDLChangeReport(packagePath string, assessments []ddlChangeAssessment, allowDestructive bool) ddlChangeReport {
+	report := ddlChangeReport{
+		Package: packagePath,
+		Changes: append([]ddlChangeAssessment{}, assessments...),
+		Refused: !allowDestructive && len(unacknowledgedDestructiveDDLChanges(assessments)) > 0,
+	}
+
+	for _, item := range assessments {
+		switch item.Class {
+		case ddlChangeSafe:
+			report.Summary.Safe++
+		case ddlChangeBlocking:
+			report.Summary.Blocking++
+		case ddlChangeDestructive:
+			report.Summary.Destructive++
+		}
+	}
+
+	return report
+}
+
+// writeDDLChangeReport 把报告写到 filename；"-" 表示写到 stdout。tsq gen ./... 写的是按包排列的报告数组。
+func writeDDLChangeReport(stdout io.Writer, filename string, report any) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode DDL change report: %w", err)
+	}
+
+	content = append(content, '\n')
+
+	if filename == "-" {
+		_, err := stdout.Write(content)
+		return err
+	}
+
+	if err := os.WriteFile(filename, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write DDL change report: %s: %w", filename, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestClassifyDDLChange(t *testing.T) {
+	t.Parallel()
+
+	column := func(mutate func(*ddlSnapshotColumn)) *ddlSnapshotColumn {
+		item := ddlSnapshotColumn{Name: "c", Kind: ddlColumnString, Size: 64}
+		if mutate != nil {
+			mutate(&item)
+		}
+
+		return &item
+	}
+
+	tests := []struct {
+		name string
+		op   ddlChange
+		want ddlChangeClass
+	}{
+		{"create table", ddlChange{kind: ddlChangeCreateTable}, ddlChangeSafe},
+		{"rename column", ddlChange{kind: ddlChangeRenameColumn}, ddlChangeSafe},
+		{"nullable column", ddlChange{kind: ddlChangeAddColumn, newColumn: column(func(c *ddlSnapshotColumn) { c.Nullable = true })}, ddlChangeSafe},
+		{"defaulted column", ddlChange{kind: ddlChangeAddColumn, newColumn: column(func(c *ddlSnapshotColumn) { c.Default = "''" })}, ddlChangeSafe},
+		{"not null column", ddlChange{kind: ddlChangeAddColumn, newColumn: column(nil)}, ddlChangeBlocking},
+		{"add index", ddlChange{kind: ddlChangeAddIndex, newIndex: &ddlSnapshotIndex{Name: "idx"}}, ddlChangeBlocking},
+		{"drop index", ddlChange{kind: ddlChangeDropIndex, oldIndex: &ddlSnapshotIndex{Name: "idx"}}, ddlChangeBlocking},
+		{"widen string", ddlChange{kind: ddlChangeAlterColumn, oldColumn: column(nil), newColumn: column(func(c *ddlSnapshotColumn) { c.Size = 128 })}, ddlChangeBlocking},
+		{"tighten nullability", ddlChange{kind: ddlChangeAlterColumn, oldColumn: column(func(c *ddlSnapshotColumn) { c.Nullable = true }), newColumn: column(nil)}, ddlChangeBlocking},
+		{"narrow string", ddlChange{kind: ddlChangeAlterColumn, oldColumn: column(nil), newColumn: column(func(c *ddlSnapshotColumn) { c.Size = 32 })}, ddlChangeDestructive},
+		{"narrow int", ddlChange{
+			kind:      ddlChangeAlterColumn,
+			oldColumn: &ddlSnapshotColumn{Name: "c", Kind: ddlColumnInt, Bits: 64},
+			newColumn: &ddlSnapshotColumn{Name: "c", Kind: ddlColumnInt, Bits: 32},
+		}, ddlChangeDestructive},
+		{"convert kind", ddlChange{kind: ddlChangeAlterColumn, oldColumn: column(nil), newColumn: column(func(c *ddlSnapshotColumn) { c.Kind = ddlColumnInt })}, ddlChangeDestructive},
+		{"drop column", ddlChange{kind: ddlChangeDropColumn, oldColumn: column(nil)}, ddlChangeDestructive},
+		{"drop table", ddlChange{kind: ddlChangeDropTable, oldTable: &ddlSnapshotTable{Name: "t"}}, ddlChangeDestructive},
+	}
+
+	for _, tt := range tests {
+		if got, reason := classifyDDLChange(tt.op); got != tt.want || (got != ddlChangeSafe && reason == "") {
+			t.Errorf("%s: classifyDDLChange() = %s (%q), want %s with a reason", tt.name, got, reason, tt.want)
+		}
+	}
+}
+
+func TestClassifyDDLChangesHonorsAnnotations(t *testing.T) {
+	t.Parallel()
+
+	previous := ddlSnapshot{Tables: []ddlSnapshotTable{
+		{Name: "items", Columns: []ddlSnapshotColumn{{Name: "sku", Kind: ddlColumnString, Size: 64}, {Name: "legacy", Kind: ddlColumnBool}}},
+		{Name: "users", Columns: []ddlSnapshotColumn{{Name: "name", Kind: ddlColumnString, Size: 64}}},
+	}}
+	current := ddlSnapshot{Tables: []ddlSnapshotTable{
+		{Name: "items", AllowDestructive: true, Columns: []ddlSnapshotColumn{{Name: "sku", Kind: ddlColumnString, Size: 64}}},
+		{Name: "users", Columns: []ddlSnapshotColumn{{Name: "name", Kind: ddlColumnString, Size: 32, AllowDestructive: true}}},
+	}}
+
+	assessments := classifyDDLChanges(diffDDLSnapshots(&previous, current))
+	if len(assessments) != 2 {
+		t.Fatalf("classifyDDLChanges() = %+v, want two changes", assessments)
+	}
+
+	for _, item := range assessments {
+		if item.Class != ddlChangeDestructive || !item.Acknowledged {
+			t.Fatalf("expected annotated destructive change to be acknowledged, got %+v", item)
+		}
+	}
+
+	if refused := unacknowledgedDestructiveDDLChanges(assessments); len(refused) != 0 {
+		t.Fatalf("expected no refused changes, got %+v", refused)
+	}
+
+	current.Tables[0].AllowDestructive = false
+
+	refused := unacknowledgedDestructiveDDLChanges(classifyDDLChanges(diffDDLSnapshots(&previous, current)))
+	if len(refused) != 1 || refused[0].Table != "items" || refused[0].Change != "drop column legacy" {
+		t.Fatalf("expected the unannotated drop to be refused, got %+v", refused)
+	}
+}
+
+func TestClassifyDDLChangesAcknowledgesListedDrops(t *testing.T) {
+	t.Parallel()
+
+	previous := ddlSnapshot{Tables: []ddlSnapshotTable{{Name: "items", Columns: []ddlSnapshotColumn{
+		{Name: "sku", Kind: ddlColumnString, Size: 64},
+		{Name: "legacy", Kind: ddlColumnBool},
+		{Name: "notes", Kind: ddlColumnString, Size: 64},
+	}}}}
+	current := ddlSnapshot{Tables: []ddlSnapshotTable{{Name: "items", AllowDrop: []string{"legacy"}, Columns: []ddlSnapshotColumn{
+		{Name: "sku", Kind: ddlColumnString, Size: 32},
+	}}}}
+
+	changes := diffDDLSnapshots(&previous, current)
+
+	refused := unacknowledgedDestructiveDDLChanges(classifyDDLChanges(changes))
+	if len(refused) != 2 || refused[0].Change != "alter column sku (type)" || refused[1].Change != "drop column notes" {
+		t.Fatalf("expected only the listed drop to be acknowledged, got %+v", refused)
+	}
+
+	if warnings := unusedDDLDestructiveAcknowledgements(current, changes); len(warnings) != 0 {
+		t.Fatalf("expected no warnings while allow_drop is in use, got %q", warnings)
+	}
+}
+
+func TestUnusedDDLDestructiveAcknowledgements(t *testing.T) {
+	t.Parallel()
+
+	// 变更记录之后快照不再有差异，留在代码里的注解都应该提醒删除。
+	current := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name:             "items",
+		AllowDestructive: true,
+		AllowDrop:        []string{"legacy"},
+		Columns:          []ddlSnapshotColumn{{Name: "sku", Kind: ddlColumnString, Size: 32, AllowDestructive: true}},
+	}}}
+
+	got := unusedDDLDestructiveAcknowledgements(current, diffDDLSnapshots(&current, current))
+	want := []string{
+		"items: @TABLE(allow_destructive=true) acknowledges no destructive change; remove it",
+		"items: allow_drop entry legacy matches no dropped column; remove it",
+		"items: db tag option allow_destructive on sku acknowledges no destructive change; remove it",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("unusedDDLDestructiveAcknowledgements() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteDDLChangeReportToStdout(t *testing.T) {
+	t.Parallel()
+
+	assessments := []ddlChangeAssessment{
+		{Table: "users", Change: "add index idx_name", Class: ddlChangeBlocking, Reason: "builds the index under a table lock"},
+		{Table: "users", Change: "drop column age", Class: ddlChangeDestructive, Reason: "drops the column and its values"},
+	}
+
+	stdout := new(bytes.Buffer)
+	if err := writeDDLChangeReport(stdout, "-", buildDDLChangeReport("./db", assessments, true)); err != nil {
+		t.Fatalf("writeDDLChangeReport() error = %v", err)
+	}
+
+	var report ddlChangeReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, stdout.String())
+	}
+
+	if report.Refused || report.Summary.Blocking != 1 || report.Summary.Destructive != 1 || len(report.Changes) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if !buildDDLChangeReport("./db", assessments, false).Refused {
+		t.Fatal("expected the report to flag the run as refused without --allow-destructive")
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	ddlMigrationsGolangMigrate = "golang-migrate"
+	ddlMigrationsGoose         = "goose"
+
+	// ddlMigrationsDirname 是编号迁移文件所在的子目录，每个方言再分一层。
+	ddlMigrationsDirname = "migrations"
+)
+
+func validateDDLMigrationsFormat(format string) error {
+	switch format {
+	case "", ddlMigrationsGolangMigrate, ddlMigrationsGoose:
+		return nil
+	default:
+		return fmt.Errorf("unsupported migrations format %q: use %s or %s", format, ddlMigrationsGolangMigrate, ddlMigrationsGoose)
+	}
+}
+
+// ddlMigrationDirs 返回各方言的编号迁移目录；不论本次用哪种输出，都要在这些目录里找过期文件。
+func ddlMigrationDirs(outDir string) []string {
+	dirs := make([]string, 0, len(ddlDialects))
+	for _, dialect := range ddlDialects {
+		dirs = append(dirs, filepath.Join(outDir, ddlMigrationsDirname, ddlDialectName(dialect)))
+	}
+
+	return dirs
+}
+
+// renderDDLMigrationFiles 把 tsq migrate 的迁移步骤（初始 schema 或压缩基线，加上之后的每条记录）
+// 写成 golang-migrate / goose 认识的编号文件。编号按从未压缩过的历史计算：压缩基线占用被并入
+// 的最后一步的编号，已经迁移到那一步的数据库不会再执行它。dialects 为空时输出全部方言。
+func renderDDLMigrationFiles(state ddlStateFile, outDir, format, version string, dialects []string) ([]ddlFileModel, error) {
+	number := 1
+	baseline := initialMigrationSequence
+
+	if state.Squash != nil {
+		number = 0
+		for _, sequence := range state.Squash.Replaces {
+			if !strings.HasPrefix(sequence, squashSequencePrefix) {
+				number++
+			}
+		}
+
+		baseline = state.Squash.Sequence
+	}
+
+	models := make([]ddlFileModel, 0, 2*len(ddlDialects)*(len(state.Records)+1))
+
+	for _, dialect := range ddlDialects {
+		name := ddlDialectName(dialect)
+		if len(dialects) > 0 && !slices.Contains(dialects, name) {
+			continue
+		}
+
+		dir := filepath.Join(outDir, ddlMigrationsDirname, name)
+
+		initial, ok := state.InitialDialects[name]
+		if !ok || strings.TrimSpace(initial.SQL) == "" {
+			return nil, fmt.Errorf("missing initial DDL for dialect %s", name)
+		}
+
+		slug := "initial"
+		if state.Squash != nil {
+			slug = "squash"
+		}
+
+		models = append(models, renderDDLMigrationFile(dir, format, version, number, slug, baseline, trimDDLHeader(initial.SQL), "")...)
+
+		for i, record := range state.Records[state.RenderedRecords:] {
+			diff := record.Dialects[name]
+
+			models = append(models, renderDDLMigrationFile(
+				dir,
+				format,
+				version,
+				number+1+i,
+				ddlMigrationSlug(record.Tables),
+				record.Sequence,
+				diff.AggregateSQL,
+				diff.DownSQL,
+			)...)
+		}
+	}
+
+	return models, nil
+}
+
+func renderDDLMigrationFile(dir, format, version string, number int, slug, sequence, upSQL, downSQL string) []ddlFileModel {
+	prefix := filepath.Join(dir, fmt.Sprintf("%04d_%s", number, slug))
+	up := renderDDLHistorySection(sequence, stripDDLTransactionControl(upSQL))
+
+	var down string
+	if strings.TrimSpace(downSQL) != "" {
+		down = renderDDLHistorySection(sequence, stripDDLTransactionControl(downSQL))
+	}
+
+	if format == ddlMigrationsGoose {
+		var buf strings.Builder
+		buf.WriteString(renderDDLHeader(version))
+		buf.WriteString("-- +goose Up\n")
+		buf.WriteString(up)
+		buf.WriteString("\n\n-- +goose Down\n")
+
+		if down != "" {
+			buf.WriteString(down)
+			buf.WriteByte('\n')
+		}
+
+		return []ddlFileModel{{Filename: prefix + ".sql", Source: []byte(buf.String())}}
+	}
+
+	// golang-migrate 允许缺少 .down.sql；初始 schema 和没有逆向 DDL 的旧记录都不写。
+	models := []ddlFileModel{{Filename: prefix + ".up.sql", Source: []byte(renderDDLHeader(version) + up + "\n")}}
+	if down != "" {
+		models = append(models, ddlFileModel{Filename: prefix + ".down.sql", Source: []byte(renderDDLHeader(version) + down + "\n")})
+	}
+
+	return models
+}
+
+// ddlMigrationSlug 用记录改动的前两张表给文件命名，例如 create_users、alter_users_drop_posts。
+func ddlMigrationSlug(tables []ddlStateRecordTable) string {
+	parts := make([]string, 0, 3)
+
+	for _, table := range tables[:min(len(tables), 2)] {
+		verb := "alter"
+
+		switch {
+		case slices.Contains(table.Columns, "create table"):
+			verb = "create"
+		case slices.Contains(table.Columns, "drop table"):
+			verb = "drop"
+		case slices.ContainsFunc(table.Columns, func(line string) bool { return strings.HasPrefix(line, "rename table") }):
+			verb = "rename"
+		}
+
+		parts = append(parts, verb+"_"+table.Table)
+	}
+
+	if len(tables) > 2 {
+		parts = append(parts, "and_more")
+	}
+
+	if len(parts) == 0 {
+		return "schema"
+	}
+
+	slug := []byte(strings.ToLower(strings.Join(parts, "_")))
+	for i, ch := range slug {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') {
+			slug[i] = '_'
+		}
+	}
+
+	return string(slug)
+}
+
+// stripDDLTransactionControl 去掉 sqlite 重建表自带的 BEGIN / COMMIT 行：golang-migrate 和 goose
+// 都会把每个迁移文件放进自己的事务。
+func stripDDLTransactionControl(sqlText string) string {
+	lines := strings.Split(strings.TrimSpace(sqlText), "\n")
+
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if isTransactionControlStatement(strings.TrimSuffix(strings.TrimSpace(line), ";")) {
+			continue
+		}
+
+		// 被去掉的语句前后各有一个空行，只留一个。
+		if strings.TrimSpace(line) == "" && (len(kept) == 0 || strings.TrimSpace(kept[len(kept)-1]) == "") {
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// trimDDLHeader 去掉初始 SQL 自带的生成头，编号文件统一写当前版本的生成头。
+func trimDDLHeader(sqlText string) string {
+	if !strings.HasPrefix(sqlText, ddlGeneratedFileHeaderPrefix) {
+		return sqlText
+	}
+
+	_, rest, _ := strings.Cut(sqlText, "\n")
+
+	return rest
+}
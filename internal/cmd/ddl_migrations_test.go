@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func migrationTestFilenames(t *testing.T, models []ddlFileModel, outDir, dialect string) []string {
+	t.Helper()
+
+	var names []string
+
+	for _, model := range models {
+		rel, err := filepath.Rel(filepath.Join(outDir, ddlMigrationsDirname, dialect), model.Filename)
+		if err != nil || strings.Contains(rel, string(filepath.Separator)) || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		names = append(names, rel)
+	}
+
+	return names
+}
+
+func TestRenderDDLMigrationFilesForGolangMigrate(t *testing.T) {
+	dir := t.TempDir()
+	state := writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 16}),
+	)
+
+	models, err := renderDDLMigrationFiles(*state, dir, ddlMigrationsGolangMigrate, "v4.0.0", nil)
+	if err != nil {
+		t.Fatalf("renderDDLMigrationFiles() error = %v", err)
+	}
+
+	want := []string{
+		"0001_initial.up.sql",
+		"0002_alter_users.up.sql",
+		"0002_alter_users.down.sql",
+		"0003_alter_users.up.sql",
+		"0003_alter_users.down.sql",
+	}
+	if got := migrationTestFilenames(t, models, dir, "sqlite"); !slices.Equal(got, want) {
+		t.Fatalf("sqlite migration files = %v, want %v", got, want)
+	}
+
+	sources := make(map[string]string, len(models))
+	for _, model := range models {
+		sources[model.Filename] = string(model.Source)
+	}
+
+	sqliteDir := filepath.Join(dir, ddlMigrationsDirname, "sqlite")
+
+	rebuild := sources[filepath.Join(sqliteDir, "0003_alter_users.up.sql")]
+	if !strings.HasPrefix(rebuild, ddlGeneratedFileHeaderPrefix) || strings.Contains(rebuild, "BEGIN") || strings.Contains(rebuild, "COMMIT") {
+		t.Fatalf("expected a generated header and no transaction control, got:\n%s", rebuild)
+	}
+
+	// golang-migrate 按编号依次执行 up，回滚时倒序执行 down。
+	db := openMigrateTestDB(t, filepath.Join(dir, "app.db"))
+	for _, name := range []string{"0001_initial.up.sql", "0002_alter_users.up.sql", "0003_alter_users.up.sql", "0003_alter_users.down.sql", "0002_alter_users.down.sql"} {
+		if _, err := db.ExecContext(context.Background(), sources[filepath.Join(sqliteDir, name)]); err != nil {
+			t.Fatalf("exec %s: %v\n%s", name, err, sources[filepath.Join(sqliteDir, name)])
+		}
+	}
+}
+
+func TestRenderDDLMigrationFilesForGooseAfterSquash(t *testing.T) {
+	dir := t.TempDir()
+	history := writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}),
+	)
+
+	squashed, err := squashDDLState(history, "v4.0.0", squashSequencePrefix+"2026-06-01 00:00:00")
+	if err != nil {
+		t.Fatalf("squashDDLState() error = %v", err)
+	}
+
+	state := appendMigrateTestRecord(t, squashed, migrateTestSnapshot(
+		ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32},
+		ddlSnapshotColumn{Name: "email", Kind: ddlColumnString, Size: 64, Nullable: true},
+	), "2026-06-02 00:00:00")
+
+	models, err := renderDDLMigrationFiles(*state, dir, ddlMigrationsGoose, "v4.0.0", []string{"postgres"})
+	if err != nil {
+		t.Fatalf("renderDDLMigrationFiles() error = %v", err)
+	}
+
+	// 压缩基线沿用被并入的最后一步的编号，已经执行到 0002 的数据库不会再执行它。
+	want := []string{"0002_squash.sql", "0003_alter_users.sql"}
+	if got := migrationTestFilenames(t, models, dir, "postgres"); !slices.Equal(got, want) {
+		t.Fatalf("postgres migration files = %v, want %v", got, want)
+	}
+
+	if got := migrationTestFilenames(t, models, dir, "sqlite"); len(got) != 0 {
+		t.Fatalf("expected only the requested dialects, got sqlite files %v", got)
+	}
+
+	source := string(models[len(models)-1].Source)
+	for _, part := range []string{"-- +goose Up\n", `ADD COLUMN "email"`, "-- +goose Down\n", `DROP COLUMN "email"`} {
+		if !strings.Contains(source, part) {
+			t.Fatalf("expected goose file to contain %q, got:\n%s", part, source)
+		}
+	}
+}
+
+func TestDDLMigrationSlug(t *testing.T) {
+	for _, tc := range []struct {
+		tables []ddlStateRecordTable
+		want   string
+	}{
+		{nil, "schema"},
+		{[]ddlStateRecordTable{{Table: "Users", Columns: []string{"create table"}}}, "create_users"},
+		{[]ddlStateRecordTable{
+			{Table: "accounts", Columns: []string{"rename table from users"}},
+			{Table: "posts", Columns: []string{"drop table"}},
+			{Table: "tags", Indexes: []string{"add index idx_tags_name"}},
+		}, "rename_accounts_drop_posts_and_more"},
+	} {
+		if got := ddlMigrationSlug(tc.tables); got != tc.want {
+			t.Fatalf("ddlMigrationSlug(%v) = %q, want %q", tc.tables, got, tc.want)
+		}
+	}
+}
+
+func TestGenCmdWritesNumberedMigrationFiles(t *testing.T) {
+	t.Cleanup(func() {
+		dryRunFlag = false
+		checkFlag = false
+		migrationsFlag = ""
+		v = false
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users")
+type User struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Name string `+"`db:\"name\"`"+`
+	Age  int32  `+"`db:\"age\"`"+`
+}
+`)
+	users := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", Kind: ddlColumnString, Size: ddlDefaultStringSize},
+		},
+	}}}
+	writeMigrateTestHistory(t, dir, users)
+	writeTestFile(t, filepath.Join(dir, "sqlite.sql"), renderDDLHeader("v4.0.0")+"-- Dialect: sqlite\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--migrations", "flyway", "."})
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported migrations format") {
+		t.Fatalf("expected an unsupported format error, got %v", err)
+	}
+
+	stderr := new(bytes.Buffer)
+	GenCmd.SetErr(stderr)
+	GenCmd.SetArgs([]string{"--migrations", "golang-migrate", "."})
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	if got := stderr.String(); !strings.Contains(got, "run golang-migrate against the migrations directory") {
+		t.Fatalf("expected migration guidance, got:\n%s", got)
+	}
+
+	for _, name := range []string{"0001_initial.up.sql", "0002_alter_users.up.sql", "0002_alter_users.down.sql"} {
+		if _, err := os.Stat(filepath.Join(dir, ddlMigrationsDirname, "mysql", name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "sqlite.sql")); !os.IsNotExist(err) {
+		t.Fatalf("expected the aggregate sqlite.sql to be removed, got err=%v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ddlStateFilename)); err != nil {
+		t.Fatalf("expected tsq.json to be kept: %v", err)
+	}
+}
@@ -0,0 +1,705 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	mergeConflictOursMarker   = "<<<<<<<"
+	mergeConflictBaseMarker   = "|||||||"
+	mergeConflictSplitMarker  = "======="
+	mergeConflictTheirsMarker = ">>>>>>>"
+)
+
+// ddlRebaseResult 描述 tsq gen --rebase 如何重建分叉的历史。
+type ddlRebaseResult struct {
+	// kept 是原样保留的分支记录，只重新计算了 ID 和 Parent。
+	kept []string
+	// replaced 是被丢弃的分支记录，它们的变更由合并后的快照重新推导成一条新记录。
+	replaced  []string
+	conflicts []ddlHistoryConflict
+}
+
+// ddlHistoryConflict 是两个分支都改过、而且改得不一样的同一个对象。
+type ddlHistoryConflict struct {
+	Table    string
+	Object   string
+	Name     string
+	Kept     string
+	Replaced string
+}
+
+func (c ddlHistoryConflict) String() string {
+	if c.Object == "table" {
+		return fmt.Sprintf("table %s: changed by %s and by %s", c.Table, c.Kept, c.Replaced)
+	}
+
+	return fmt.Sprintf("%s %s.%s: changed by %s and by %s", c.Object, c.Table, c.Name, c.Kept, c.Replaced)
+}
+
+// ddlSnapshotHash 返回快照的内容哈希：快照 JSON 的 SHA-256 前 8 字节，16 位十六进制。
+// 改名提示不写入 tsq.json，因此也不参与哈希。
+func ddlSnapshotHash(snapshot ddlSnapshot) (string, error) {
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash DDL snapshot: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// buildDDLRecordChanges 取出一次 diff 改动的每张表在前后两个快照里的定义。
+func buildDDLRecordChanges(previous, current ddlSnapshot, changes ddlChangeSet) []ddlStateTableChange {
+	previousByName := make(map[string]ddlSnapshotTable, len(previous.Tables))
+	for _, table := range previous.Tables {
+		previousByName[table.Name] = table
+	}
+
+	currentByName := make(map[string]ddlSnapshotTable, len(current.Tables))
+	for _, table := range current.Tables {
+		currentByName[table.Name] = table
+	}
+
+	result := make([]ddlStateTableChange, 0, len(changes.Tables))
+
+	for _, tableName := range changes.Tables {
+		beforeName := tableName
+
+		for _, op := range changes.ByTable[tableName] {
+			if op.kind == ddlChangeRenameTable {
+				beforeName = op.oldTable.Name
+			}
+		}
+
+		item := ddlStateTableChange{Table: tableName}
+		if table, ok := previousByName[beforeName]; ok {
+			item.Before = new(table)
+		}
+
+		if table, ok := currentByName[tableName]; ok {
+			item.After = new(table)
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// applyDDLTableChanges 把一条记录的表定义套到快照上；forward 为 false 时反向撤销这条记录。
+func applyDDLTableChanges(snapshot ddlSnapshot, changes []ddlStateTableChange, forward bool) ddlSnapshot {
+	tables := make(map[string]ddlSnapshotTable, len(snapshot.Tables))
+	for _, table := range snapshot.Tables {
+		tables[table.Name] = table
+	}
+
+	for _, change := range changes {
+		from, to := change.Before, change.After
+		if !forward {
+			from, to = to, from
+		}
+
+		if from != nil {
+			delete(tables, from.Name)
+		}
+
+		if to != nil {
+			tables[to.Name] = *to
+		}
+	}
+
+	result := ddlSnapshot{Tables: make([]ddlSnapshotTable, 0, len(tables))}
+	for _, table := range tables {
+		result.Tables = append(result.Tables, table)
+	}
+
+	sort.Slice(result.Tables, func(i, j int) bool {
+		return result.Tables[i].Name < result.Tables[j].Name
+	})
+
+	return result
+}
+
+// validateDDLHistoryChain 检查历史记录首尾相接、最后一条记录与快照一致。手工合并两个分支的
+// tsq.json 通常会破坏这两点；旧版本写下的没有 ID 的记录不参与检查。
+func validateDDLHistoryChain(state *ddlStateFile) error {
+	if state == nil || len(state.Records) == 0 {
+		return nil
+	}
+
+	for i := 1; i < len(state.Records); i++ {
+		previous, record := state.Records[i-1], state.Records[i]
+		if previous.ID == "" || record.ID == "" || record.Parent == previous.ID {
+			continue
+		}
+
+		return fmt.Errorf("DDL history in tsq.json forks: record %s does not follow %s; run tsq gen --rebase", record.Sequence, previous.Sequence)
+	}
+
+	last := state.Records[len(state.Records)-1]
+	if last.ID == "" {
+		return nil
+	}
+
+	hash, err := ddlSnapshotHash(state.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	if last.ID != hash {
+		return fmt.Errorf("the snapshot in tsq.json does not match its last history record %s; run tsq gen --rebase", last.Sequence)
+	}
+
+	return nil
+}
+
+func hasMergeConflictMarkers(content []byte) bool {
+	for line := range bytes.Lines(content) {
+		if bytes.HasPrefix(line, []byte(mergeConflictOursMarker)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitMergeConflict 把带冲突标记的文件还原成合并双方各自的版本，diff3 风格的 base 段被丢弃。
+func splitMergeConflict(content []byte) ([]byte, []byte) {
+	const (
+		sectionShared = iota
+		sectionOurs
+		sectionBase
+		sectionTheirs
+	)
+
+	var ours, theirs bytes.Buffer
+
+	section := sectionShared
+
+	for line := range bytes.Lines(content) {
+		marker := string(bytes.TrimRight(line, "\r\n"))
+
+		switch {
+		case section == sectionShared && strings.HasPrefix(marker, mergeConflictOursMarker):
+			section = sectionOurs
+			continue
+		case section == sectionOurs && strings.HasPrefix(marker, mergeConflictBaseMarker):
+			section = sectionBase
+			continue
+		case (section == sectionOurs || section == sectionBase) && marker == mergeConflictSplitMarker:
+			section = sectionTheirs
+			continue
+		case section == sectionTheirs && strings.HasPrefix(marker, mergeConflictTheirsMarker):
+			section = sectionShared
+			continue
+		}
+
+		switch section {
+		case sectionShared:
+			ours.Write(line)
+			theirs.Write(line)
+		case sectionOurs:
+			ours.Write(line)
+		case sectionTheirs:
+			theirs.Write(line)
+		}
+	}
+
+	return ours.Bytes(), theirs.Bytes()
+}
+
+// rebaseDDLStateFile 读取合并后的 tsq.json（可以带 git 冲突标记，也可以是手工把两边记录都留下的
+// 版本），把分叉的历史重建成一条链。
+func rebaseDDLStateFile(outDir string, allowConflicts bool) (*ddlStateFile, *ddlRebaseResult, error) {
+	filename := filepath.Join(outDir, ddlStateFilename)
+
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("no DDL history to rebase: %s does not exist", filename)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sides := [][]byte{content}
+	if hasMergeConflictMarkers(content) {
+		ours, theirs := splitMergeConflict(content)
+		sides = [][]byte{ours, theirs}
+	}
+
+	states := make([]*ddlStateFile, 0, len(sides))
+
+	for _, side := range sides {
+		state, err := parseDDLStateFile(filename, side)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		states = append(states, state)
+	}
+
+	return rebaseDDLStates(states, allowConflicts)
+}
+
+// rebaseDDLStates 合并双方的历史记录。按首条分支记录的时间排序，最早的分支原样保留；之后的
+// 分支如果只改动了前面分支没碰过的表，也原样保留并接到后面，否则丢弃，由本次 tsq gen 从
+// 合并后的快照重新推导。两个分支把同一列或同一索引改成不同定义时视为冲突。
+func rebaseDDLStates(states []*ddlStateFile, allowConflicts bool) (*ddlStateFile, *ddlRebaseResult, error) {
+	base := states[0]
+
+	for _, other := range states[1:] {
+		if !reflect.DeepEqual(other.InitialDialects, base.InitialDialects) ||
+			!reflect.DeepEqual(other.Squash, base.Squash) ||
+			other.RenderedRecords != base.RenderedRecords {
+			return nil, nil, errors.New("the merged branches do not share the same initial schema: squash the DDL history only after merging")
+		}
+	}
+
+	records := unionDDLRecords(states)
+
+	forkParent, prefix, chains, err := splitDDLHistoryChains(records)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(chains) < 2 {
+		// 没有分叉：取记录最全的一方，其他方是它的前缀。
+		longest := base
+		for _, state := range states[1:] {
+			if len(state.Records) > len(longest.Records) {
+				longest = state
+			}
+		}
+
+		if err := validateDDLHistoryChain(longest); err != nil {
+			return nil, nil, err
+		}
+
+		state := *longest
+		state.Proto = mergeDDLStateProto(states)
+
+		return &state, nil, nil
+	}
+
+	forkSnapshot, err := findDDLForkSnapshot(states, forkParent, chains)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		if chains[i][0].Sequence != chains[j][0].Sequence {
+			return chains[i][0].Sequence < chains[j][0].Sequence
+		}
+
+		return chains[i][0].ID < chains[j][0].ID
+	})
+
+	result := &ddlRebaseResult{}
+	snapshot := forkSnapshot
+	rebased := slices.Clone(prefix)
+	touched := make(map[string]struct{})
+
+	var keptRecords []ddlStateRecord
+
+	for i, chain := range chains {
+		names := ddlRecordTableNames(chain)
+
+		if i > 0 && !disjointDDLTableNames(names, touched) {
+			final := forkSnapshot
+			for _, record := range chain {
+				final = applyDDLTableChanges(final, record.Changes, true)
+			}
+
+			result.conflicts = append(result.conflicts, findDDLHistoryConflicts(forkSnapshot, snapshot, final, keptRecords, chain)...)
+
+			for _, record := range chain {
+				result.replaced = append(result.replaced, record.Sequence)
+			}
+
+			continue
+		}
+
+		for _, record := range chain {
+			parent, err := ddlSnapshotHash(snapshot)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			snapshot = applyDDLTableChanges(snapshot, record.Changes, true)
+
+			id, err := ddlSnapshotHash(snapshot)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			record.Parent, record.ID = parent, id
+
+			rebased = append(rebased, record)
+			keptRecords = append(keptRecords, record)
+			result.kept = append(result.kept, record.Sequence)
+		}
+
+		for name := range names {
+			touched[name] = struct{}{}
+		}
+	}
+
+	if len(result.conflicts) > 0 && !allowConflicts {
+		return nil, nil, newDDLHistoryConflictError(result.conflicts)
+	}
+
+	state := *base
+	state.Snapshot = snapshot
+	state.Records = rebased
+	state.Proto = mergeDDLStateProto(states)
+
+	return &state, result, nil
+}
+
+// unionDDLRecords 按 ID 和迁移名对双方的记录取并集，保持各自原有的先后顺序。
+func unionDDLRecords(states []*ddlStateFile) []ddlStateRecord {
+	var records []ddlStateRecord
+
+	seen := make(map[string]struct{})
+
+	for _, state := range states {
+		for _, record := range state.Records {
+			key := record.ID + "\x00" + record.Sequence
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// splitDDLHistoryChains 找出分叉点，返回分叉前快照的哈希、共同的前缀，以及从分叉点出发的各条分支。
+func splitDDLHistoryChains(records []ddlStateRecord) (string, []ddlStateRecord, [][]ddlStateRecord, error) {
+	children := make(map[string][]int)
+
+	forkParent := ""
+	for i, record := range records {
+		if record.Parent == "" {
+			continue
+		}
+
+		children[record.Parent] = append(children[record.Parent], i)
+		if forkParent == "" && len(children[record.Parent]) > 1 {
+			forkParent = record.Parent
+		}
+	}
+
+	if forkParent == "" {
+		return "", records, nil, nil
+	}
+
+	first := children[forkParent][0]
+	prefix := records[:first]
+
+	if len(prefix) > 0 && prefix[len(prefix)-1].ID != forkParent {
+		return "", nil, nil, fmt.Errorf("DDL history forks after %s, which is not the last record both branches share", records[first].Sequence)
+	}
+
+	byParent := make(map[string][]ddlStateRecord)
+	for _, record := range records[first:] {
+		if record.ID == "" || len(record.Changes) == 0 {
+			return "", nil, nil, fmt.Errorf("record %s was written before tsq gen stored table changes and cannot be rebased: resolve tsq.json by hand", record.Sequence)
+		}
+
+		byParent[record.Parent] = append(byParent[record.Parent], record)
+	}
+
+	chains := make([][]ddlStateRecord, 0, len(byParent[forkParent]))
+	used := 0
+
+	for _, head := range byParent[forkParent] {
+		chain := []ddlStateRecord{head}
+
+		for {
+			next := byParent[chain[len(chain)-1].ID]
+			if len(next) == 0 {
+				break
+			}
+
+			if len(next) > 1 {
+				return "", nil, nil, fmt.Errorf("DDL history forks again after %s: rebase one merge at a time", chain[len(chain)-1].Sequence)
+			}
+
+			chain = append(chain, next[0])
+		}
+
+		used += len(chain)
+		chains = append(chains, chain)
+	}
+
+	if used != len(records)-first {
+		return "", nil, nil, errors.New("some DDL history records do not continue either branch: resolve tsq.json by hand")
+	}
+
+	return forkParent, prefix, chains, nil
+}
+
+// findDDLForkSnapshot 从某一方的快照出发逐条撤销它那条分支的记录，得到分叉前的快照。
+func findDDLForkSnapshot(states []*ddlStateFile, forkParent string, chains [][]ddlStateRecord) (ddlSnapshot, error) {
+	for _, state := range states {
+		hash, err := ddlSnapshotHash(state.Snapshot)
+		if err != nil {
+			return ddlSnapshot{}, err
+		}
+
+		for _, chain := range chains {
+			if chain[len(chain)-1].ID != hash {
+				continue
+			}
+
+			snapshot := state.Snapshot
+			for i := len(chain) - 1; i >= 0; i-- {
+				snapshot = applyDDLTableChanges(snapshot, chain[i].Changes, false)
+			}
+
+			forkHash, err := ddlSnapshotHash(snapshot)
+			if err != nil {
+				return ddlSnapshot{}, err
+			}
+
+			if forkHash != forkParent {
+				return ddlSnapshot{}, fmt.Errorf("cannot rebuild the snapshot before %s from tsq.json", chain[0].Sequence)
+			}
+
+			return snapshot, nil
+		}
+	}
+
+	return ddlSnapshot{}, errors.New("the snapshot in tsq.json matches neither merged branch: keep one side's snapshot when resolving the conflict")
+}
+
+func ddlRecordTableNames(records []ddlStateRecord) map[string]struct{} {
+	names := make(map[string]struct{})
+
+	for _, record := range records {
+		for _, change := range record.Changes {
+			if change.Before != nil {
+				names[change.Before.Name] = struct{}{}
+			}
+
+			if change.After != nil {
+				names[change.After.Name] = struct{}{}
+			}
+		}
+	}
+
+	return names
+}
+
+func disjointDDLTableNames(names, touched map[string]struct{}) bool {
+	for name := range names {
+		if _, ok := touched[name]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findDDLHistoryConflicts 比较分叉前（fork）、保留分支之后（kept）和被替换分支之后（replaced）
+// 的同名对象：只有两边都改过、改成的定义又不一样时才算冲突。
+func findDDLHistoryConflicts(
+	fork, kept, replaced ddlSnapshot,
+	keptRecords, replacedRecords []ddlStateRecord,
+) []ddlHistoryConflict {
+	var conflicts []ddlHistoryConflict
+
+	keptNames := ddlRecordTableNames(keptRecords)
+	replacedNames := ddlRecordTableNames(replacedRecords)
+
+	names := make([]string, 0, len(replacedNames))
+	for name := range replacedNames {
+		if _, ok := keptNames[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	conflict := func(table, object, name string) ddlHistoryConflict {
+		return ddlHistoryConflict{
+			Table:    table,
+			Object:   object,
+			Name:     name,
+			Kept:     lastDDLRecordChanging(keptRecords, table, object, name),
+			Replaced: lastDDLRecordChanging(replacedRecords, table, object, name),
+		}
+	}
+
+	for _, name := range names {
+		before, after, theirs := findDDLSnapshotTable(fork, name), findDDLSnapshotTable(kept, name), findDDLSnapshotTable(replaced, name)
+
+		if after == nil || theirs == nil {
+			if !ddlSnapshotTablesEqual(after, theirs) && !ddlSnapshotTablesEqual(after, before) && !ddlSnapshotTablesEqual(theirs, before) {
+				conflicts = append(conflicts, conflict(name, "table", name))
+			}
+
+			continue
+		}
+
+		if before == nil {
+			before = &ddlSnapshotTable{Name: name}
+		}
+
+		for _, column := range ddlTableColumnNames(*before, *after, *theirs) {
+			b, k, r := findDDLSnapshotColumn(*before, column), findDDLSnapshotColumn(*after, column), findDDLSnapshotColumn(*theirs, column)
+			if !ddlSnapshotColumnPtrsEqual(k, b) && !ddlSnapshotColumnPtrsEqual(r, b) && !ddlSnapshotColumnPtrsEqual(k, r) {
+				conflicts = append(conflicts, conflict(name, "column", column))
+			}
+		}
+
+		for _, index := range ddlTableIndexNames(*before, *after, *theirs) {
+			b, k, r := findDDLSnapshotIndex(*before, index), findDDLSnapshotIndex(*after, index), findDDLSnapshotIndex(*theirs, index)
+			if !reflect.DeepEqual(k, b) && !reflect.DeepEqual(r, b) && !reflect.DeepEqual(k, r) {
+				conflicts = append(conflicts, conflict(name, "index", index))
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// lastDDLRecordChanging 返回分支里最后一条改动了该对象的记录。
+func lastDDLRecordChanging(records []ddlStateRecord, table, object, name string) string {
+	for i := len(records) - 1; i >= 0; i-- {
+		for _, change := range records[i].Changes {
+			if (change.Before == nil || change.Before.Name != table) && (change.After == nil || change.After.Name != table) {
+				continue
+			}
+
+			before, after := change.Before, change.After
+			if before == nil {
+				before = &ddlSnapshotTable{}
+			}
+
+			if after == nil {
+				after = &ddlSnapshotTable{}
+			}
+
+			switch object {
+			case "column":
+				if ddlSnapshotColumnPtrsEqual(findDDLSnapshotColumn(*before, name), findDDLSnapshotColumn(*after, name)) {
+					continue
+				}
+			case "index":
+				if reflect.DeepEqual(findDDLSnapshotIndex(*before, name), findDDLSnapshotIndex(*after, name)) {
+					continue
+				}
+			}
+
+			return records[i].Sequence
+		}
+	}
+
+	return records[len(records)-1].Sequence
+}
+
+func newDDLHistoryConflictError(conflicts []ddlHistoryConflict) error {
+	lines := make([]string, 0, len(conflicts))
+	for _, item := range conflicts {
+		lines = append(lines, "  "+item.String())
+	}
+
+	return fmt.Errorf(
+		"refusing to rebase conflicting schema changes from merged branches:\n%s\n"+
+			"check the merged Go source declares the definition you want, then rerun with --rebase --allow-conflicts",
+		strings.Join(lines, "\n"),
+	)
+}
+
+func findDDLSnapshotTable(snapshot ddlSnapshot, name string) *ddlSnapshotTable {
+	for i := range snapshot.Tables {
+		if snapshot.Tables[i].Name == name {
+			return &snapshot.Tables[i]
+		}
+	}
+
+	return nil
+}
+
+func findDDLSnapshotColumn(table ddlSnapshotTable, name string) *ddlSnapshotColumn {
+	for i := range table.Columns {
+		if table.Columns[i].Name == name {
+			return &table.Columns[i]
+		}
+	}
+
+	return nil
+}
+
+func findDDLSnapshotIndex(table ddlSnapshotTable, name string) *ddlSnapshotIndex {
+	for i := range table.Indexes {
+		if table.Indexes[i].Name == name {
+			return &table.Indexes[i]
+		}
+	}
+
+	return nil
+}
+
+func ddlSnapshotTablesEqual(left, right *ddlSnapshotTable) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+
+	return reflect.DeepEqual(left.Columns, right.Columns) && reflect.DeepEqual(left.Indexes, right.Indexes)
+}
+
+func ddlSnapshotColumnPtrsEqual(left, right *ddlSnapshotColumn) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+
+	return ddlSnapshotColumnsEqual(*left, *right)
+}
+
+func ddlTableColumnNames(tables ...ddlSnapshotTable) []string {
+	var names []string
+
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			if !slices.Contains(names, column.Name) {
+				names = append(names, column.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+func ddlTableIndexNames(tables ...ddlSnapshotTable) []string {
+	var names []string
+
+	for _, table := range tables {
+		for _, idx := range table.Indexes {
+			if !slices.Contains(names, idx.Name) {
+				names = append(names, idx.Name)
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func rebaseTestSnapshot(users []ddlSnapshotColumn, posts []ddlSnapshotColumn) ddlSnapshot {
+	// tsq gen 按表名排序快照，posts 排在 users 前面。
+	snapshot := migrateTestSnapshot(users...)
+	snapshot.Tables = append([]ddlSnapshotTable{{
+		Name: "posts",
+		Columns: append([]ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+		}, posts...),
+	}}, snapshot.Tables...)
+
+	return snapshot
+}
+
+// rebaseTestConflict 把两份 tsq.json 按行拼成 git 合并冲突的样子：相同的首尾行共用，中间部分放进冲突标记。
+func rebaseTestConflict(t *testing.T, ours, theirs *ddlStateFile) []byte {
+	t.Helper()
+
+	lines := func(state *ddlStateFile) []string {
+		content, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			t.Fatalf("marshal state: %v", err)
+		}
+
+		return strings.SplitAfter(string(content)+"\n", "\n")
+	}
+
+	left, right := lines(ours), lines(theirs)
+
+	head := 0
+	for head < len(left) && head < len(right) && left[head] == right[head] {
+		head++
+	}
+
+	tail := 0
+	for tail < len(left)-head && tail < len(right)-head && left[len(left)-1-tail] == right[len(right)-1-tail] {
+		tail++
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(left[:head], ""))
+	b.WriteString("<<<<<<< HEAD\n")
+	b.WriteString(strings.Join(left[head:len(left)-tail], ""))
+	b.WriteString("=======\n")
+	b.WriteString(strings.Join(right[head:len(right)-tail], ""))
+	b.WriteString(">>>>>>> feature\n")
+	b.WriteString(strings.Join(left[len(left)-tail:], ""))
+
+	return []byte(b.String())
+}
+
+func TestSplitMergeConflictRestoresBothSides(t *testing.T) {
+	content := "shared\n<<<<<<< HEAD\nours\n||||||| base\nbase\n=======\ntheirs\n>>>>>>> feature\nend\n"
+
+	ours, theirs := splitMergeConflict([]byte(content))
+	if string(ours) != "shared\nours\nend\n" || string(theirs) != "shared\ntheirs\nend\n" {
+		t.Fatalf("splitMergeConflict() = %q, %q", ours, theirs)
+	}
+
+	if !hasMergeConflictMarkers([]byte(content)) || hasMergeConflictMarkers(ours) {
+		t.Fatal("expected only the conflicted content to report merge markers")
+	}
+}
+
+func TestRebaseDDLStateFileKeepsBranchesOnDisjointTables(t *testing.T) {
+	dir := t.TempDir()
+	base := writeMigrateTestHistory(t, dir, rebaseTestSnapshot(nil, nil))
+
+	age := ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}
+	title := ddlSnapshotColumn{Name: "title", Kind: ddlColumnString, Size: 64}
+
+	ours := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{age}, nil), "2026-06-02 00:00:00")
+	theirs := appendMigrateTestRecord(t, base, rebaseTestSnapshot(nil, []ddlSnapshotColumn{title}), "2026-06-01 00:00:00")
+	writeTestFile(t, filepath.Join(dir, ddlStateFilename), string(rebaseTestConflict(t, ours, theirs)))
+
+	if _, err := loadDDLStateFile(dir); err == nil || !strings.Contains(err.Error(), "run tsq gen --rebase") {
+		t.Fatalf("expected the conflicted tsq.json to ask for --rebase, got %v", err)
+	}
+
+	state, result, err := rebaseDDLStateFile(dir, false)
+	if err != nil {
+		t.Fatalf("rebaseDDLStateFile() error = %v", err)
+	}
+
+	if !slices.Equal(result.kept, []string{"2026-06-01 00:00:00", "2026-06-02 00:00:00"}) || len(result.replaced) != 0 {
+		t.Fatalf("unexpected rebase result %+v", result)
+	}
+
+	merged := rebaseTestSnapshot([]ddlSnapshotColumn{age}, []ddlSnapshotColumn{title})
+	if !reflect.DeepEqual(state.Snapshot, merged) {
+		t.Fatalf("rebased snapshot = %+v, want %+v", state.Snapshot, merged)
+	}
+
+	if err := validateDDLHistoryChain(state); err != nil {
+		t.Fatalf("expected a linear history after rebase, got %v", err)
+	}
+
+	// 被接到后面的记录只换了 Parent/ID，SQL 原样保留。
+	if !reflect.DeepEqual(state.Records[1].Dialects["sqlite"], ours.Records[0].Dialects["sqlite"]) {
+		t.Fatalf("expected the kept record SQL to stay unchanged, got %+v", state.Records[1].Dialects["sqlite"])
+	}
+
+	if state.Records[1].Parent != state.Records[0].ID || state.Records[1].ID == ours.Records[0].ID {
+		t.Fatalf("expected the later branch to be re-parented, got %+v", state.Records)
+	}
+}
+
+func TestRebaseDDLStatesReplacesBranchesOnSharedTables(t *testing.T) {
+	dir := t.TempDir()
+	base := writeMigrateTestHistory(t, dir, rebaseTestSnapshot(nil, nil))
+
+	age := ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}
+	email := ddlSnapshotColumn{Name: "email", Kind: ddlColumnString, Size: 64}
+
+	ours := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{age}, nil), "2026-06-01 00:00:00")
+	theirs := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{email}, nil), "2026-06-02 00:00:00")
+
+	// 顺序无关：先读到哪一方，结果都一样。
+	for _, states := range [][]*ddlStateFile{{ours, theirs}, {theirs, ours}} {
+		state, result, err := rebaseDDLStates(states, false)
+		if err != nil {
+			t.Fatalf("rebaseDDLStates() error = %v", err)
+		}
+
+		if !slices.Equal(result.kept, []string{"2026-06-01 00:00:00"}) || !slices.Equal(result.replaced, []string{"2026-06-02 00:00:00"}) {
+			t.Fatalf("unexpected rebase result %+v", result)
+		}
+
+		if !reflect.DeepEqual(state.Snapshot, ours.Snapshot) || len(state.Records) != 1 {
+			t.Fatalf("expected the earlier branch to remain, got %+v", state)
+		}
+	}
+}
+
+func TestRebaseDDLStatesRefusesConflictingColumnChanges(t *testing.T) {
+	dir := t.TempDir()
+	base := writeMigrateTestHistory(t, dir, rebaseTestSnapshot(nil, nil))
+
+	ours := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{
+		{Name: "age", Kind: ddlColumnInt, Bits: 32},
+	}, nil), "2026-06-01 00:00:00")
+	theirs := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{
+		{Name: "age", Kind: ddlColumnString, Size: 16},
+	}, nil), "2026-06-02 00:00:00")
+
+	_, _, err := rebaseDDLStates([]*ddlStateFile{ours, theirs}, false)
+	if err == nil || !strings.Contains(err.Error(), "column users.age: changed by 2026-06-01 00:00:00 and by 2026-06-02 00:00:00") {
+		t.Fatalf("expected a column conflict, got %v", err)
+	}
+
+	_, result, err := rebaseDDLStates([]*ddlStateFile{ours, theirs}, true)
+	if err != nil || len(result.conflicts) != 1 {
+		t.Fatalf("expected --allow-conflicts to record the conflict, got %+v, %v", result, err)
+	}
+}
+
+func TestValidateDDLHistoryChainDetectsHandMergedHistory(t *testing.T) {
+	dir := t.TempDir()
+	base := writeMigrateTestHistory(t, dir, rebaseTestSnapshot(nil, nil))
+
+	ours := appendMigrateTestRecord(t, base, rebaseTestSnapshot([]ddlSnapshotColumn{
+		{Name: "age", Kind: ddlColumnInt, Bits: 32},
+	}, nil), "2026-06-01 00:00:00")
+	theirs := appendMigrateTestRecord(t, base, rebaseTestSnapshot(nil, []ddlSnapshotColumn{
+		{Name: "title", Kind: ddlColumnString, Size: 64},
+	}), "2026-06-02 00:00:00")
+
+	merged := *theirs
+	merged.Records = append(slices.Clone(ours.Records), theirs.Records...)
+
+	if err := validateDDLHistoryChain(&merged); err == nil || !strings.Contains(err.Error(), "forks") {
+		t.Fatalf("expected a fork error, got %v", err)
+	}
+
+	saveMigrateTestHistory(t, dir, &merged)
+
+	state, result, err := rebaseDDLStateFile(dir, false)
+	if err != nil || len(result.kept) != 2 || len(state.Snapshot.Tables[0].Columns) != 2 || len(state.Snapshot.Tables[1].Columns) != 2 {
+		t.Fatalf("expected --rebase to accept the hand-merged history, got %+v, %v", result, err)
+	}
+}
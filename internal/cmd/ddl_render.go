@@ -37,6 +37,34 @@ type ddlArtifacts struct {
 	firstRun     bool
 	hasChange    bool
 	recordTables []ddlStateRecordTable
+	irreversible []string
+	assessments  []ddlChangeAssessment
+	// warnings 是没有确认任何破坏性变更的 allow_destructive / allow_drop 注解。
+	warnings   []string
+	squash     *ddlStateSquash
+	rebase     *ddlRebaseResult
+	migrations string
+	// dir 是 SQL 文件和 tsq.json 所在目录，config 决定输出哪些方言、文件叫什么。
+	dir    string
+	config *genConfig
+	// snapshot 是按当前 Go 代码推出的 schema。
+	snapshot ddlSnapshot
+	// proto 是写进 tsq.json 的 protobuf 字段编号。
+	proto map[string]ddlStateProtoMessage
+}
+
+// ddlHistoryOptions 是 tsq gen 改写和输出 DDL 历史的选项。
+type ddlHistoryOptions struct {
+	squash         bool
+	rebase         bool
+	allowConflicts bool
+	// migrations 非空时按 golang-migrate / goose 的编号文件输出历史，不再写聚合 SQL 文件。
+	migrations string
+	// config 是包适用的 tsq.yaml，为 nil 时按内置默认值输出。
+	config *genConfig
+	// protoMessages 非 nil 时按消息名给出 --emit proto 的字段，为新字段分配编号；为 nil 时
+	// 原样保留 tsq.json 里的编号。
+	protoMessages map[string][]string
 }
 
 type ddlDialectSpec struct {
@@ -45,6 +73,15 @@ type ddlDialectSpec struct {
 
 type ddlTypeResolver struct {
 	packages map[string]*packages.Package
+	options  ddlTypeOptions
+}
+
+// ddlTypeOptions 来自 tsq.yaml 的 ddl 段，字段的 db 标签优先于这里的默认值。
+type ddlTypeOptions struct {
+	// stringSize 是没写 size 的字符串列长度，0 表示 ddlDefaultStringSize。
+	stringSize int
+	// types 按 types.TypeString 的完整类型名给出默认 DDL 列类型。
+	types map[string]string
 }
 
 type ddlColumnKind string
@@ -65,6 +102,10 @@ type ddlColumnDescriptor struct {
 	nullable bool
 	size     int
 	rawType  string
+	// renamedFrom 是 db 标签 was:<old_name> 声明的旧列名。
+	renamedFrom string
+	// allowDestructive 来自 db 标签选项 allow_destructive。
+	allowDestructive bool
 }
 
 var ddlDialects = []ddlDialectSpec{
@@ -73,7 +114,14 @@ var ddlDialects = []ddlDialectSpec{
 	{dialect: tsqdialect.PostgresDialect{}},
 }
 
-func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir string) (ddlArtifacts, error) {
+func buildDDLArtifacts(list []*genmodel.StructInfo, packageDir string, resolver *ddlTypeResolver, history ddlHistoryOptions) (ddlArtifacts, error) {
+	config := history.config
+	if config == nil {
+		config = &genConfig{}
+	}
+
+	outDir := config.ddlDir(packageDir)
+
 	if err := validateIndexNameCollisions(list); err != nil {
 		return ddlArtifacts{}, err
 	}
@@ -95,11 +143,6 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		return tables[i].Table < tables[j].Table
 	})
 
-	resolver, err := newDDLTypeResolver(packagePath, outDir)
-	if err != nil {
-		return ddlArtifacts{}, err
-	}
-
 	version := stableVersion(buildinfo.Version())
 
 	currentSnapshot, err := buildCurrentDDLSnapshot(tables, resolver)
@@ -107,11 +150,37 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		return ddlArtifacts{}, err
 	}
 
-	previousState, err := loadDDLStateFile(outDir)
+	var (
+		previousState *ddlStateFile
+		rebased       *ddlRebaseResult
+	)
+
+	if history.rebase {
+		previousState, rebased, err = rebaseDDLStateFile(outDir, history.allowConflicts)
+	} else {
+		previousState, err = loadDDLStateFile(outDir)
+		if err == nil {
+			err = validateDDLHistoryChain(previousState)
+		}
+	}
+
 	if err != nil {
 		return ddlArtifacts{}, err
 	}
 
+	now := time.Now()
+
+	var squashed *ddlStateSquash
+	if history.squash {
+		// 先压缩已有历史，本次的变更再作为压缩后的第一条记录追加。
+		previousState, err = squashDDLState(previousState, version, squashSequencePrefix+now.Format(time.DateTime))
+		if err != nil {
+			return ddlArtifacts{}, err
+		}
+
+		squashed = previousState.Squash
+	}
+
 	var previousSnapshot *ddlSnapshot
 	if previousState != nil {
 		previousSnapshot = &previousState.Snapshot
@@ -119,9 +188,11 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 
 	changes := diffDDLSnapshots(previousSnapshot, currentSnapshot)
 	recordTables := buildDDLRecordTables(changes)
+	irreversible := buildDDLIrreversibleNotes(changes)
+	assessments := classifyDDLChanges(changes)
+
 	hasChange := len(recordTables) > 0
 	models := make([]ddlFileModel, 0, len(ddlDialects)+1)
-	dialectHistory := make(map[string]ddlStateDialectDiff, len(ddlDialects))
 	firstRun := previousState == nil
 	currentDialects := make(map[string][]byte, len(ddlDialects))
 
@@ -130,24 +201,26 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		currentDialects[name] = renderDDLSnapshotAggregateFile(version, currentSnapshot, dialect)
 	}
 
-	initialDialects, renderedRecords, err := buildDDLInitialDialects(previousState, outDir, currentDialects)
+	initialDialects, renderedRecords, err := buildDDLInitialDialects(previousState, outDir, config, currentDialects)
 	if err != nil {
 		return ddlArtifacts{}, err
 	}
 
-	sequence := ""
+	var record *ddlStateRecord
 	if previousState != nil && hasChange {
-		sequence = time.Now().Format(time.DateTime)
-	}
-
-	for _, dialect := range ddlDialects {
-		name := ddlDialectName(dialect)
-
-		diffRecord, err := renderDDLIncrementalArtifact(dialect, changes)
+		record, err = buildDDLStateRecord(*previousSnapshot, currentSnapshot, changes, now.Format(time.DateTime))
 		if err != nil {
 			return ddlArtifacts{}, err
 		}
-		dialectHistory[name] = diffRecord
+	}
+
+	var proto map[string]ddlStateProtoMessage
+	if previousState != nil {
+		proto = previousState.Proto
+	}
+
+	if history.protoMessages != nil {
+		proto = assignProtoFieldNumbers(proto, history.protoMessages)
 	}
 
 	stateSource, err := marshalDDLStateFile(
@@ -156,9 +229,8 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		currentSnapshot,
 		initialDialects,
 		renderedRecords,
-		recordTables,
-		dialectHistory,
-		sequence,
+		record,
+		proto,
 	)
 	if err != nil {
 		return ddlArtifacts{}, err
@@ -169,18 +241,30 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		return ddlArtifacts{}, fmt.Errorf("failed to parse rendered DDL state: %w", err)
 	}
 
-	for _, dialect := range ddlDialects {
-		name := ddlDialectName(dialect)
-
-		source, err := renderDDLAggregateFileFromState(state, name)
+	if history.migrations != "" {
+		migrationModels, err := renderDDLMigrationFiles(state, outDir, history.migrations, version, config.Dialects)
 		if err != nil {
 			return ddlArtifacts{}, err
 		}
 
-		models = append(models, ddlFileModel{
-			Filename: filepath.Join(outDir, name+".sql"),
-			Source:   source,
-		})
+		models = append(models, migrationModels...)
+	} else {
+		for _, dialect := range ddlDialects {
+			name := ddlDialectName(dialect)
+			if !config.emitsDialect(name) {
+				continue
+			}
+
+			source, err := renderDDLAggregateFileFromState(state, name)
+			if err != nil {
+				return ddlArtifacts{}, err
+			}
+
+			models = append(models, ddlFileModel{
+				Filename: filepath.Join(outDir, config.ddlFilename(name)),
+				Source:   source,
+			})
+		}
 	}
 
 	models = append(models, ddlFileModel{
@@ -197,12 +281,23 @@ func buildDDLArtifacts(packagePath string, list []*genmodel.StructInfo, outDir s
 		firstRun:     firstRun,
 		hasChange:    hasChange,
 		recordTables: append([]ddlStateRecordTable(nil), recordTables...),
+		irreversible: irreversible,
+		assessments:  assessments,
+		warnings:     unusedDDLDestructiveAcknowledgements(currentSnapshot, changes),
+		squash:       squashed,
+		rebase:       rebased,
+		migrations:   history.migrations,
+		dir:          outDir,
+		config:       config,
+		snapshot:     currentSnapshot,
+		proto:        proto,
 	}, nil
 }
 
 func buildDDLInitialDialects(
 	previous *ddlStateFile,
 	outDir string,
+	config *genConfig,
 	current map[string][]byte,
 ) (map[string]ddlStateDialectSQL, int, error) {
 	if previous != nil && len(previous.InitialDialects) > 0 {
@@ -214,7 +309,7 @@ func buildDDLInitialDialects(
 	initial := make(map[string]ddlStateDialectSQL, len(ddlDialects))
 	for _, dialect := range ddlDialects {
 		name := ddlDialectName(dialect)
-		filename := filepath.Join(outDir, name+".sql")
+		filename := filepath.Join(outDir, config.ddlFilename(name))
 
 		content, err := os.ReadFile(filename)
 		switch {
@@ -342,7 +437,7 @@ func renderDDLColumnSpec(dialect tsqdialect.Dialect, column tsqdialect.DDLColumn
 	return strings.Join(parts, " "), nil
 }
 
-func newDDLTypeResolver(packagePath, dir string) (*ddlTypeResolver, error) {
+func newDDLTypeResolver(packagePath, dir string, options ddlTypeOptions) (*ddlTypeResolver, error) {
 	cfg, pattern, err := resolveDDLLoadRequest(packagePath, dir)
 	if err != nil {
 		return nil, err
@@ -368,7 +463,7 @@ func newDDLTypeResolver(packagePath, dir string) (*ddlTypeResolver, error) {
 		return nil, fmt.Errorf("failed to load package %s", packagePath)
 	}
 
-	return &ddlTypeResolver{packages: byPath}, nil
+	return &ddlTypeResolver{packages: byPath, options: options}, nil
 }
 
 func resolveDDLLoadRequest(packagePath, dir string) (*packages.Config, string, error) {
@@ -523,17 +618,22 @@ func (r *ddlTypeResolver) describeField(
 	table *genmodel.StructInfo,
 	field genmodel.FieldInfo,
 ) (ddlColumnDescriptor, error) {
-	namedType, pkg, err := r.lookupNamedStruct(table.TypeInfo)
+	varObj, tag, err := r.lookupField(table, field)
 	if err != nil {
 		return ddlColumnDescriptor{}, err
 	}
 
-	varObj, tag, err := lookupDDLField(namedType, pkg, field.Name)
+	return classifyDDLColumnType(varObj.Type(), tag, r.options)
+}
+
+// lookupField 返回字段的类型对象和完整的结构体标签。
+func (r *ddlTypeResolver) lookupField(s *genmodel.StructInfo, field genmodel.FieldInfo) (*types.Var, string, error) {
+	namedType, pkg, err := r.lookupNamedStruct(s.TypeInfo)
 	if err != nil {
-		return ddlColumnDescriptor{}, err
+		return nil, "", err
 	}
 
-	return classifyDDLColumnType(varObj.Type(), tag)
+	return lookupDDLField(namedType, pkg, field.Name)
 }
 
 func (r *ddlTypeResolver) lookupNamedStruct(typeInfo genmodel.TypeInfo) (*types.Named, *types.Package, error) {
@@ -612,8 +712,11 @@ func underlyingStructType(t types.Type) *types.Struct {
 	}
 }
 
-func classifyDDLColumnType(t types.Type, rawTag string) (ddlColumnDescriptor, error) {
+func classifyDDLColumnType(t types.Type, rawTag string, options ddlTypeOptions) (ddlColumnDescriptor, error) {
 	opts := parseDDLTagOptions(reflect.StructTag(rawTag).Get("db"))
+	if opts.rawType == "" {
+		opts.rawType = options.types[ddlGoTypeName(t)]
+	}
 
 	desc, err := classifyDDLColumnTypeRecursive(t, opts.size, false)
 	if err != nil {
@@ -630,10 +733,12 @@ func classifyDDLColumnType(t types.Type, rawTag string) (ddlColumnDescriptor, er
 	}
 
 	if desc.kind == ddlColumnString {
-		desc.size = normalizeDDLStringSize(opts.size)
+		desc.size = normalizeDDLStringSize(opts.size, options.stringSize)
 	}
 
 	desc.rawType = opts.rawType
+	desc.renamedFrom = opts.renamedFrom
+	desc.allowDestructive = opts.allowDestructive
 
 	return desc, nil
 }
@@ -734,14 +839,27 @@ func classifyDDLColumnTypeRecursive(
 	return ddlColumnDescriptor{}, fmt.Errorf("unsupported DDL field type %s", types.TypeString(t, nil))
 }
 
-func normalizeDDLStringSize(size int) int {
+func normalizeDDLStringSize(size, fallback int) int {
 	if size > 0 {
 		return size
 	}
 
+	if fallback > 0 {
+		return fallback
+	}
+
 	return ddlDefaultStringSize
 }
 
+// ddlGoTypeName 返回 tsq.yaml ddl.types 用的类型名，指针字段按元素类型查找。
+func ddlGoTypeName(t types.Type) string {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+
+	return types.TypeString(t, nil)
+}
+
 func ddlBasicIntegerDescriptor(basic *types.Basic, nullable bool) ddlColumnDescriptor {
 	desc := ddlColumnDescriptor{kind: ddlColumnInt, bits: 64, nullable: nullable}
 
@@ -777,8 +895,10 @@ func ddlBasicIntegerDescriptor(basic *types.Basic, nullable bool) ddlColumnDescr
 }
 
 type ddlTagOptions struct {
-	size    int
-	rawType string
+	size             int
+	rawType          string
+	renamedFrom      string
+	allowDestructive bool
 }
 
 func parseDDLTagOptions(dbTag string) ddlTagOptions {
@@ -791,6 +911,10 @@ func parseDDLTagOptions(dbTag string) ddlTagOptions {
 	for _, part := range parts[1:] {
 		key, value, ok := strings.Cut(strings.TrimSpace(part), ":")
 		if !ok {
+			if key == "allow_destructive" {
+				opts.allowDestructive = true
+			}
+
 			continue
 		}
 
@@ -811,6 +935,12 @@ func parseDDLTagOptions(dbTag string) ddlTagOptions {
 			}
 
 			opts.rawType = value
+		case "was":
+			if value == "" {
+				continue
+			}
+
+			opts.renamedFrom = value
 		}
 	}
 
@@ -923,42 +1053,45 @@ func ddlPlanStatusFor(filename string, src []byte) (generationPlanStatus, error)
 }
 
 func findStaleDDLFiles(dir string, plannedFiles map[string]struct{}) ([]string, error) {
-	entries, err := os.ReadDir(dir)
-	if os.IsNotExist(err) {
-		return nil, nil
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
 	stale := make([]string, 0)
 
-	for _, entry := range entries {
-		if entry.IsDir() {
+	// 聚合 SQL 和编号迁移文件互为替代，切换输出方式时另一种留下的生成文件也算过期。
+	for _, searchDir := range append([]string{dir}, ddlMigrationDirs(dir)...) {
+		entries, err := os.ReadDir(searchDir)
+		if os.IsNotExist(err) {
 			continue
 		}
 
-		name := entry.Name()
-		if !strings.HasSuffix(name, ".sql") && name != ddlStateFilename && name != legacyDDLStateFilename {
-			continue
-		}
-
-		filename := filepath.Join(dir, name)
-		if _, ok := plannedFiles[filename]; ok {
-			continue
-		}
-
-		content, err := os.ReadFile(filename)
 		if err != nil {
 			return nil, err
 		}
 
-		if !isGeneratedDDLArtifact(content) {
-			continue
-		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			name := entry.Name()
+			if !strings.HasSuffix(name, ".sql") && name != ddlStateFilename && name != legacyDDLStateFilename {
+				continue
+			}
 
-		stale = append(stale, filename)
+			filename := filepath.Join(searchDir, name)
+			if _, ok := plannedFiles[filename]; ok {
+				continue
+			}
+
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+
+			if !isGeneratedDDLArtifact(content) {
+				continue
+			}
+
+			stale = append(stale, filename)
+		}
 	}
 
 	sort.Strings(stale)
@@ -1035,6 +1168,11 @@ func isGeneratedDDLArtifact(content []byte) bool {
 		return true
 	}
 
+	// 带合并冲突标记的 tsq.json 按其中一方判断，tsq gen --rebase 需要覆盖它。
+	if hasMergeConflictMarkers(content) {
+		content, _ = splitMergeConflict(content)
+	}
+
 	var meta struct {
 		GeneratedBy string `json:"generated_by"`
 	}
@@ -1,6 +1,9 @@
 package cmd
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseDDLTagOptionsSupportsExplicitTypes(t *testing.T) {
 	t.Parallel()
@@ -29,3 +32,146 @@ func TestSplitDDLTagPartsKeepsTypeCommas(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDDLTagOptionsReadsSchemaChangeHints(t *testing.T) {
+	t.Parallel()
+
+	opts := parseDDLTagOptions(`name,size:64,was:full_name,allow_destructive`)
+	if opts.renamedFrom != "full_name" {
+		t.Fatalf("parseDDLTagOptions() renamedFrom = %q, want %q", opts.renamedFrom, "full_name")
+	}
+	if !opts.allowDestructive {
+		t.Fatal("parseDDLTagOptions() allowDestructive = false, want true")
+	}
+}
+
+func renameTestSnapshots() (ddlSnapshot, ddlSnapshot) {
+	previous := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "accounts",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "full_name", Kind: ddlColumnString, Size: 64},
+		},
+		Indexes: []ddlSnapshotIndex{{Name: "idx_users_name", Fields: []string{"full_name"}}},
+	}}}
+	current := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name:        "users",
+		RenamedFrom: "accounts",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", Kind: ddlColumnString, Size: 64, RenamedFrom: "full_name"},
+		},
+		Indexes: []ddlSnapshotIndex{{Name: "idx_users_name", Fields: []string{"name"}}},
+	}}}
+
+	return previous, current
+}
+
+func TestDiffDDLSnapshotsRendersRenameHints(t *testing.T) {
+	t.Parallel()
+
+	previous, current := renameTestSnapshots()
+	changes := diffDDLSnapshots(&previous, current)
+
+	tables := buildDDLRecordTables(changes)
+	if len(tables) != 1 || tables[0].Table != "users" ||
+		strings.Join(tables[0].Columns, "; ") != "rename table from accounts; rename column full_name to name" ||
+		len(tables[0].Indexes) != 0 {
+		t.Fatalf("buildDDLRecordTables() = %+v", tables)
+	}
+
+	reverse := diffDDLSnapshots(&current, invertDDLRenameHints(previous, changes))
+
+	for _, dialect := range ddlDialects {
+		diff, err := renderDDLIncrementalArtifact(dialect, changes, reverse)
+		if err != nil {
+			t.Fatalf("renderDDLIncrementalArtifact() error = %v", err)
+		}
+
+		q := dialect.dialect.QuoteField
+		wantUp := "ALTER TABLE " + q("accounts") + " RENAME TO " + q("users") + ";\n\n" +
+			"ALTER TABLE " + q("users") + " RENAME COLUMN " + q("full_name") + " TO " + q("name") + ";"
+		if !strings.Contains(diff.AggregateSQL, wantUp) || strings.Contains(diff.AggregateSQL, "DROP") {
+			t.Fatalf("%s up SQL = %q, want renames only", ddlDialectName(dialect), diff.AggregateSQL)
+		}
+
+		wantDown := "ALTER TABLE " + q("users") + " RENAME TO " + q("accounts") + ";\n\n" +
+			"ALTER TABLE " + q("accounts") + " RENAME COLUMN " + q("name") + " TO " + q("full_name") + ";"
+		if !strings.Contains(diff.DownSQL, wantDown) || strings.Contains(diff.DownSQL, "DROP") {
+			t.Fatalf("%s down SQL = %q, want renames only", ddlDialectName(dialect), diff.DownSQL)
+		}
+	}
+
+	// Once the rename is recorded, the hints left in the code are inert.
+	if again := diffDDLSnapshots(&current, current); len(again.Tables) != 0 {
+		t.Fatalf("expected applied rename hints to produce no changes, got %+v", again.ByTable)
+	}
+}
+
+func TestRenderSQLiteRebuildCopiesRenamedColumns(t *testing.T) {
+	t.Parallel()
+
+	previous, current := renameTestSnapshots()
+	current.Tables[0].Columns[1].Size = 128
+
+	var sqlite ddlDialectSpec
+	for _, dialect := range ddlDialects {
+		if ddlDialectName(dialect) == "sqlite" {
+			sqlite = dialect
+		}
+	}
+
+	body := renderDDLIncrementalAggregateBody(sqlite, diffDDLSnapshots(&previous, current))
+	for _, want := range []string{
+		`ALTER TABLE "accounts" RENAME TO "__tsq_rebuild_users";`,
+		`INSERT INTO "users" ("id", "name") SELECT "id", "full_name" FROM "__tsq_rebuild_users";`,
+		`CREATE INDEX "idx_users_name" ON "users"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected sqlite rebuild to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestRenderDDLIncrementalArtifactRecordsManualDown(t *testing.T) {
+	t.Parallel()
+
+	previous := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "code", Kind: ddlColumnString, Size: 16},
+		},
+	}}}
+	current := ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64},
+			{Name: "code", Kind: ddlColumnString, Size: 16},
+		},
+	}}}
+
+	changes := diffDDLSnapshots(&previous, current)
+	reverse := diffDDLSnapshots(&current, previous)
+
+	for _, dialect := range ddlDialects {
+		diff, err := renderDDLIncrementalArtifact(dialect, changes, reverse)
+		if err != nil {
+			t.Fatalf("renderDDLIncrementalArtifact() error = %v", err)
+		}
+
+		// SQLite 重建整张表，能恢复主键；其余方言只能留注释。
+		if ddlDialectName(dialect) == "sqlite" {
+			if len(diff.ManualDown) != 0 {
+				t.Fatalf("sqlite manual down = %q, want none", diff.ManualDown)
+			}
+
+			continue
+		}
+
+		want := "users: manual change required for primary key column id"
+		if len(diff.ManualDown) != 1 || diff.ManualDown[0] != want || !strings.Contains(diff.DownSQL, "-- "+want) {
+			t.Fatalf("%s manual down = %q, down SQL = %q", ddlDialectName(dialect), diff.ManualDown, diff.DownSQL)
+		}
+	}
+}
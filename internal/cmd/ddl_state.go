@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 
@@ -24,14 +26,44 @@ type ddlStateFile struct {
 	Version         string                        `json:"version"`
 	Snapshot        ddlSnapshot                   `json:"snapshot"`
 	InitialDialects map[string]ddlStateDialectSQL `json:"initial_dialects,omitempty"`
+	Squash          *ddlStateSquash               `json:"squash,omitempty"`
 	RenderedRecords int                           `json:"rendered_records,omitempty"`
 	Records         []ddlStateRecord              `json:"records,omitempty"`
+	// Proto 是 --emit proto 分配过的字段编号，按消息名分组。
+	Proto map[string]ddlStateProtoMessage `json:"proto,omitempty"`
 }
 
+// ddlStateProtoMessage 记录一个 protobuf 消息的字段编号。Reserved 是删掉的字段，编号不再复用。
+type ddlStateProtoMessage struct {
+	Fields   map[string]int `json:"fields,omitempty"`
+	Reserved map[string]int `json:"reserved,omitempty"`
+}
+
+// ddlStateSquash 记录最近一次 tsq gen --squash。Sequence 是压缩后初始 schema 的迁移名，
+// Through 是被并入的最后一步，Replaces 是历次压缩并入的全部步骤。
+type ddlStateSquash struct {
+	Sequence string   `json:"sequence"`
+	Through  string   `json:"through"`
+	Replaces []string `json:"replaces"`
+}
+
+// ddlStateRecord 是一条历史记录。ID 和 Parent 是记录前后快照的哈希，Changes 保存记录改动的表
+// 在前后的完整定义；两者让分支合并后的 tsq gen --rebase 可以不依赖记录顺序重建历史。
 type ddlStateRecord struct {
-	Sequence string                         `json:"sequence"`
-	Tables   []ddlStateRecordTable          `json:"tables"`
-	Dialects map[string]ddlStateDialectDiff `json:"dialects"`
+	ID           string                         `json:"id,omitempty"`
+	Parent       string                         `json:"parent,omitempty"`
+	Sequence     string                         `json:"sequence"`
+	Tables       []ddlStateRecordTable          `json:"tables"`
+	Changes      []ddlStateTableChange          `json:"changes,omitempty"`
+	Irreversible []string                       `json:"irreversible,omitempty"`
+	Dialects     map[string]ddlStateDialectDiff `json:"dialects"`
+}
+
+// ddlStateTableChange 是一张表在一条记录前后的定义；新建的表没有 Before，删除的表没有 After。
+type ddlStateTableChange struct {
+	Table  string            `json:"table"`
+	Before *ddlSnapshotTable `json:"before,omitempty"`
+	After  *ddlSnapshotTable `json:"after,omitempty"`
 }
 
 type ddlStateRecordTable struct {
@@ -42,6 +74,9 @@ type ddlStateRecordTable struct {
 
 type ddlStateDialectDiff struct {
 	AggregateSQL string `json:"aggregate_sql"`
+	DownSQL      string `json:"down_sql,omitempty"`
+	// ManualDown 列出 DownSQL 里只能留注释、要手工处理的变更；非空时 migrate down 视为不可逆。
+	ManualDown []string `json:"manual_down,omitempty"`
 }
 
 type ddlStateDialectSQL struct {
@@ -56,6 +91,12 @@ type ddlSnapshotTable struct {
 	Name    string              `json:"name"`
 	Columns []ddlSnapshotColumn `json:"columns"`
 	Indexes []ddlSnapshotIndex  `json:"indexes,omitempty"`
+	// RenamedFrom 是 @TABLE(renamed_from=...) 声明的旧表名，只参与本次 diff，不写入 tsq.json。
+	RenamedFrom string `json:"-"`
+	// AllowDestructive 来自 @TABLE(allow_destructive=true)，同样不写入 tsq.json。
+	AllowDestructive bool `json:"-"`
+	// AllowDrop 来自 @TABLE(allow_drop=[...])，是确认可以删除的列名，同样不写入 tsq.json。
+	AllowDrop []string `json:"-"`
 }
 
 type ddlSnapshotColumn struct {
@@ -69,6 +110,10 @@ type ddlSnapshotColumn struct {
 	PrimaryKey    bool          `json:"primary_key,omitempty"`
 	AutoIncrement bool          `json:"auto_increment,omitempty"`
 	Default       string        `json:"default,omitempty"`
+	// RenamedFrom 是 db 标签 was:<old_name> 声明的旧列名，只参与本次 diff，不写入 tsq.json。
+	RenamedFrom string `json:"-"`
+	// AllowDestructive 来自 db 标签选项 allow_destructive，同样不写入 tsq.json。
+	AllowDestructive bool `json:"-"`
 }
 
 type ddlSnapshotIndex struct {
@@ -94,13 +139,15 @@ type ddlChange struct {
 }
 
 const (
-	ddlChangeCreateTable = "create_table"
-	ddlChangeDropTable   = "drop_table"
-	ddlChangeAddColumn   = "add_column"
-	ddlChangeDropColumn  = "drop_column"
-	ddlChangeAlterColumn = "alter_column"
-	ddlChangeAddIndex    = "add_index"
-	ddlChangeDropIndex   = "drop_index"
+	ddlChangeCreateTable  = "create_table"
+	ddlChangeDropTable    = "drop_table"
+	ddlChangeRenameTable  = "rename_table"
+	ddlChangeAddColumn    = "add_column"
+	ddlChangeDropColumn   = "drop_column"
+	ddlChangeAlterColumn  = "alter_column"
+	ddlChangeRenameColumn = "rename_column"
+	ddlChangeAddIndex     = "add_index"
+	ddlChangeDropIndex    = "drop_index"
 )
 
 func buildCurrentDDLSnapshot(tables []*genmodel.StructInfo, resolver *ddlTypeResolver) (ddlSnapshot, error) {
@@ -127,9 +174,12 @@ func buildCurrentDDLTableSnapshot(
 	resolver *ddlTypeResolver,
 ) (ddlSnapshotTable, error) {
 	result := ddlSnapshotTable{
-		Name:    table.Table,
-		Columns: make([]ddlSnapshotColumn, 0, len(table.Fields)),
-		Indexes: make([]ddlSnapshotIndex, 0, len(table.UxList)+len(table.IdxList)),
+		Name:             table.Table,
+		RenamedFrom:      table.RenamedFrom,
+		AllowDestructive: table.AllowDestructive,
+		AllowDrop:        table.AllowDrop,
+		Columns:          make([]ddlSnapshotColumn, 0, len(table.Fields)),
+		Indexes:          make([]ddlSnapshotIndex, 0, len(table.UxList)+len(table.IdxList)),
 	}
 
 	for _, field := range orderedDDLFields(table) {
@@ -139,16 +189,18 @@ func buildCurrentDDLTableSnapshot(
 		}
 
 		result.Columns = append(result.Columns, ddlSnapshotColumn{
-			Name:          field.Column,
-			Kind:          desc.kind,
-			Bits:          desc.bits,
-			Unsigned:      desc.unsigned,
-			Nullable:      desc.nullable,
-			Size:          desc.size,
-			RawType:       desc.rawType,
-			PrimaryKey:    field.Name == table.PK,
-			AutoIncrement: field.Name == table.PK && table.AI,
-			Default:       ddlManagedDefaultClause(table, field, desc),
+			Name:             field.Column,
+			Kind:             desc.kind,
+			Bits:             desc.bits,
+			Unsigned:         desc.unsigned,
+			Nullable:         desc.nullable,
+			Size:             desc.size,
+			RawType:          desc.rawType,
+			PrimaryKey:       field.Name == table.PK,
+			AutoIncrement:    field.Name == table.PK && table.AI,
+			Default:          ddlManagedDefaultClause(table, field, desc),
+			RenamedFrom:      desc.renamedFrom,
+			AllowDestructive: desc.allowDestructive,
 		})
 	}
 
@@ -204,23 +256,31 @@ func loadDDLStateFile(outDir string) (*ddlStateFile, error) {
 			return nil, err
 		}
 
-		if !isGeneratedDDLArtifact(content) {
-			return nil, fmt.Errorf("refusing to read non-generated DDL state file: %s", filename)
+		if hasMergeConflictMarkers(content) {
+			return nil, fmt.Errorf("DDL state file has unresolved merge conflicts: %s: run tsq gen --rebase", filename)
 		}
 
-		var state ddlStateFile
-		if err := json.Unmarshal(content, &state); err != nil {
-			return nil, fmt.Errorf("failed to parse DDL state file: %s"+": %w", filename, err)
-		}
+		return parseDDLStateFile(filename, content)
+	}
 
-		if state.RenderedRecords > len(state.Records) {
-			state.RenderedRecords = len(state.Records)
-		}
+	return nil, nil
+}
 
-		return &state, nil
+func parseDDLStateFile(filename string, content []byte) (*ddlStateFile, error) {
+	if !isGeneratedDDLArtifact(content) {
+		return nil, fmt.Errorf("refusing to read non-generated DDL state file: %s", filename)
 	}
 
-	return nil, nil
+	var state ddlStateFile
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse DDL state file: %s"+": %w", filename, err)
+	}
+
+	if state.RenderedRecords > len(state.Records) {
+		state.RenderedRecords = len(state.Records)
+	}
+
+	return &state, nil
 }
 
 func marshalDDLStateFile(
@@ -229,9 +289,8 @@ func marshalDDLStateFile(
 	current ddlSnapshot,
 	initialDialects map[string]ddlStateDialectSQL,
 	renderedRecords int,
-	recordTables []ddlStateRecordTable,
-	dialects map[string]ddlStateDialectDiff,
-	sequence string,
+	record *ddlStateRecord,
+	proto map[string]ddlStateProtoMessage,
 ) ([]byte, error) {
 	state := ddlStateFile{
 		GeneratedBy:     "tsq-" + version,
@@ -239,9 +298,11 @@ func marshalDDLStateFile(
 		Snapshot:        current,
 		InitialDialects: cloneDDLStateDialects(initialDialects),
 		RenderedRecords: renderedRecords,
+		Proto:           proto,
 	}
 
 	if previous != nil {
+		state.Squash = previous.Squash
 		state.Records = append(state.Records, previous.Records...)
 	}
 
@@ -249,14 +310,10 @@ func marshalDDLStateFile(
 		state.RenderedRecords = len(state.Records)
 	}
 
-	if sequence != "" && len(recordTables) > 0 {
-		record := ddlStateRecord{
-			Sequence: sequence,
-			Tables:   append([]ddlStateRecordTable(nil), recordTables...),
-			Dialects: cloneDDLStateDiffs(dialects),
-		}
-
-		state.Records = append(state.Records, record)
+	if record != nil && record.Sequence != "" && len(record.Tables) > 0 {
+		item := *record
+		item.Dialects = cloneDDLStateDiffs(record.Dialects)
+		state.Records = append(state.Records, item)
 	}
 
 	content, err := json.MarshalIndent(state, "", "  ")
@@ -267,6 +324,79 @@ func marshalDDLStateFile(
 	return append(content, '\n'), nil
 }
 
+// buildDDLStateRecord 渲染从 previous 到 current 的一条历史记录，changes 是两者的 diff。
+func buildDDLStateRecord(previous, current ddlSnapshot, changes ddlChangeSet, sequence string) (*ddlStateRecord, error) {
+	reverse := diffDDLSnapshots(&current, invertDDLRenameHints(previous, changes))
+
+	dialects := make(map[string]ddlStateDialectDiff, len(ddlDialects))
+	for _, dialect := range ddlDialects {
+		diff, err := renderDDLIncrementalArtifact(dialect, changes, reverse)
+		if err != nil {
+			return nil, err
+		}
+
+		dialects[ddlDialectName(dialect)] = diff
+	}
+
+	id, err := ddlSnapshotHash(current)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := ddlSnapshotHash(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ddlStateRecord{
+		ID:           id,
+		Parent:       parent,
+		Sequence:     sequence,
+		Tables:       buildDDLRecordTables(changes),
+		Changes:      buildDDLRecordChanges(previous, current, changes),
+		Irreversible: buildDDLIrreversibleNotes(changes),
+		Dialects:     dialects,
+	}, nil
+}
+
+// squashDDLState 把历史记录并入新的初始 schema：初始 SQL 按上一次快照重新渲染，
+// 被并入的迁移步骤记进 Squash，tsq migrate 据此认出已经执行过这些步骤的数据库。
+func squashDDLState(previous *ddlStateFile, version, sequence string) (*ddlStateFile, error) {
+	if previous == nil || len(previous.Records) == 0 {
+		return nil, errors.New("nothing to squash: tsq.json has no history records")
+	}
+
+	// RenderedRecords 之前的记录已经并入初始 SQL，不是独立的迁移步骤。
+	replaces := []string{initialMigrationSequence}
+	if previous.Squash != nil {
+		replaces = append(slices.Clone(previous.Squash.Replaces), previous.Squash.Sequence)
+	}
+
+	for _, record := range previous.Records[previous.RenderedRecords:] {
+		replaces = append(replaces, record.Sequence)
+	}
+
+	initial := make(map[string]ddlStateDialectSQL, len(ddlDialects))
+	for _, dialect := range ddlDialects {
+		initial[ddlDialectName(dialect)] = ddlStateDialectSQL{
+			SQL: string(renderDDLSnapshotAggregateFile(version, previous.Snapshot, dialect)),
+		}
+	}
+
+	return &ddlStateFile{
+		GeneratedBy:     previous.GeneratedBy,
+		Version:         previous.Version,
+		Snapshot:        previous.Snapshot,
+		InitialDialects: initial,
+		Squash: &ddlStateSquash{
+			Sequence: sequence,
+			Through:  replaces[len(replaces)-1],
+			Replaces: replaces,
+		},
+		Proto: previous.Proto,
+	}, nil
+}
+
 func cloneDDLStateDialects(items map[string]ddlStateDialectSQL) map[string]ddlStateDialectSQL {
 	if len(items) == 0 {
 		return nil
@@ -336,11 +466,32 @@ func diffDDLSnapshots(previous *ddlSnapshot, current ddlSnapshot) ddlChangeSet {
 
 	sort.Strings(allNames)
 
+	renamedTables := resolveDDLTableRenames(previousByName, currentByName)
+
+	renamedSources := make(map[string]struct{}, len(renamedTables))
+	for _, oldName := range renamedTables {
+		renamedSources[oldName] = struct{}{}
+	}
+
 	for _, tableName := range allNames {
+		if _, ok := renamedSources[tableName]; ok {
+			continue
+		}
+
 		before, hadBefore := previousByName[tableName]
 		after, hasAfter := currentByName[tableName]
+		oldName, renamed := renamedTables[tableName]
 
 		switch {
+		case renamed:
+			before = previousByName[oldName]
+			result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
+				kind:     ddlChangeRenameTable,
+				table:    tableName,
+				oldTable: new(before),
+				newTable: new(after),
+			})
+			diffExistingDDLTable(&result, before, after)
 		case !hadBefore && hasAfter:
 			result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
 				kind:     ddlChangeCreateTable,
@@ -370,6 +521,93 @@ func diffDDLSnapshots(previous *ddlSnapshot, current ddlSnapshot) ddlChangeSet {
 	return result
 }
 
+// resolveDDLTableRenames 返回 新表名 -> 旧表名。只有旧表仍在上一次快照里、新表名尚未出现过时，
+// renamed_from 才算一次改名；改名完成后留在代码里的提示因此不会再产生变更。
+func resolveDDLTableRenames(previous, current map[string]ddlSnapshotTable) map[string]string {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	renames := make(map[string]string)
+	claimed := make(map[string]struct{})
+
+	for _, name := range names {
+		oldName := current[name].RenamedFrom
+		if oldName == "" || oldName == name {
+			continue
+		}
+
+		if _, ok := previous[name]; ok {
+			continue
+		}
+
+		if _, ok := previous[oldName]; !ok {
+			continue
+		}
+
+		if _, ok := current[oldName]; ok {
+			continue
+		}
+
+		if _, ok := claimed[oldName]; ok {
+			continue
+		}
+
+		claimed[oldName] = struct{}{}
+		renames[name] = oldName
+	}
+
+	return renames
+}
+
+// resolveDDLColumnRenames 按与 resolveDDLTableRenames 相同的规则解析 was:<old_name>，
+// 返回 新列名 -> 旧列名。
+func resolveDDLColumnRenames(before, after ddlSnapshotTable) map[string]string {
+	beforeColumns := make(map[string]struct{}, len(before.Columns))
+	for _, column := range before.Columns {
+		beforeColumns[column.Name] = struct{}{}
+	}
+
+	afterColumns := make(map[string]struct{}, len(after.Columns))
+	for _, column := range after.Columns {
+		afterColumns[column.Name] = struct{}{}
+	}
+
+	renames := make(map[string]string)
+	claimed := make(map[string]struct{})
+
+	for _, column := range after.Columns {
+		oldName := column.RenamedFrom
+		if oldName == "" || oldName == column.Name {
+			continue
+		}
+
+		if _, ok := beforeColumns[column.Name]; ok {
+			continue
+		}
+
+		if _, ok := beforeColumns[oldName]; !ok {
+			continue
+		}
+
+		if _, ok := afterColumns[oldName]; ok {
+			continue
+		}
+
+		if _, ok := claimed[oldName]; ok {
+			continue
+		}
+
+		claimed[oldName] = struct{}{}
+		renames[column.Name] = oldName
+	}
+
+	return renames
+}
+
 func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable) {
 	tableName := after.Name
 	beforeTableCopy := before
@@ -385,11 +623,39 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 		afterColumns[column.Name] = column
 	}
 
+	renamedColumns := resolveDDLColumnRenames(before, after)
+
+	renamedSources := make(map[string]string, len(renamedColumns))
+	for newName, oldName := range renamedColumns {
+		renamedSources[oldName] = newName
+	}
+
+	// 改名要先于删列、加列和改列执行，后续语句引用的都是新列名。
+	for _, column := range after.Columns {
+		oldName, ok := renamedColumns[column.Name]
+		if !ok {
+			continue
+		}
+
+		result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
+			kind:      ddlChangeRenameColumn,
+			table:     tableName,
+			oldTable:  &beforeTableCopy,
+			newTable:  &afterTableCopy,
+			oldColumn: new(beforeColumns[oldName]),
+			newColumn: new(column),
+		})
+	}
+
 	for _, column := range before.Columns {
 		if _, ok := afterColumns[column.Name]; ok {
 			continue
 		}
 
+		if _, ok := renamedSources[column.Name]; ok {
+			continue
+		}
+
 		result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
 			kind:      ddlChangeDropColumn,
 			table:     tableName,
@@ -401,6 +667,11 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 
 	for _, column := range after.Columns {
 		beforeColumn, ok := beforeColumns[column.Name]
+		if oldName, renamed := renamedColumns[column.Name]; renamed {
+			beforeColumn, ok = beforeColumns[oldName], true
+			beforeColumn.Name = column.Name
+		}
+
 		if !ok {
 			result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
 				kind:      ddlChangeAddColumn,
@@ -413,7 +684,7 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 			continue
 		}
 
-		if reflect.DeepEqual(beforeColumn, column) {
+		if ddlSnapshotColumnsEqual(beforeColumn, column) {
 			continue
 		}
 
@@ -438,6 +709,9 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 	}
 
 	for _, idx := range before.Indexes {
+		// 改名的列会带着索引一起改名，比较前先把旧索引的列名换成新列名。
+		renamedIdx := renameDDLIndexFields(idx, renamedSources)
+
 		next, ok := afterIndexes[idx.Name]
 		if !ok {
 			result.ByTable[tableName] = append(result.ByTable[tableName], ddlChange{
@@ -451,7 +725,7 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 			continue
 		}
 
-		if reflect.DeepEqual(idx, next) {
+		if reflect.DeepEqual(renamedIdx, next) {
 			continue
 		}
 
@@ -486,6 +760,73 @@ func diffExistingDDLTable(result *ddlChangeSet, before, after ddlSnapshotTable)
 	}
 }
 
+func ddlSnapshotColumnsEqual(left, right ddlSnapshotColumn) bool {
+	left.RenamedFrom, left.AllowDestructive = "", false
+	right.RenamedFrom, right.AllowDestructive = "", false
+
+	return reflect.DeepEqual(left, right)
+}
+
+func renameDDLIndexFields(idx ddlSnapshotIndex, renamedSources map[string]string) ddlSnapshotIndex {
+	if len(renamedSources) == 0 {
+		return idx
+	}
+
+	fields := make([]string, 0, len(idx.Fields))
+	for _, field := range idx.Fields {
+		if newName, ok := renamedSources[field]; ok {
+			field = newName
+		}
+
+		fields = append(fields, field)
+	}
+
+	idx.Fields = fields
+
+	return idx
+}
+
+// invertDDLRenameHints 为逆向 diff 准备旧快照：把正向 diff 中的改名反过来标到旧快照上，
+// 使 down 生成 RENAME 而不是删表/删列再重建。
+func invertDDLRenameHints(previous ddlSnapshot, changes ddlChangeSet) ddlSnapshot {
+	result := ddlSnapshot{Tables: make([]ddlSnapshotTable, 0, len(previous.Tables))}
+
+	positions := make(map[string]int, len(previous.Tables))
+	for idx, table := range previous.Tables {
+		table.Columns = append([]ddlSnapshotColumn(nil), table.Columns...)
+		positions[table.Name] = idx
+		result.Tables = append(result.Tables, table)
+	}
+
+	for _, tableName := range changes.Tables {
+		for _, op := range changes.ByTable[tableName] {
+			if op.oldTable == nil {
+				continue
+			}
+
+			idx, ok := positions[op.oldTable.Name]
+			if !ok {
+				continue
+			}
+
+			table := &result.Tables[idx]
+
+			switch op.kind {
+			case ddlChangeRenameTable:
+				table.RenamedFrom = op.newTable.Name
+			case ddlChangeRenameColumn:
+				for i := range table.Columns {
+					if table.Columns[i].Name == op.oldColumn.Name {
+						table.Columns[i].RenamedFrom = op.newColumn.Name
+					}
+				}
+			}
+		}
+	}
+
+	return result
+}
+
 func buildDDLRecordTables(changes ddlChangeSet) []ddlStateRecordTable {
 	result := make([]ddlStateRecordTable, 0, len(changes.Tables))
 
@@ -543,9 +884,9 @@ func compareDDLChanges(left, right ddlChange) int {
 
 func ddlChangeCategoryRank(change ddlChange) int {
 	switch change.kind {
-	case ddlChangeCreateTable, ddlChangeDropTable:
+	case ddlChangeCreateTable, ddlChangeDropTable, ddlChangeRenameTable:
 		return 0
-	case ddlChangeAddColumn, ddlChangeAlterColumn, ddlChangeDropColumn:
+	case ddlChangeAddColumn, ddlChangeAlterColumn, ddlChangeDropColumn, ddlChangeRenameColumn:
 		return 1
 	case ddlChangeAddIndex, ddlChangeDropIndex:
 		if ddlChangeIndexUnique(change) {
@@ -560,24 +901,26 @@ func ddlChangeCategoryRank(change ddlChange) int {
 
 func ddlChangeActionRank(change ddlChange) int {
 	switch change.kind {
-	case ddlChangeCreateTable, ddlChangeAddColumn, ddlChangeAddIndex:
+	case ddlChangeRenameTable, ddlChangeRenameColumn:
 		return 0
-	case ddlChangeAlterColumn:
+	case ddlChangeCreateTable, ddlChangeAddColumn, ddlChangeAddIndex:
 		return 1
-	case ddlChangeDropColumn, ddlChangeDropIndex, ddlChangeDropTable:
+	case ddlChangeAlterColumn:
 		return 2
-	default:
+	case ddlChangeDropColumn, ddlChangeDropIndex, ddlChangeDropTable:
 		return 3
+	default:
+		return 4
 	}
 }
 
 func ddlChangeObjectName(change ddlChange) string {
 	switch change.kind {
-	case ddlChangeCreateTable:
+	case ddlChangeCreateTable, ddlChangeRenameTable:
 		return change.newTable.Name
 	case ddlChangeDropTable:
 		return change.oldTable.Name
-	case ddlChangeAddColumn, ddlChangeAlterColumn:
+	case ddlChangeAddColumn, ddlChangeAlterColumn, ddlChangeRenameColumn:
 		return change.newColumn.Name
 	case ddlChangeDropColumn:
 		return change.oldColumn.Name
@@ -607,6 +950,10 @@ func classifyDDLRecordLine(change ddlChange) (string, bool) {
 		return "create table", false
 	case ddlChangeDropTable:
 		return "drop table", false
+	case ddlChangeRenameTable:
+		return "rename table from " + change.oldTable.Name, false
+	case ddlChangeRenameColumn:
+		return "rename column " + change.oldColumn.Name + " to " + change.newColumn.Name, false
 	case ddlChangeAddColumn:
 		return "add column " + change.newColumn.Name, false
 	case ddlChangeDropColumn:
@@ -737,14 +1084,93 @@ func renderDDLIndexCreateStatement(tableName string, idx ddlSnapshotIndex, diale
 	return dialect.dialect.DDLCreateIndex(tableName, idx.Name, quotedFields, idx.Unique)
 }
 
-func renderDDLIncrementalArtifact(dialect ddlDialectSpec, changes ddlChangeSet) (ddlStateDialectDiff, error) {
+// renderDDLIncrementalArtifact 渲染一条历史记录的正向 DDL 和逆向 DDL。逆向 DDL 就是
+// 从新快照到旧快照的正向差异，因此列类型、索引和表结构都按旧快照恢复。
+func renderDDLIncrementalArtifact(dialect ddlDialectSpec, changes, reverse ddlChangeSet) (ddlStateDialectDiff, error) {
 	result := ddlStateDialectDiff{
 		AggregateSQL: renderDDLIncrementalAggregateBody(dialect, changes),
+		DownSQL:      renderDDLIncrementalAggregateBody(dialect, reverse),
+		ManualDown:   ddlManualChanges(dialect, reverse),
 	}
 
 	return result, nil
 }
 
+// buildDDLIrreversibleNotes 列出逆向 DDL 只能恢复结构、恢复不了数据的变更，范围与
+// classifyDDLChange 判为 destructive 的变更一致：删表删列、缩短长度、收窄位宽、改符号和类型转换。
+func buildDDLIrreversibleNotes(changes ddlChangeSet) []string {
+	var notes []string
+
+	for _, tableName := range changes.Tables {
+		for _, op := range changes.ByTable[tableName] {
+			class, reason := classifyDDLChange(op)
+			if class != ddlChangeDestructive {
+				continue
+			}
+
+			switch op.kind {
+			case ddlChangeDropTable:
+				notes = append(notes, fmt.Sprintf("%s: drop table loses its rows; down recreates it empty", tableName))
+			case ddlChangeDropColumn:
+				notes = append(notes, fmt.Sprintf("%s: drop column %s loses its values; down re-adds it empty", tableName, op.oldColumn.Name))
+			default:
+				notes = append(notes, fmt.Sprintf("%s: alter column %s %s; down restores the old type, not the lost data", tableName, op.newColumn.Name, reason))
+			}
+		}
+	}
+
+	return notes
+}
+
+// ddlManualChanges 列出 changes 在 dialect 上生成不了 SQL、只能留注释手工处理的变更。
+func ddlManualChanges(dialect ddlDialectSpec, changes ddlChangeSet) []string {
+	var result []string
+
+	for _, tableName := range changes.Tables {
+		ops := changes.ByTable[tableName]
+		if dialect.dialect.DDLAlterColumnMode() == tsqdialect.DDLAlterColumnRebuild && ddlChangesRequireTableRebuild(ops) {
+			if before, after := ddlRebuildSnapshots(ops); before == nil || after == nil {
+				result = append(result, tableName+": "+ddlManualRebuildReason)
+			}
+
+			continue
+		}
+
+		for _, op := range ops {
+			if reason := ddlManualChangeReason(dialect, op); reason != "" {
+				result = append(result, op.table+": "+reason)
+			}
+		}
+	}
+
+	return result
+}
+
+// ddlManualRebuildReason 是缺少前后表定义、没法生成 SQLite 重建语句时的说明。
+const ddlManualRebuildReason = "manual change required to rebuild table for sqlite"
+
+// ddlManualChangeReason 返回 op 在 dialect 上需要手工处理的原因；能直接生成 SQL 时返回空串。
+func ddlManualChangeReason(dialect ddlDialectSpec, op ddlChange) string {
+	switch op.kind {
+	case ddlChangeAddColumn:
+		if op.newColumn.PrimaryKey || op.newColumn.AutoIncrement {
+			return fmt.Sprintf("manual change required to add primary key column %s", op.newColumn.Name)
+		}
+	case ddlChangeAlterColumn:
+		before, after := *op.oldColumn, *op.newColumn
+		switch {
+		case before.PrimaryKey != after.PrimaryKey || before.AutoIncrement != after.AutoIncrement:
+			return fmt.Sprintf("manual change required for primary key column %s", after.Name)
+		case dialect.dialect.DDLAlterColumnMode() != tsqdialect.DDLAlterColumnDirect:
+			return fmt.Sprintf("manual change required for column %s on %s", after.Name, ddlDialectName(dialect))
+		case len(dialect.dialect.DDLAlterColumnStatements(op.table, ddlColumnSpecFromSnapshot(before), ddlColumnSpecFromSnapshot(after))) == 0:
+			return fmt.Sprintf("manual change required for column %s", after.Name)
+		}
+	}
+
+	return ""
+}
+
 func renderDDLIncrementalAggregateBody(dialect ddlDialectSpec, changes ddlChangeSet) string {
 	if len(changes.Tables) == 0 {
 		return "-- No schema changes."
@@ -810,10 +1236,8 @@ func ddlChangesRequireTableRebuild(ops []ddlChange) bool {
 	return false
 }
 
-func renderSQLiteRebuildTableBody(dialect ddlDialectSpec, tableName string, ops []ddlChange) (string, bool) {
-	var before *ddlSnapshotTable
-	var after *ddlSnapshotTable
-
+// ddlRebuildSnapshots 找出重建表所需的前后表定义，缺哪个就返回 nil。
+func ddlRebuildSnapshots(ops []ddlChange) (before, after *ddlSnapshotTable) {
 	for _, op := range ops {
 		if before == nil && op.oldTable != nil {
 			before = op.oldTable
@@ -824,29 +1248,41 @@ func renderSQLiteRebuildTableBody(dialect ddlDialectSpec, tableName string, ops
 		}
 	}
 
+	return before, after
+}
+
+func renderSQLiteRebuildTableBody(dialect ddlDialectSpec, tableName string, ops []ddlChange) (string, bool) {
+	before, after := ddlRebuildSnapshots(ops)
 	if before == nil || after == nil {
-		return renderDDLManualComment(tableName, "manual change required to rebuild table for sqlite"), true
+		return renderDDLManualComment(tableName, ddlManualRebuildReason), true
 	}
 
+	renamedColumns := make(map[string]string)
+	for _, op := range ops {
+		if op.kind == ddlChangeRenameColumn {
+			renamedColumns[op.newColumn.Name] = op.oldColumn.Name
+		}
+	}
+
+	// 重建直接从旧表名改到临时表，表改名和列改名都体现在下面的 INSERT ... SELECT 里。
 	tempTable := "__tsq_rebuild_" + tableName
 	statements := []string{
 		"BEGIN TRANSACTION;",
 		fmt.Sprintf(
 			"ALTER TABLE %s RENAME TO %s;",
-			dialect.dialect.QuoteField(tableName),
+			dialect.dialect.QuoteField(before.Name),
 			dialect.dialect.QuoteField(tempTable),
 		),
 		renderDDLSnapshotCreateTable(*after, dialect),
 	}
 
-	commonColumns := sharedDDLSnapshotColumns(*before, *after)
-	if len(commonColumns) > 0 {
-		quotedColumns := quoteDDLColumns(commonColumns, dialect)
+	targetColumns, sourceColumns := sharedDDLSnapshotColumns(*before, *after, renamedColumns)
+	if len(targetColumns) > 0 {
 		statements = append(statements, fmt.Sprintf(
 			"INSERT INTO %s (%s) SELECT %s FROM %s;",
 			dialect.dialect.QuoteField(tableName),
-			strings.Join(quotedColumns, ", "),
-			strings.Join(quotedColumns, ", "),
+			strings.Join(quoteDDLColumns(targetColumns, dialect), ", "),
+			strings.Join(quoteDDLColumns(sourceColumns, dialect), ", "),
 			dialect.dialect.QuoteField(tempTable),
 		))
 	}
@@ -859,20 +1295,29 @@ func renderSQLiteRebuildTableBody(dialect ddlDialectSpec, tableName string, ops
 	return strings.Join(statements, "\n\n"), true
 }
 
-func sharedDDLSnapshotColumns(before, after ddlSnapshotTable) []string {
+// sharedDDLSnapshotColumns 返回重建时要复制的 (新列, 旧列) 两组列名，renamedColumns 为 新列名 -> 旧列名。
+func sharedDDLSnapshotColumns(before, after ddlSnapshotTable, renamedColumns map[string]string) ([]string, []string) {
 	beforeColumns := make(map[string]struct{}, len(before.Columns))
 	for _, column := range before.Columns {
 		beforeColumns[column.Name] = struct{}{}
 	}
 
-	names := make([]string, 0, len(after.Columns))
+	targets := make([]string, 0, len(after.Columns))
+	sources := make([]string, 0, len(after.Columns))
+
 	for _, column := range after.Columns {
-		if _, ok := beforeColumns[column.Name]; ok {
-			names = append(names, column.Name)
+		source := column.Name
+		if oldName, ok := renamedColumns[column.Name]; ok {
+			source = oldName
+		}
+
+		if _, ok := beforeColumns[source]; ok {
+			targets = append(targets, column.Name)
+			sources = append(sources, source)
 		}
 	}
 
-	return names
+	return targets, sources
 }
 
 func quoteDDLColumns(columns []string, dialect ddlDialectSpec) []string {
@@ -885,16 +1330,29 @@ func quoteDDLColumns(columns []string, dialect ddlDialectSpec) []string {
 }
 
 func renderDDLChangeOperation(dialect ddlDialectSpec, op ddlChange) []string {
+	if reason := ddlManualChangeReason(dialect, op); reason != "" {
+		return []string{renderDDLManualComment(op.table, reason)}
+	}
+
 	switch op.kind {
 	case ddlChangeCreateTable:
 		return []string{renderDDLSnapshotTableBlock(*op.newTable, dialect)}
 	case ddlChangeDropTable:
 		return []string{fmt.Sprintf("DROP TABLE %s;", dialect.dialect.QuoteField(op.oldTable.Name))}
+	case ddlChangeRenameTable:
+		return []string{fmt.Sprintf(
+			"ALTER TABLE %s RENAME TO %s;",
+			dialect.dialect.QuoteField(op.oldTable.Name),
+			dialect.dialect.QuoteField(op.newTable.Name),
+		)}
+	case ddlChangeRenameColumn:
+		return []string{fmt.Sprintf(
+			"ALTER TABLE %s RENAME COLUMN %s TO %s;",
+			dialect.dialect.QuoteField(op.table),
+			dialect.dialect.QuoteField(op.oldColumn.Name),
+			dialect.dialect.QuoteField(op.newColumn.Name),
+		)}
 	case ddlChangeAddColumn:
-		if op.newColumn.PrimaryKey || op.newColumn.AutoIncrement {
-			return []string{renderDDLManualComment(op.table, fmt.Sprintf("manual change required to add primary key column %s", op.newColumn.Name))}
-		}
-
 		return []string{fmt.Sprintf(
 			"ALTER TABLE %s ADD COLUMN %s;",
 			dialect.dialect.QuoteField(op.table),
@@ -907,7 +1365,7 @@ func renderDDLChangeOperation(dialect ddlDialectSpec, op ddlChange) []string {
 			dialect.dialect.QuoteField(op.oldColumn.Name),
 		)}
 	case ddlChangeAlterColumn:
-		return renderDDLAlterColumnStatements(dialect, op.table, *op.oldColumn, *op.newColumn)
+		return dialect.dialect.DDLAlterColumnStatements(op.table, ddlColumnSpecFromSnapshot(*op.oldColumn), ddlColumnSpecFromSnapshot(*op.newColumn))
 	case ddlChangeAddIndex:
 		return []string{renderDDLIndexCreateStatement(op.table, *op.newIndex, dialect)}
 	case ddlChangeDropIndex:
@@ -917,28 +1375,6 @@ func renderDDLChangeOperation(dialect ddlDialectSpec, op ddlChange) []string {
 	}
 }
 
-func renderDDLAlterColumnStatements(
-	dialect ddlDialectSpec,
-	tableName string,
-	before ddlSnapshotColumn,
-	after ddlSnapshotColumn,
-) []string {
-	if before.PrimaryKey != after.PrimaryKey || before.AutoIncrement != after.AutoIncrement {
-		return []string{renderDDLManualComment(tableName, fmt.Sprintf("manual change required for primary key column %s", after.Name))}
-	}
-
-	if dialect.dialect.DDLAlterColumnMode() != tsqdialect.DDLAlterColumnDirect {
-		return []string{renderDDLManualComment(tableName, fmt.Sprintf("manual change required for column %s on %s", after.Name, ddlDialectName(dialect)))}
-	}
-
-	statements := dialect.dialect.DDLAlterColumnStatements(tableName, ddlColumnSpecFromSnapshot(before), ddlColumnSpecFromSnapshot(after))
-	if len(statements) == 0 {
-		return []string{renderDDLManualComment(tableName, fmt.Sprintf("manual change required for column %s", after.Name))}
-	}
-
-	return statements
-}
-
 func ddlColumnTypeChanged(before, after ddlSnapshotColumn) bool {
 	return before.Kind != after.Kind ||
 		before.Bits != after.Bits ||
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+	"github.com/tmoeish/tsq/v4/internal/ddlcompare"
+)
+
+const (
+	schemaDifferenceMissing  = "missing"
+	schemaDifferenceExtra    = "extra"
+	schemaDifferenceMismatch = "mismatch"
+)
+
+var (
+	diffDriverFlag string
+	diffDSNFlag    string
+	diffJSONFlag   bool
+)
+
+func init() {
+	DiffCmd.Flags().StringVar(&diffDriverFlag, "driver", "", "database driver: sqlite, mysql, or postgres")
+	DiffCmd.Flags().StringVar(&diffDSNFlag, "dsn", "", "data source name of the database to inspect")
+	DiffCmd.Flags().BoolVar(&diffJSONFlag, "json", false, "print the differences and the reconcile DDL as JSON")
+}
+
+// DiffCmd compares a live database schema with the snapshot recorded in tsq.json.
+var DiffCmd = &cobra.Command{
+	Use:   "diff <package-or-dir>",
+	Short: "Compare a live database schema with the generated snapshot",
+	Long: `Compare the schema of a live database with the snapshot that tsq gen records
+in tsq.json, and print the DDL that would reconcile the database.
+
+The database is inspected with the tables, columns and indexes reported by the
+dialect selected by --driver. Bookkeeping tables whose names start with _tsq_
+or __tsq_rebuild_ are ignored. Every other table that the snapshot does not
+declare is reported as extra.
+
+Differences:
+  - missing:  declared in tsq.json but absent from the database
+  - extra:    present in the database but not declared
+  - mismatch: present in both with a different type, nullability, default,
+              key, or index definition
+
+The command exits with a non-zero status when it finds any difference, so it
+can run the same check as SchemaPolicyValidate in CI or an ops runbook without
+starting the application.`,
+	Example: strings.Join([]string{
+		"  tsq diff --driver sqlite --dsn ./app.db ./examples/academy",
+		"  tsq diff --driver postgres --dsn \"$DATABASE_URL\" --json ./internal/database",
+	}, "\n"),
+	Args: exactOnePackageArgFor("diff"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		report, err := buildSchemaDiffReport(ctx, args[0], diffDriverFlag, diffDSNFlag)
+		if err != nil {
+			return err
+		}
+
+		if diffJSONFlag {
+			err = writeSchemaDiffJSON(cmd.OutOrStdout(), report)
+		} else {
+			err = writeSchemaDiffText(cmd.OutOrStdout(), report)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if report.Drift {
+			return fmt.Errorf("schema drift detected: %d difference(s)", len(report.Differences))
+		}
+
+		return nil
+	},
+}
+
+type schemaDiffReport struct {
+	Package     string             `json:"package"`
+	Dialect     string             `json:"dialect"`
+	Drift       bool               `json:"drift"`
+	Differences []schemaDifference `json:"differences"`
+	// DDL 是把数据库改成 tsq.json 快照所需的语句，没有差异时为空。
+	DDL string `json:"ddl,omitempty"`
+}
+
+type schemaDifference struct {
+	Object   string `json:"object"`
+	Table    string `json:"table"`
+	Name     string `json:"name,omitempty"`
+	Kind     string `json:"kind"`
+	Live     string `json:"live,omitempty"`
+	Declared string `json:"declared,omitempty"`
+}
+
+func buildSchemaDiffReport(ctx context.Context, packagePath, driverName, dsn string) (schemaDiffReport, error) {
+	if driverName == "" {
+		return schemaDiffReport{}, errors.New("tsq diff requires --driver")
+	}
+
+	if dsn == "" {
+		return schemaDiffReport{}, errors.New("tsq diff requires --dsn")
+	}
+
+	sqlDriver, dialect, err := resolveDatabaseDriver("diff", driverName)
+	if err != nil {
+		return schemaDiffReport{}, err
+	}
+
+	dir, err := resolveDDLHistoryDir(packagePath)
+	if err != nil {
+		return schemaDiffReport{}, err
+	}
+
+	state, err := loadDDLStateFile(dir)
+	if err != nil {
+		return schemaDiffReport{}, err
+	}
+
+	if state == nil {
+		return schemaDiffReport{}, fmt.Errorf("no DDL history found in %s: run tsq gen first", dir)
+	}
+
+	db, err := sql.Open(sqlDriver, dsn)
+	if err != nil {
+		return schemaDiffReport{}, fmt.Errorf("failed to open %s database: %w", sqlDriver, err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	live, err := inspectLiveDDLSnapshot(ctx, db, dialect, state.Snapshot)
+	if err != nil {
+		return schemaDiffReport{}, err
+	}
+
+	changes := diffDDLSnapshots(&live, state.Snapshot)
+	report := schemaDiffReport{
+		Package:     packagePath,
+		Dialect:     string(dialect.Name()),
+		Differences: collectSchemaDifferences(changes, ddlDialectSpec{dialect: dialect}),
+	}
+
+	if len(report.Differences) > 0 {
+		report.Drift = true
+		report.DDL = renderDDLIncrementalAggregateBody(ddlDialectSpec{dialect: dialect}, changes)
+	}
+
+	return report, nil
+}
+
+// inspectLiveDDLSnapshot 把数据库现状读成快照。与声明等价的列直接取声明值，
+// 这样 diffDDLSnapshots 只会看到真正的差异，而不是各方言类型名写法上的不同。
+func inspectLiveDDLSnapshot(
+	ctx context.Context,
+	db *sql.DB,
+	dialect tsqdialect.Dialect,
+	declared ddlSnapshot,
+) (ddlSnapshot, error) {
+	declaredTables := make(map[string]ddlSnapshotTable, len(declared.Tables))
+	for _, table := range declared.Tables {
+		declaredTables[table.Name] = table
+	}
+
+	names, err := dialect.ListTables(ctx, db)
+	if err != nil {
+		return ddlSnapshot{}, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	sort.Strings(names)
+
+	var live ddlSnapshot
+
+	for _, name := range names {
+		if isDDLBookkeepingTable(name) {
+			continue
+		}
+
+		columns, exists, err := dialect.InspectTableColumns(ctx, db, name)
+		if err != nil {
+			return ddlSnapshot{}, fmt.Errorf("failed to inspect table %s: %w", name, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		indexes, err := dialect.ListIndexes(ctx, db, name)
+		if err != nil {
+			return ddlSnapshot{}, fmt.Errorf("failed to list indexes of table %s: %w", name, err)
+		}
+
+		live.Tables = append(live.Tables, buildLiveDDLSnapshotTable(dialect, name, columns, indexes, declaredTables[name]))
+	}
+
+	return live, nil
+}
+
+func buildLiveDDLSnapshotTable(
+	dialect tsqdialect.Dialect,
+	name string,
+	columns []tsqdialect.DDLColumnSpec,
+	indexes []tsqdialect.NamedIndexDefinition,
+	declared ddlSnapshotTable,
+) ddlSnapshotTable {
+	declaredColumns := make(map[string]ddlSnapshotColumn, len(declared.Columns))
+	for _, column := range declared.Columns {
+		declaredColumns[column.Name] = column
+	}
+
+	table := ddlSnapshotTable{Name: name}
+
+	for _, column := range columns {
+		want, ok := declaredColumns[column.Name]
+		if ok && ddlcompare.ColumnSpecsEquivalent(dialect, column, ddlColumnSpecFromSnapshot(want)) {
+			table.Columns = append(table.Columns, want)
+			continue
+		}
+
+		table.Columns = append(table.Columns, ddlSnapshotColumn{
+			Name:          column.Name,
+			Kind:          ddlColumnKind(column.Type.Kind),
+			Bits:          column.Type.Bits,
+			Unsigned:      column.Type.Unsigned,
+			Nullable:      column.Type.Nullable,
+			Size:          column.Type.Size,
+			RawType:       column.Type.RawType,
+			PrimaryKey:    column.PrimaryKey,
+			AutoIncrement: column.AutoIncrement,
+			Default:       column.Default,
+		})
+	}
+
+	// 主键和约束背后的索引由建表语句维护，tsq.json 不记录它们。
+	for _, idx := range indexes {
+		if idx.PrimaryKey || idx.Constraint {
+			continue
+		}
+
+		table.Indexes = append(table.Indexes, ddlSnapshotIndex{
+			Name:   idx.Name,
+			Fields: append([]string(nil), idx.Fields...),
+			Unique: idx.Unique,
+		})
+	}
+
+	sort.Slice(table.Indexes, func(i, j int) bool {
+		return table.Indexes[i].Name < table.Indexes[j].Name
+	})
+
+	return table
+}
+
+func isDDLBookkeepingTable(name string) bool {
+	return strings.HasPrefix(name, "_tsq_") || strings.HasPrefix(name, "__tsq_rebuild_")
+}
+
+// collectSchemaDifferences 把 数据库 -> 快照 的变更集翻译成差异列表；
+// 同名索引先删后建表示定义不一致。
+func collectSchemaDifferences(changes ddlChangeSet, dialect ddlDialectSpec) []schemaDifference {
+	result := make([]schemaDifference, 0)
+
+	for _, tableName := range changes.Tables {
+		ops := changes.ByTable[tableName]
+
+		added := make(map[string]ddlSnapshotIndex)
+		dropped := make(map[string]ddlSnapshotIndex)
+
+		for _, op := range ops {
+			switch op.kind {
+			case ddlChangeAddIndex:
+				added[op.newIndex.Name] = *op.newIndex
+			case ddlChangeDropIndex:
+				dropped[op.oldIndex.Name] = *op.oldIndex
+			}
+		}
+
+		for _, op := range ops {
+			item := schemaDifference{Table: tableName}
+
+			switch op.kind {
+			case ddlChangeCreateTable:
+				item.Object, item.Kind = "table", schemaDifferenceMissing
+			case ddlChangeDropTable:
+				item.Object, item.Kind = "table", schemaDifferenceExtra
+			case ddlChangeAddColumn:
+				item.Object, item.Name, item.Kind = "column", op.newColumn.Name, schemaDifferenceMissing
+				item.Declared = renderDDLSnapshotColumnDefinition(*op.newColumn, dialect)
+			case ddlChangeDropColumn:
+				item.Object, item.Name, item.Kind = "column", op.oldColumn.Name, schemaDifferenceExtra
+				item.Live = renderDDLSnapshotColumnDefinition(*op.oldColumn, dialect)
+			case ddlChangeAlterColumn:
+				item.Object, item.Name, item.Kind = "column", op.newColumn.Name, schemaDifferenceMismatch
+				item.Live = renderDDLSnapshotColumnDefinition(*op.oldColumn, dialect)
+				item.Declared = renderDDLSnapshotColumnDefinition(*op.newColumn, dialect)
+			case ddlChangeAddIndex:
+				item.Object, item.Name, item.Kind = "index", op.newIndex.Name, schemaDifferenceMissing
+				item.Declared = formatSchemaDiffIndex(*op.newIndex)
+
+				if before, ok := dropped[op.newIndex.Name]; ok {
+					item.Kind = schemaDifferenceMismatch
+					item.Live = formatSchemaDiffIndex(before)
+				}
+			case ddlChangeDropIndex:
+				if _, ok := added[op.oldIndex.Name]; ok {
+					continue
+				}
+
+				item.Object, item.Name, item.Kind = "index", op.oldIndex.Name, schemaDifferenceExtra
+				item.Live = formatSchemaDiffIndex(*op.oldIndex)
+			default:
+				continue
+			}
+
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func formatSchemaDiffIndex(idx ddlSnapshotIndex) string {
+	fields := "(" + strings.Join(idx.Fields, ", ") + ")"
+	if idx.Unique {
+		return "UNIQUE " + fields
+	}
+
+	return fields
+}
+
+func writeSchemaDiffText(w io.Writer, report schemaDiffReport) error {
+	if !report.Drift {
+		_, err := fmt.Fprintf(w, "No schema drift: %s matches the %s database.\n", report.Package, report.Dialect)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Schema drift in %s against the %s database:\n", report.Package, report.Dialect); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, item := range report.Differences {
+		name := item.Table
+		if item.Name != "" {
+			name += "." + item.Name
+		}
+
+		var detail []string
+		if item.Live != "" {
+			detail = append(detail, "live: "+item.Live)
+		}
+
+		if item.Declared != "" {
+			detail = append(detail, "declared: "+item.Declared)
+		}
+
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", item.Kind, item.Object, name, strings.Join(detail, "; "))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\nDDL to reconcile the database:\n\n%s\n", report.DDL)
+
+	return err
+}
+
+func writeSchemaDiffJSON(w io.Writer, report schemaDiffReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema diff: %w", err)
+	}
+
+	_, err = w.Write(append(content, '\n'))
+
+	return err
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runDiff(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	reset := func() {
+		diffDriverFlag = ""
+		diffDSNFlag = ""
+		diffJSONFlag = false
+		DiffCmd.SetArgs(nil)
+	}
+
+	// 同一个测试里会先后带和不带 --json 执行，每次都从默认值开始解析。
+	reset()
+	t.Cleanup(reset)
+
+	out := new(bytes.Buffer)
+	DiffCmd.SetOut(out)
+	DiffCmd.SetErr(out)
+	DiffCmd.SetArgs(args)
+
+	err := DiffCmd.Execute()
+
+	return out.String(), err
+}
+
+func TestDiffCmdRequiresDriverAndDSN(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := runDiff(t, dir); err == nil || !strings.Contains(err.Error(), "requires --driver") {
+		t.Fatalf("expected missing driver error, got %v", err)
+	}
+
+	if _, err := runDiff(t, "--driver", "oracle", "--dsn", "x", dir); err == nil || !strings.Contains(err.Error(), "unsupported diff driver") {
+		t.Fatalf("expected unsupported driver error, got %v", err)
+	}
+
+	if _, err := runDiff(t, "--driver", "sqlite", "--dsn", filepath.Join(dir, "app.db"), dir); err == nil || !strings.Contains(err.Error(), "run tsq gen first") {
+		t.Fatalf("expected missing history error, got %v", err)
+	}
+}
+
+func TestDiffCmdReportsDriftAndReconcileDDL(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	applied := migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32})
+	writeMigrateTestHistory(t, dir, applied)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("migrate up error = %v", err)
+	}
+
+	out, err := runDiff(t, "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil || !strings.Contains(out, "No schema drift") {
+		t.Fatalf("expected a freshly migrated database to match, got %v:\n%s", err, out)
+	}
+
+	db := openMigrateTestDB(t, dsn)
+	for _, statement := range []string{
+		`CREATE TABLE legacy (id INTEGER)`,
+		`DROP INDEX idx_users_id`,
+		`CREATE UNIQUE INDEX idx_users_id ON users (id)`,
+	} {
+		if _, err := db.ExecContext(context.Background(), statement); err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+
+	writeMigrateTestHistory(t, dir, applied, migrateTestSnapshot(
+		ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32},
+		ddlSnapshotColumn{Name: "email", Kind: ddlColumnString, Size: 64, Nullable: true},
+	))
+
+	out, err = runDiff(t, "--driver", "sqlite", "--dsn", dsn, "--json", dir)
+	if err == nil || !strings.Contains(err.Error(), "schema drift detected: 3 difference(s)") {
+		t.Fatalf("expected a drift error, got %v", err)
+	}
+
+	// 单独执行子命令时 cobra 会在 JSON 之后打印错误和用法，只解码第一个值。
+	var report schemaDiffReport
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&report); err != nil {
+		t.Fatalf("diff output is not JSON: %v\n%s", err, out)
+	}
+
+	want := []schemaDifference{
+		{Object: "table", Table: "legacy", Kind: schemaDifferenceExtra},
+		{Object: "column", Table: "users", Name: "email", Kind: schemaDifferenceMissing, Declared: `"email" VARCHAR(64)`},
+		{Object: "index", Table: "users", Name: "idx_users_id", Kind: schemaDifferenceMismatch, Live: "UNIQUE (id)", Declared: "(id)"},
+	}
+
+	if !report.Drift || len(report.Differences) != len(want) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	for i := range want {
+		if report.Differences[i] != want[i] {
+			t.Fatalf("difference %d = %+v, want %+v", i, report.Differences[i], want[i])
+		}
+	}
+
+	for _, statement := range []string{
+		`DROP TABLE "legacy";`,
+		`ALTER TABLE "users" ADD COLUMN "email" VARCHAR(64);`,
+		`CREATE INDEX "idx_users_id" ON "users"("id");`,
+	} {
+		if !strings.Contains(report.DDL, statement) {
+			t.Fatalf("expected reconcile DDL to contain %q, got:\n%s", statement, report.DDL)
+		}
+	}
+
+	if _, err := db.ExecContext(context.Background(), report.DDL); err != nil {
+		t.Fatalf("apply reconcile DDL: %v\n%s", err, report.DDL)
+	}
+
+	if out, err = runDiff(t, "--driver", "sqlite", "--dsn", dsn, dir); err != nil || !strings.Contains(out, "No schema drift") {
+		t.Fatalf("expected the reconcile DDL to remove the drift, got %v:\n%s", err, out)
+	}
+}
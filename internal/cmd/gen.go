@@ -1,19 +1,22 @@
 package cmd
 
 import (
-	"bytes"
+	"cmp"
 	_ "embed"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
+	"golang.org/x/tools/go/packages"
 
 	"github.com/tmoeish/tsq/v4/internal/buildinfo"
 	"github.com/tmoeish/tsq/v4/internal/genmodel"
@@ -30,11 +33,32 @@ var (
 	//go:embed tsq_runtime.go.tmpl
 	defaultRuntimeTpl string
 
+	//go:embed tsq_http.go.tmpl
+	defaultHTTPTpl string
+
+	//go:embed tsq_repository.go.tmpl
+	defaultRepositoryTpl string
+
+	//go:embed tsq_factory.go.tmpl
+	defaultFactoryTpl string
+
 	tplFlag       string
 	resultTplFlag string
 	dryRunFlag    bool
 	checkFlag     bool
 	v             bool
+
+	allowDestructiveFlag bool
+	reportFlag           string
+	squashFlag           bool
+	rebaseFlag           bool
+	allowConflictsFlag   bool
+	migrationsFlag       string
+	watchFlag            bool
+	watchRecordFlag      bool
+	watchDebounceFlag    time.Duration
+	pluginFlag           []string
+	emitFlag             []string
 )
 
 const generatedFileHeaderPrefix = "// Code generated by tsq-"
@@ -60,6 +84,17 @@ func init() {
 	GenCmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "render in memory and print which files would change")
 	GenCmd.Flags().BoolVar(&checkFlag, "check", false, "render in memory and fail if generated files are out of date")
 	GenCmd.Flags().BoolVarP(&v, "verbose", "v", false, "print each generated file path")
+	GenCmd.Flags().BoolVar(&allowDestructiveFlag, "allow-destructive", false, "record schema changes that lose data, such as dropped columns or narrowed types")
+	GenCmd.Flags().StringVar(&reportFlag, "report", "", "write a JSON report of classified schema changes to this file (\"-\" for stdout)")
+	GenCmd.Flags().BoolVar(&squashFlag, "squash", false, "collapse the recorded DDL history into a new initial schema before recording changes")
+	GenCmd.Flags().BoolVar(&rebaseFlag, "rebase", false, "rebuild a DDL history that forked across merged branches before recording changes")
+	GenCmd.Flags().BoolVar(&allowConflictsFlag, "allow-conflicts", false, "with --rebase, record the merged definition of columns and indexes that both branches changed")
+	GenCmd.Flags().BoolVar(&watchFlag, "watch", false, "regenerate whenever the package's .go files or tsq.yaml change (imported packages are loaded once); schema changes are recorded only after typing \"record\"")
+	GenCmd.Flags().BoolVar(&watchRecordFlag, "watch-record", false, "with --watch, record schema changes on every regeneration without asking")
+	GenCmd.Flags().DurationVar(&watchDebounceFlag, "debounce", 300*time.Millisecond, "with --watch, how long files must stay unchanged before regenerating")
+	GenCmd.Flags().StringSliceVar(&emitFlag, "emit", nil, "also generate optional outputs: "+strings.Join(genOutputs, ", ")+" (comma-separated; overrides emit in tsq.yaml)")
+	GenCmd.Flags().StringArrayVar(&pluginFlag, "plugin", nil, "run an external generator as name=path; it receives the parsed models and schema as JSON on stdin and returns files to write (repeatable)")
+	GenCmd.Flags().StringVar(&migrationsFlag, "migrations", "", "write the DDL history as numbered golang-migrate or goose files under migrations/<dialect> instead of sqlite.sql / mysql.sql / postgres.sql")
 }
 
 type packageRuntimeTemplateData struct {
@@ -84,26 +119,117 @@ type runtimeColumnTemplateData struct {
 	PrimaryKey    bool
 	AutoIncrement bool
 	Default       string
+	RenamedFrom   string
 }
 
 // GenCmd generates tsq table, result, and DDL artifacts for a package.
 var GenCmd = &cobra.Command{
-	Use:   "gen <package-or-dir>",
-	Short: "Generate *.tsq.go files for one Go package",
-	Long: `Generate TSQ code for one Go package.
+	Use:   "gen <package-or-dir-or-pattern>...",
+	Short: "Generate *.tsq.go files for Go packages",
+	Long: `Generate TSQ code for one or more Go packages.
 
 Accepted inputs:
   - module import path: github.com/acme/project/internal/database
   - relative directory: ./internal/database
   - absolute directory: /path/to/project/internal/database
+  - package patterns or several paths: ./... or ./internal/... ./cmd/db
+    Patterns load every package once, skip packages without @TABLE or
+    @RESULT, and generate the rest concurrently. Output is printed per
+    package in import-path order; any failing package (including stale
+    files under --check) makes the command fail, listing every package
+    that failed. --report writes a JSON array with one report per package.
 
 Generated files:
   - <struct>.tsq.go for each @TABLE struct
   - <result>.result.tsq.go for each @RESULT struct
+  - http.tsq.go with net/http CRUD handlers (<Type>Handler) for tables
+    that declare @TABLE(http=true)
   - sqlite.sql / mysql.sql / postgres.sql beside generated Go files
     with the initial schema plus dated migration sections
   - tsq.json with the latest snapshot and migration history
 
+Schema change classes:
+  - safe: new tables, nullable or defaulted columns, renames
+  - blocking: index builds, column rewrites, NOT NULL on existing rows
+  - destructive: dropped tables or columns, narrowed or converted types
+  Destructive changes are refused unless --allow-destructive is passed,
+  the altered field carries the db tag option allow_destructive, a dropped
+  column is listed in @TABLE(allow_drop=["<column>"]), or the table
+  declares @TABLE(allow_destructive=true). An annotation that acknowledges
+  nothing prints a warning on every run; remove it once recorded.
+
+History squash:
+  --squash folds every recorded migration into a new initial schema
+  named "squash <time>" and keeps the folded step names in tsq.json,
+  so tsq migrate treats databases that already ran them as up to date.
+
+Migration files:
+  --migrations golang-migrate writes each migration step as
+  migrations/<dialect>/NNNN_<slug>.up.sql and .down.sql; --migrations goose
+  writes NNNN_<slug>.sql with -- +goose Up / Down sections. These files
+  replace sqlite.sql / mysql.sql / postgres.sql; tsq.json is still written.
+  Numbers count every step ever recorded, so a squashed baseline reuses the
+  number of the last step it folds in.
+
+Project configuration:
+  tsq.yaml in the package directory, or the nearest parent up to the module
+  root, sets per-package defaults: dialects to emit, ddl.dir / ddl.files /
+  ddl.migrations for the DDL output, ddl.string_size and ddl.types
+  (Go type -> column type), naming.columns / naming.indexes, and
+  templates.table / templates.result, emit and proto.package /
+  proto.go_package. Field tags and command-line flags
+  take precedence over the file.
+
+Optional outputs:
+  --emit (or emit: [...] in tsq.yaml) adds generated files per package:
+    openapi      openapi.tsq.json: OpenAPI 3.1 component schemas for every
+                 @TABLE and @RESULT type plus PageRequest and <Type>PageResponse
+    typescript   models.tsq.ts: an interface per @TABLE and @RESULT type,
+                 union types for enums, PageRequest and PageResponse<T>;
+                 64-bit integers are number, or string with json:",string"
+    proto        <package>.tsq.proto: a proto3 message per @TABLE and @RESULT
+                 type, wrapper types for nullable fields and Timestamp for
+                 times, plus proto.tsq.go with ToProto / <Type>FromProto;
+                 field numbers are kept in tsq.json and never reshuffle
+    repository   repository.tsq.go: a <Type>Repository interface per @TABLE
+                 covering its queries and CRUD methods, the database-backed
+                 New<Type>Repository and an in-memory New<Type>MemoryRepository
+                 for unit tests that enforces unique keys and versions
+    factory      factory.tsq.go: New<Type>Factory(traits...) per @TABLE whose
+                 Build fills every NOT NULL column with a valid default
+                 (sequence numbers for unique columns, strings within size:)
+                 and whose Create inserts the record with Insert
+  Outputs that are no longer selected are removed as stale.
+
+Plugins:
+  --plugin name=path runs an external generator after parsing. It receives
+  protocol version, package, the parsed structs and the current schema as
+  JSON on stdin and answers on stdout with
+  {"protocol": 1, "files": [{"path": "...", "content": "..."}]}. Paths are
+  relative to the package directory. Each file must start with
+  "// Code generated by tsq-" (or be JSON with "generated_by": "tsq-...");
+  plugin files are planned, checked and written like the built-in ones.
+
+Watch mode:
+  --watch generates once, then regenerates the package whenever its .go
+  files (not *.tsq.go) or tsq.yaml change and stay unchanged for --debounce.
+  Go files whose output did not change are left untouched. Schema changes
+  are summarized but not written to tsq.json or the SQL files until you type
+  "record" and press Enter; --watch-record records them on every run.
+  Parse errors are printed and watching continues. Imported packages are
+  loaded once; each regeneration re-parses and type-checks only the watched
+  package, and reloads everything only when it imports a new package.
+  Restart --watch to pick up changes to the packages it imports.
+
+Merged branches:
+  Each history record stores the hashes of the snapshots before and after
+  it. After merging branches that both ran tsq gen, run tsq gen --rebase on
+  the conflicted tsq.json: the branch that recorded first keeps its records,
+  later branches that touched other tables are appended unchanged, and the
+  rest are re-derived from the merged snapshot into one new record. Columns
+  or indexes both branches changed differently are refused unless
+  --allow-conflicts is passed.
+
 Overwrite behavior:
   - creates missing generated files
   - atomically replaces existing generated files that start with
@@ -118,149 +244,363 @@ Overwrite behavior:
   - generator validation errors point to the offending struct or field`,
 	Example: strings.Join([]string{
 		"  tsq gen ./examples/academy",
+		"  tsq gen --check ./...",
 		"  tsq gen --dry-run ./examples/academy",
 		"  tsq gen --check github.com/tmoeish/tsq/v4/examples/academy",
+		"  tsq gen --squash ./internal/database",
+		"  tsq gen --rebase ./internal/database",
+		"  tsq gen --migrations golang-migrate ./internal/database",
+		"  tsq gen --watch ./internal/database",
+		"  tsq gen --emit openapi,typescript ./internal/database",
+		"  tsq gen --plugin repo=./bin/tsq-repo ./internal/database",
 		"  tsq gen github.com/tmoeish/tsq/v4/examples/academy",
 		"  tsq gen /abs/path/to/project/internal/database --tpl ./cmd/tsq.go.tmpl",
 	}, "\n"),
-	Args: exactOnePackageArg,
+	Args: genPackageArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if dryRunFlag && checkFlag {
 			return errors.New("--dry-run and --check cannot be used together")
 		}
 
-		tableTpl, err := resolveTemplateText(tplFlag, defaultTableTpl, "template")
-		if err != nil {
+		if err := validateDDLMigrationsFormat(migrationsFlag); err != nil {
 			return err
 		}
 
-		resultTpl, err := resolveTemplateText(resultTplFlag, defaultResultTpl, "Result template")
-		if err != nil {
+		if _, err := parseGenPluginFlags(pluginFlag); err != nil {
 			return err
 		}
 
-		runtimeTplText, err := resolveTemplateText("", defaultRuntimeTpl, "runtime template")
-		if err != nil {
-			return err
+		if err := validateGenOutputs(emitFlag); err != nil {
+			return fmt.Errorf("--emit: %w", err)
 		}
 
-		pPath := args[0]
-		errWriter := cmd.ErrOrStderr()
+		if watchFlag {
+			if err := validateGenWatchFlags(args); err != nil {
+				return err
+			}
 
-		list, dir, err := parser.Parse(pPath)
-		if err != nil {
-			return err
+			return runGenWatch(cmd, args[0])
 		}
 
-		for i := range list {
-			list[i].SetTSQVersion(stableVersion(buildinfo.Version()))
+		if len(args) > 1 || isGenPackagePattern(args[0]) {
+			return runGenPackages(cmd, args)
 		}
 
-		tpl, err := template.New("tsq.go.tmpl").Funcs(funcMap()).Parse(tableTpl)
+		return runGenPackage(genPackageRun{
+			path:   args[0],
+			stdout: cmd.OutOrStdout(),
+			stderr: cmd.ErrOrStderr(),
+			report: func(report ddlChangeReport) error {
+				return writeDDLChangeReport(cmd.OutOrStdout(), reportFlag, report)
+			},
+		})
+	},
+}
+
+// genPackageRun 是一次为单个包执行的 tsq gen。
+type genPackageRun struct {
+	path   string
+	stdout io.Writer
+	stderr io.Writer
+	report func(ddlChangeReport) error
+
+	// watch 表示 --watch 的重复生成：跳过内容没变的生成文件，不去动它们的修改时间，并且有 schema
+	// 变更时总是打印摘要。
+	watch bool
+	// holdDDLHistory 非 nil 时有待记录的 schema 变更只打印摘要并交给它，不写 DDL 文件和 tsq.json。
+	holdDDLHistory func(ddlArtifacts)
+
+	// dir、set 和 types 来自 tsq gen ./... 的那一次 packages.Load；单包运行时为空，按包自行加载。
+	dir   string
+	set   *parser.PackageSet
+	types map[string]*packages.Package
+}
+
+func runGenPackage(run genPackageRun) error {
+	pPath := run.path
+	errWriter := run.stderr
+
+	packageDir := run.dir
+	if packageDir == "" {
+		dir, err := resolveDDLStateDir(pPath)
 		if err != nil {
-			return fmt.Errorf("%s: %w", "failed to parse table template", err)
+			return err
 		}
 
-		resultTplParsed, err := template.New("tsq_result.go.tmpl").Funcs(funcMap()).Parse(resultTpl)
+		packageDir = dir
+	}
+
+	config, err := loadGenConfig(packageDir)
+	if err != nil {
+		return err
+	}
+
+	migrations := cmp.Or(migrationsFlag, config.DDL.Migrations)
+
+	tableTpl, err := resolveTemplateText(cmp.Or(tplFlag, config.resolvePath(config.Templates.Table)), defaultTableTpl, "template")
+	if err != nil {
+		return err
+	}
+
+	resultTpl, err := resolveTemplateText(cmp.Or(resultTplFlag, config.resolvePath(config.Templates.Result)), defaultResultTpl, "Result template")
+	if err != nil {
+		return err
+	}
+
+	runtimeTplText, err := resolveTemplateText("", defaultRuntimeTpl, "runtime template")
+	if err != nil {
+		return err
+	}
+
+	var (
+		list []*genmodel.StructInfo
+		dir  string
+	)
+
+	if run.set != nil {
+		list, dir, err = run.set.Parse(pPath, config.naming())
+	} else {
+		list, dir, err = parser.ParseWithNaming(pPath, config.naming())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	resolver := &ddlTypeResolver{packages: run.types, options: config.typeOptions()}
+	if run.types == nil {
+		resolver, err = newDDLTypeResolver(pPath, dir, config.typeOptions())
 		if err != nil {
-			return fmt.Errorf("%s: %w", "failed to parse Result template", err)
+			return err
 		}
+	}
 
-		runtimeTplParsed, err := template.New("tsq_runtime.go.tmpl").Funcs(funcMap()).Parse(runtimeTplText)
+	for i := range list {
+		list[i].SetTSQVersion(stableVersion(buildinfo.Version()))
+	}
+
+	tpl, err := template.New("tsq.go.tmpl").Funcs(funcMap()).Parse(tableTpl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", "failed to parse table template", err)
+	}
+
+	resultTplParsed, err := template.New("tsq_result.go.tmpl").Funcs(funcMap()).Parse(resultTpl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", "failed to parse Result template", err)
+	}
+
+	runtimeTplParsed, err := template.New("tsq_runtime.go.tmpl").Funcs(funcMap()).Parse(runtimeTplText)
+	if err != nil {
+		return fmt.Errorf("%s: %w", "failed to parse runtime template", err)
+	}
+
+	httpTplParsed, err := template.New("tsq_http.go.tmpl").Funcs(funcMap()).Parse(defaultHTTPTpl)
+	if err != nil {
+		return fmt.Errorf("%s: %w", "failed to parse http template", err)
+	}
+
+	models, err := buildGenerationModels(list, dir, tpl, resultTplParsed, runtimeTplParsed, httpTplParsed, resolver)
+	if err != nil {
+		return err
+	}
+
+	stats := summarizeGenerationModels(models)
+	if v {
+		_, _ = fmt.Fprintf(errWriter, "parsed %d table(s), %d result(s)\n", stats.Tables, stats.Results)
+	}
+
+	outputs := config.Emit
+	if len(emitFlag) > 0 {
+		outputs = emitFlag
+	}
+
+	var protoMessages map[string][]string
+
+	if slices.Contains(outputs, genOutputProto) {
+		messages, err := describeProtoMessages(list, resolver)
 		if err != nil {
-			return fmt.Errorf("%s: %w", "failed to parse runtime template", err)
+			return fmt.Errorf("%s output: %w", genOutputProto, err)
 		}
 
-		models, err := buildGenerationModels(list, dir, tpl, resultTplParsed, runtimeTplParsed)
+		protoMessages = protoMessageFieldNames(messages)
+	}
+
+	ddlArtifacts, err := buildDDLArtifacts(list, dir, resolver, ddlHistoryOptions{
+		squash:         squashFlag,
+		rebase:         rebaseFlag,
+		allowConflicts: allowConflictsFlag,
+		migrations:     migrations,
+		config:         config,
+		protoMessages:  protoMessages,
+	})
+	if err != nil {
+		return err
+	}
+
+	outputModels, err := buildGenOutputModels(outputs, genOutputInput{
+		list:        list,
+		dir:         dir,
+		resolver:    resolver,
+		version:     stableVersion(buildinfo.Version()),
+		config:      config,
+		protoFields: ddlArtifacts.proto,
+	})
+	if err != nil {
+		return err
+	}
+
+	models = append(models, outputModels...)
+
+	plugins, err := parseGenPluginFlags(pluginFlag)
+	if err != nil {
+		return err
+	}
+
+	if len(plugins) > 0 {
+		pluginModels, err := buildGenPluginModels(plugins, genPluginRequest{
+			Protocol:   genPluginProtocolVersion,
+			TSQVersion: stableVersion(buildinfo.Version()),
+			Package:    genPluginPackageFor(list, dir),
+			Structs:    list,
+			Schema:     ddlArtifacts.snapshot,
+		}, models, ddlArtifacts, errWriter)
 		if err != nil {
 			return err
 		}
 
-		stats := summarizeGenerationModels(models)
-		if v {
-			_, _ = fmt.Fprintf(errWriter, "parsed %d table(s), %d result(s)\n", stats.Tables, stats.Results)
-		}
+		models = append(models, pluginModels...)
+	}
 
-		plan, err := buildGenerationPlan(models, dir)
-		if err != nil {
+	plan, err := buildGenerationPlan(models, dir)
+	if err != nil {
+		return err
+	}
+
+	ddlPlan, err := buildDDLPlan(ddlArtifacts.models, ddlArtifacts.dir)
+	if err != nil {
+		return err
+	}
+
+	combinedPlan := appendCombinedGenerationPlans(plan, ddlPlan)
+
+	if reportFlag != "" {
+		if err := run.report(buildDDLChangeReport(pPath, ddlArtifacts.assessments, allowDestructiveFlag)); err != nil {
 			return err
 		}
+	}
 
-		ddlArtifacts, err := buildDDLArtifacts(pPath, list, dir)
-		if err != nil {
+	for _, warning := range ddlArtifacts.warnings {
+		if _, err := fmt.Fprintf(errWriter, "warning: %s\n", warning); err != nil {
 			return err
 		}
+	}
 
-		ddlPlan, err := buildDDLPlan(ddlArtifacts.models, dir)
-		if err != nil {
-			return err
+	if dryRunFlag {
+		printGenerationPlan(run.stdout, combinedPlan)
+
+		if v || ddlArtifacts.hasChange {
+			printDDLChangeSummary(errWriter, ddlArtifacts)
+		}
+
+		if v {
+			printGenerationSummary(errWriter, combinedPlan)
 		}
 
-		combinedPlan := appendCombinedGenerationPlans(plan, ddlPlan)
+		return nil
+	}
 
-		if dryRunFlag {
-			printGenerationPlan(cmd.OutOrStdout(), combinedPlan)
+	if checkFlag {
+		if v || ddlArtifacts.hasChange {
+			printDDLChangeSummary(errWriter, ddlArtifacts)
+		}
 
-			if v {
-				printDDLChangeSummary(errWriter, ddlArtifacts)
-				printGenerationSummary(errWriter, combinedPlan)
-			}
+		if v {
+			printGenerationSummary(errWriter, combinedPlan)
+		}
 
-			return nil
+		if err := ensureGenerationPlanUpToDate(combinedPlan); err != nil {
+			return err
 		}
 
-		if checkFlag {
-			if v {
-				printDDLChangeSummary(errWriter, ddlArtifacts)
-				printGenerationSummary(errWriter, combinedPlan)
-			}
+		return nil
+	}
 
-			if err := ensureGenerationPlanUpToDate(combinedPlan); err != nil {
-				return err
+	unchanged := make(map[string]bool)
+	if run.watch {
+		for _, entry := range plan {
+			if entry.Status == generationPlanUnchanged {
+				unchanged[entry.Filename] = true
 			}
-
-			return nil
 		}
+	}
 
+	// 暂不记录 schema 变更（包括首次生成）时只更新 Go 代码，tsq.json 和 SQL 文件等用户确认后再写；
+	// 破坏性变更的拒绝也推迟到那时。
+	if run.holdDDLHistory != nil && (ddlArtifacts.firstRun || ddlArtifacts.hasChange) {
 		for _, model := range models {
+			if unchanged[model.Filename] {
+				continue
+			}
+
 			if err := renderGenerationModel(model); err != nil {
 				return err
 			}
 		}
 
-		for _, model := range ddlArtifacts.models {
-			if v {
-				if _, err := fmt.Fprintf(errWriter, "ddl %s\n", model.Filename); err != nil {
-					return err
-				}
-			}
+		printDDLChangeSummary(errWriter, ddlArtifacts)
+		run.holdDDLHistory(ddlArtifacts)
 
-			if err := writeDDLFile(model.Filename, model.Source); err != nil {
-				return fmt.Errorf("failed to write DDL file: %s"+": %w", model.Filename, err)
-			}
-		}
+		return nil
+	}
 
-		for _, entry := range ddlPlan {
-			if entry.Status != generationPlanStale {
-				continue
-			}
+	if refused := unacknowledgedDestructiveDDLChanges(ddlArtifacts.assessments); len(refused) > 0 && !allowDestructiveFlag {
+		return newDDLDestructiveChangeError(refused)
+	}
 
-			if err := os.Remove(entry.Filename); err != nil && !os.IsNotExist(err) {
-				return fmt.Errorf("failed to remove stale DDL file: %s"+": %w", entry.Filename, err)
-			}
+	for _, model := range models {
+		if unchanged[model.Filename] {
+			continue
 		}
 
-		if err := printDDLGuidance(errWriter, ddlArtifacts); err != nil {
+		if err := renderGenerationModel(model); err != nil {
 			return err
 		}
+	}
 
+	for _, model := range ddlArtifacts.models {
 		if v {
-			printDDLChangeSummary(errWriter, ddlArtifacts)
-			printGenerationSummary(errWriter, combinedPlan)
+			if _, err := fmt.Fprintf(errWriter, "ddl %s\n", model.Filename); err != nil {
+				return err
+			}
 		}
 
-		return nil
-	},
+		if err := writeDDLFile(model.Filename, model.Source); err != nil {
+			return fmt.Errorf("failed to write DDL file: %s"+": %w", model.Filename, err)
+		}
+	}
+
+	for _, entry := range ddlPlan {
+		if entry.Status != generationPlanStale {
+			continue
+		}
+
+		if err := os.Remove(entry.Filename); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale DDL file: %s"+": %w", entry.Filename, err)
+		}
+	}
+
+	if err := printDDLGuidance(errWriter, ddlArtifacts); err != nil {
+		return err
+	}
+
+	if v {
+		printDDLChangeSummary(errWriter, ddlArtifacts)
+		printGenerationSummary(errWriter, combinedPlan)
+	} else if run.watch && ddlArtifacts.hasChange {
+		printDDLChangeSummary(errWriter, ddlArtifacts)
+	}
+
+	return nil
 }
 
 func appendCombinedGenerationPlans(plans ...[]generationPlanEntry) []generationPlanEntry {
@@ -282,22 +622,66 @@ func appendCombinedGenerationPlans(plans ...[]generationPlanEntry) []generationP
 }
 
 func printDDLGuidance(w io.Writer, artifacts ddlArtifacts) error {
+	if squash := artifacts.squash; squash != nil {
+		if _, err := fmt.Fprintf(w, "%s: squashed %d migration step(s) into %q; databases already at %s adopt it on the next tsq migrate up\n",
+			maybeANSI(w, ansiBoldCyan, "DDL"),
+			len(squash.Replaces),
+			squash.Sequence,
+			squash.Through,
+		); err != nil {
+			return err
+		}
+	}
+
+	if rebase := artifacts.rebase; rebase != nil {
+		if _, err := fmt.Fprintf(w, "%s: rebased the merged DDL history: kept %s; re-derived %s into the new record\n",
+			maybeANSI(w, ansiBoldCyan, "DDL"),
+			formatDDLSequenceList(rebase.kept),
+			formatDDLSequenceList(rebase.replaced),
+		); err != nil {
+			return err
+		}
+
+		for _, item := range rebase.conflicts {
+			if _, err := fmt.Fprintf(w, "  %s %s\n", maybeANSI(w, ansiYellow, "conflict:"), item); err != nil {
+				return err
+			}
+		}
+	}
+
 	if !artifacts.firstRun && !artifacts.hasChange {
 		return nil
 	}
 
+	config := artifacts.config
+	if config == nil {
+		config = &genConfig{}
+	}
+
 	label := maybeANSI(w, ansiBoldCyan, "DDL")
-	filename := "<dialect>.sql"
 	action := "execute the full schema file matching your database dialect"
 
 	if !artifacts.firstRun {
 		action = "execute the latest dated schema section in the file matching your database dialect"
 	}
 
+	if artifacts.migrations != "" {
+		action = "run " + artifacts.migrations + " against the migrations directory matching your database dialect"
+	}
+
 	paths := make([]string, 0, len(ddlDialects))
 	for _, dialect := range ddlDialects {
 		name := ddlDialectName(dialect)
-		paths = append(paths, fmt.Sprintf("%s=%s", name, strings.Replace(filename, "<dialect>", name, 1)))
+		if !config.emitsDialect(name) {
+			continue
+		}
+
+		filename := config.ddlFilename(name)
+		if artifacts.migrations != "" {
+			filename = filepath.Join(ddlMigrationsDirname, name)
+		}
+
+		paths = append(paths, fmt.Sprintf("%s=%s", name, filepath.Join(config.DDL.Dir, filename)))
 	}
 
 	if _, err := fmt.Fprintf(w, "%s: %s: %s\n",
@@ -311,6 +695,14 @@ func printDDLGuidance(w io.Writer, artifacts ddlArtifacts) error {
 	return nil
 }
 
+func formatDDLSequenceList(sequences []string) string {
+	if len(sequences) == 0 {
+		return "none"
+	}
+
+	return strings.Join(sequences, ", ")
+}
+
 func printDDLChangeSummary(w io.Writer, artifacts ddlArtifacts) {
 	if !artifacts.hasChange {
 		if _, err := fmt.Fprintf(w, "%s: no schema changes\n", maybeANSI(w, ansiBoldCyan, "ddl")); err != nil {
@@ -324,13 +716,22 @@ func printDDLChangeSummary(w io.Writer, artifacts ddlArtifacts) {
 		return
 	}
 
+	classes := make(map[string]ddlChangeAssessment, len(artifacts.assessments))
+	for _, item := range artifacts.assessments {
+		classes[item.Table+"\x00"+item.Change] = item
+	}
+
+	label := func(table, line string) string {
+		return colorizeDDLAction(w, line) + formatDDLChangeClass(w, classes[table+"\x00"+line])
+	}
+
 	for _, table := range artifacts.recordTables {
 		if _, err := fmt.Fprintf(w, "  <%s>:\n", maybeANSI(w, ansiBold, table.Table)); err != nil {
 			return
 		}
 
 		if len(table.Columns) == 1 && len(table.Indexes) == 0 && isDDLTableLine(table.Columns[0]) {
-			if _, err := fmt.Fprintf(w, "    %s\n", colorizeDDLAction(w, table.Columns[0])); err != nil {
+			if _, err := fmt.Fprintf(w, "    %s\n", label(table.Table, table.Columns[0])); err != nil {
 				return
 			}
 
@@ -343,7 +744,7 @@ func printDDLChangeSummary(w io.Writer, artifacts ddlArtifacts) {
 			}
 
 			for _, line := range table.Columns {
-				if _, err := fmt.Fprintf(w, "      %s\n", colorizeDDLAction(w, line)); err != nil {
+				if _, err := fmt.Fprintf(w, "      %s\n", label(table.Table, line)); err != nil {
 					return
 				}
 			}
@@ -355,12 +756,42 @@ func printDDLChangeSummary(w io.Writer, artifacts ddlArtifacts) {
 			}
 
 			for _, line := range table.Indexes {
-				if _, err := fmt.Fprintf(w, "      %s\n", colorizeDDLAction(w, line)); err != nil {
+				if _, err := fmt.Fprintf(w, "      %s\n", label(table.Table, line)); err != nil {
 					return
 				}
 			}
 		}
 	}
+
+	if len(artifacts.irreversible) == 0 {
+		return
+	}
+
+	if _, err := fmt.Fprintf(w, "  %s:\n", maybeANSI(w, ansiYellow, "irreversible")); err != nil {
+		return
+	}
+
+	for _, line := range artifacts.irreversible {
+		if _, err := fmt.Fprintf(w, "    %s\n", line); err != nil {
+			return
+		}
+	}
+}
+
+// formatDDLChangeClass 给非 safe 的变更加上 " [blocking]" / " [destructive]" 后缀。
+func formatDDLChangeClass(w io.Writer, item ddlChangeAssessment) string {
+	switch item.Class {
+	case ddlChangeBlocking:
+		return " " + maybeANSI(w, ansiYellow, "[blocking]")
+	case ddlChangeDestructive:
+		if item.Acknowledged {
+			return " " + maybeANSI(w, ansiRed, "[destructive, acknowledged]")
+		}
+
+		return " " + maybeANSI(w, ansiRed, "[destructive]")
+	default:
+		return ""
+	}
 }
 
 func isDDLTableLine(line string) bool {
@@ -378,7 +809,7 @@ func colorizeDDLAction(w io.Writer, line string) string {
 	}
 
 	switch action {
-	case "create", "add":
+	case "create", "add", "rename":
 		return ansiGreen + action + ansiReset + " " + rest
 	case "alter":
 		return ansiYellow + action + ansiReset + " " + rest
@@ -410,10 +841,6 @@ func isTerminalWriter(w io.Writer) bool {
 	return term.IsTerminal(int(fd.Fd()))
 }
 
-func exactOnePackageArg(_ *cobra.Command, args []string) error {
-	return exactOnePackageArgFor("gen")(nil, args)
-}
-
 func exactOnePackageArgFor(command string) cobra.PositionalArgs {
 	return func(_ *cobra.Command, args []string) error {
 		if len(args) == 1 {
@@ -660,6 +1087,10 @@ func validateGeneratedSymbolCollisions(list []*genmodel.StructInfo) error {
 			baseSymbols = append(baseSymbols, "Result"+typeName)
 		}
 
+		if data.HTTP && !data.IsResult {
+			baseSymbols = append(baseSymbols, typeName+"Handler", "New"+typeName+"Handler")
+		}
+
 		if data.DeletedAtField != "" {
 			baseSymbols = append(baseSymbols,
 				"QueryActive"+typeName,
@@ -853,6 +1284,11 @@ func writeGeneratedFile(filename string, src []byte) error {
 	dir := filepath.Dir(filename)
 	pattern := "." + filepath.Base(filename) + ".tmp-*"
 
+	// 插件可以把文件写进包目录下的子目录。
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
 	tmpFile, err := os.CreateTemp(dir, pattern)
 	if err != nil {
 		return err
@@ -896,7 +1332,7 @@ func ensureWritableGeneratedFile(filename string) error {
 		return err
 	}
 
-	if len(existing) == 0 || bytes.HasPrefix(existing, []byte(generatedFileHeaderPrefix)) {
+	if len(existing) == 0 || isGeneratedArtifact(existing) {
 		return nil
 	}
 
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// factoryFilename 是 --emit factory 写在包目录里的文件。
+const factoryFilename = "factory.tsq.go"
+
+type factoryTemplateData struct {
+	Package    genmodel.PackageInfo
+	Tables     []factoryTableTemplateData
+	TSQVersion string
+	// Imports 是默认值里类型转换用到的外部包（导入路径 → 包名）。
+	Imports map[string]string
+	// NeedsTime、NeedsSequence、NeedsString 和 NeedsInt 决定模板导入 time、生成序号、factoryString
+	// 和 factoryInt。
+	NeedsTime     bool
+	NeedsSequence bool
+	NeedsString   bool
+	NeedsInt      bool
+}
+
+type factoryTableTemplateData struct {
+	*genmodel.StructInfo
+	// Values 是 Build 按字段顺序写入的默认值，没列出的字段保持零值。
+	Values []factoryValueTemplateData
+	// UsesSequence 表示默认值用到了本次 Build 的序号 n，UsesString、UsesInt 表示用到了
+	// factoryString、factoryInt。
+	UsesSequence bool
+	UsesString   bool
+	UsesInt      bool
+	// SequenceVar 是这张表的序号计数器，每张表各用一个，别的表 Build 不会耗掉它的取值。
+	SequenceVar string
+}
+
+type factoryValueTemplateData struct {
+	Field string
+	Expr  string
+}
+
+// buildFactoryModels 为每个 @TABLE 生成测试用的 XxxFactory，全部写进一个 factory.tsq.go。
+func buildFactoryModels(input genOutputInput) ([]generationModel, error) {
+	tables := make([]*genmodel.StructInfo, 0, len(input.list))
+	for _, s := range input.list {
+		if s == nil || s.TableMeta == nil || s.IsResult || len(s.Fields) == 0 {
+			continue
+		}
+
+		tables = append(tables, s)
+	}
+
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	slices.SortFunc(tables, func(a, b *genmodel.StructInfo) int {
+		return strings.Compare(a.TypeInfo.TypeName, b.TypeInfo.TypeName)
+	})
+
+	if err := validateFactorySymbolCollisions(input.list, tables); err != nil {
+		return nil, err
+	}
+
+	data := factoryTemplateData{
+		Package:    tables[0].TypeInfo.Package,
+		TSQVersion: input.version,
+		Imports:    make(map[string]string),
+	}
+
+	for _, table := range tables {
+		tableData, err := describeFactoryTable(table, input.resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.TypeInfo.TypeName, err)
+		}
+
+		fields := make([]string, 0, len(tableData.Values))
+		for _, value := range tableData.Values {
+			fields = append(fields, value.Field)
+		}
+
+		usesTime, err := collectFieldImports(table, fields, data.Imports)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.TypeInfo.TypeName, err)
+		}
+
+		data.NeedsTime = data.NeedsTime || usesTime
+		data.NeedsSequence = data.NeedsSequence || tableData.UsesSequence
+		data.NeedsString = data.NeedsString || tableData.UsesString
+		data.NeedsInt = data.NeedsInt || tableData.UsesInt
+		data.Tables = append(data.Tables, tableData)
+	}
+
+	tpl, err := template.New("tsq_factory.go.tmpl").Funcs(funcMap()).Parse(defaultFactoryTpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse factory template: %w", err)
+	}
+
+	return []generationModel{{
+		Data:       data,
+		Template:   tpl,
+		Filename:   filepath.Join(input.dir, factoryFilename),
+		ErrorLabel: "factory template rendering failed",
+	}}, nil
+}
+
+// describeFactoryTable 按 DDL 的列类型分类给出每个 NOT NULL 列的默认值。自增主键和托管字段交给
+// Insert，可空列保持 NULL；主键和唯一索引里的列用序号 n 保证不重复，字符串不超过 size。
+func describeFactoryTable(table *genmodel.StructInfo, resolver *ddlTypeResolver) (factoryTableTemplateData, error) {
+	data := factoryTableTemplateData{StructInfo: table, SequenceVar: factorySequenceVar(table.TypeInfo.TypeName)}
+
+	managed := []string{table.CreatedAtField, table.UpdatedAtField, table.DeletedAtField, table.VersionField}
+	if table.AI {
+		managed = append(managed, table.PK)
+	}
+
+	unique := []string{table.PK}
+	for _, ux := range table.UxList {
+		unique = append(unique, ux.Fields...)
+	}
+
+	for _, field := range table.Fields {
+		if slices.Contains(managed, field.Name) {
+			continue
+		}
+
+		varObj, tag, err := resolver.lookupField(table, field)
+		if err != nil {
+			return factoryTableTemplateData{}, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		// 分类不了的类型（ddl.types 映射的 decimal 之类）和可空列都保持零值。
+		desc, err := classifyDDLColumnType(varObj.Type(), tag, resolver.options)
+		if err != nil || desc.nullable {
+			continue
+		}
+
+		typ := fieldType(field)
+		isUnique := slices.Contains(unique, field.Name)
+		isJSON := strings.Contains(strings.ToUpper(desc.rawType), "JSON")
+
+		_, _, enumNames := jsonEnumValues(varObj.Type(), varObj.Pkg())
+		expr, usesN := "", false
+
+		switch desc.kind {
+		case ddlColumnString:
+			switch {
+			case isJSON:
+				expr = factoryConvert(typ, "string", `"null"`)
+			case len(enumNames) > 0 && !isUnique:
+				expr = enumNames[0]
+			default:
+				expr = factoryConvert(typ, "string", fmt.Sprintf("factoryString(%s, n, %d)", strconv.Quote(field.Column), desc.size))
+				usesN = true
+				data.UsesString = true
+			}
+		case ddlColumnInt, ddlColumnFloat:
+			switch {
+			case isUnique:
+				expr = "n"
+				if limit := factorySequenceLimit(desc); limit > 0 {
+					expr = fmt.Sprintf("factoryInt(%s, n, %d)", strconv.Quote(field.Column), limit)
+					data.UsesInt = true
+				}
+
+				expr = factoryConvert(typ, "int64", expr)
+				usesN = true
+			case len(enumNames) > 0:
+				expr = enumNames[0]
+			}
+		case ddlColumnBytes:
+			if isJSON {
+				expr = fmt.Sprintf(`%s("null")`, typ)
+			} else {
+				expr = typ + "{}"
+			}
+		case ddlColumnTime:
+			expr = generatedTimeRef("Now()")
+			if isUnique {
+				expr = fmt.Sprintf("%s.Add(%s(n) * %s)", expr, generatedTimeRef("Duration"), generatedTimeRef("Second"))
+				usesN = true
+			}
+		}
+
+		if expr == "" {
+			continue
+		}
+
+		data.UsesSequence = data.UsesSequence || usesN
+		data.Values = append(data.Values, factoryValueTemplateData{Field: field.Name, Expr: expr})
+	}
+
+	return data, nil
+}
+
+// factorySequenceLimit 返回窄数值列能精确放下的最大序号，超过后转换会回绕或丢精度；
+// 64 位整数和 float64 放得下任何实际用到的序号，返回 0 表示不用检查。
+func factorySequenceLimit(desc ddlColumnDescriptor) int64 {
+	switch {
+	case desc.kind == ddlColumnFloat && desc.bits == 32:
+		return 1 << 24
+	case desc.kind != ddlColumnInt || desc.bits == 0 || desc.bits >= 64:
+		return 0
+	case desc.unsigned:
+		return 1<<desc.bits - 1
+	default:
+		return 1<<(desc.bits-1) - 1
+	}
+}
+
+// factorySequenceVar 是表的序号计数器变量名。
+func factorySequenceVar(typeName string) string {
+	return lowerInitial(typeName) + "FactorySequence"
+}
+
+// factoryConvert 在字段类型不是 from 时把 expr 转成字段类型。
+func factoryConvert(typ, from, expr string) string {
+	if typ == from {
+		return expr
+	}
+
+	return fmt.Sprintf("%s(%s)", typ, expr)
+}
+
+// validateFactorySymbolCollisions 检查生成的工厂类型名不和包里的表、结果类型撞名。
+func validateFactorySymbolCollisions(list, tables []*genmodel.StructInfo) error {
+	seen := make(map[string]string)
+	for _, s := range list {
+		if s != nil {
+			seen[s.TypeInfo.TypeName] = s.TypeInfo.TypeName
+		}
+	}
+
+	for _, table := range tables {
+		typeName := table.TypeInfo.TypeName
+		for _, symbol := range []string{typeName + "Factory", "New" + typeName + "Factory", factorySequenceVar(typeName)} {
+			if owner, ok := seen[symbol]; ok {
+				return fmt.Errorf("generated symbol %s collides between %s and %s", symbol, owner, typeName)
+			}
+
+			seen[symbol] = typeName
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsFactoriesThatSatisfyConstraints(t *testing.T) {
+	t.Cleanup(func() {
+		emitFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	model := `package gentest
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Level 是会员等级。
+type Level string
+
+const (
+	LevelBasic Level = "basic"
+	LevelPro   Level = "pro"
+)
+
+// @TABLE(
+//	name="members",
+//	pk="ID",
+//	version,
+//	created_at,
+//	ux=[{fields=["Code"]}, {fields=["Email"]}],
+// )
+type Member struct {
+	ID        int64           ` + "`db:\"id\" json:\"id\"`" + `
+	Code      string          ` + "`db:\"code,size:6\" json:\"code\"`" + `
+	Email     string          ` + "`db:\"email\" json:\"email\"`" + `
+	Level     Level           ` + "`db:\"level\" json:\"level\"`" + `
+	Score     int             ` + "`db:\"score\" json:\"score\"`" + `
+	Profile   json.RawMessage ` + "`db:\"profile,type:JSON\" json:\"profile\"`" + `
+	JoinedAt  time.Time       ` + "`db:\"joined_at\" json:\"joined_at\"`" + `
+	Nickname  *string         ` + "`db:\"nickname\" json:\"nickname\"`" + `
+	Version   int64           ` + "`db:\"version\" json:\"version\"`" + `
+	CreatedAt time.Time       ` + "`db:\"created_at\" json:\"created_at\"`" + `
+}
+
+// @TABLE(name="badges", pk="Rank,false")
+type Badge struct {
+	Rank  int64  ` + "`db:\"rank\" json:\"rank\"`" + `
+	Title string ` + "`db:\"title\" json:\"title\"`" + `
+}
+
+// @TABLE(name="slots", pk="ID", ux=[{fields=["Slot"]}])
+type Slot struct {
+	ID   int64 ` + "`db:\"id\" json:\"id\"`" + `
+	Slot uint8 ` + "`db:\"slot\" json:\"slot\"`" + `
+}
+`
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+
+	// SQLite 不检查 VARCHAR 长度，这里用 CHECK 约束代替，让超长的默认值直接插入失败。
+	writeTestFile(t, filepath.Join(dir, "factory_test.go"), `package gentest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmoeish/tsq/v4"
+	_ "modernc.org/sqlite"
+)
+
+const schema = `+"`"+`
+CREATE TABLE members (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code VARCHAR(6) NOT NULL CHECK (length(code) <= 6),
+	email VARCHAR(255) NOT NULL,
+	level VARCHAR(255) NOT NULL CHECK (level IN ('basic', 'pro')),
+	score INTEGER NOT NULL,
+	profile JSON NOT NULL CHECK (json_valid(profile)),
+	joined_at DATETIME NOT NULL,
+	nickname VARCHAR(255),
+	version INTEGER NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX ux_members_code ON members (code);
+CREATE UNIQUE INDEX ux_members_email ON members (email);
+CREATE TABLE badges (rank INTEGER PRIMARY KEY, title VARCHAR(255) NOT NULL);
+`+"`"+`
+
+func TestFactories(t *testing.T) {
+	ctx := context.Background()
+
+	dsn := filepath.Join(t.TempDir(), "factory.db")
+	db, err := tsq.NewRuntime("sqlite", dsn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB().Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.DB().Close()
+
+	rt, err := tsq.NewRuntime("sqlite", dsn, TSQTables())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = rt.DB().Close() })
+
+	members := NewMemberFactory()
+	for range 12 {
+		if _, err := members.Create(ctx, rt); err != nil {
+			t.Fatalf("Create() = %v", err)
+		}
+	}
+
+	pro := members.With(func(m *Member) { m.Level = LevelPro })
+	nick := "al"
+	got, err := pro.Create(ctx, rt, func(m *Member) { m.Nickname = &nick })
+	if err != nil || got.ID == 0 {
+		t.Fatalf("Create() = %+v, %v", got, err)
+	}
+
+	saved, err := QueryMemberByID.GetOrErr(ctx, rt, got.ID)
+	if err != nil || saved.Level != LevelPro || saved.Nickname == nil || *saved.Nickname != "al" || len(saved.Code) > 6 {
+		t.Fatalf("expected traits to override defaults, got %+v, %v", saved, err)
+	}
+
+	if built := members.Build(); built.Level != LevelBasic || built.Nickname != nil || built.JoinedAt.IsZero() {
+		t.Fatalf("unexpected defaults: %+v", built)
+	}
+
+	// 每张表各用一个序号：前面建过的 member 不会耗掉 badge 的取值。
+	badges := NewBadgeFactory()
+	for i := range 3 {
+		badge, err := badges.Create(ctx, rt)
+		if err != nil || badge.Rank != int64(i+1) {
+			t.Fatalf("Create() = %+v, %v", badge, err)
+		}
+	}
+
+	slots := NewSlotFactory()
+	for range 255 {
+		slots.Build()
+	}
+	if !panics(func() { slots.Build() }) {
+		t.Fatal("expected the 256th uint8 slot to panic instead of wrapping around")
+	}
+
+	if got := factoryString("code", 35, 6); got != "code-z" {
+		t.Fatalf("factoryString(35) = %q", got)
+	}
+	if got := factoryString("code", 36, 6); got != "10" {
+		t.Fatalf("expected the prefix to be dropped once it no longer fits, got %q", got)
+	}
+	if !panics(func() { factoryString("code", 36*36, 2) }) {
+		t.Fatal("expected factoryString to panic when the sequence outgrows the size")
+	}
+}
+
+func panics(f func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	f()
+	return false
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--emit", "factory", "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, factoryFilename))
+	if err != nil {
+		t.Fatalf("expected %s: %v", factoryFilename, err)
+	}
+
+	for _, want := range []string{
+		"func NewMemberFactory(traits ...func(*Member)) *MemberFactory {",
+		`record.Code = factoryString("code", n, 6)`,
+		"record.Level = LevelBasic",
+		`record.Profile = json.RawMessage("null")`,
+		"record.Rank = n",
+		`record.Slot = uint8(factoryInt("slot", n, 255))`,
+		"var badgeFactorySequence atomic.Int64",
+		"func (f *BadgeFactory) Create(ctx context.Context, db tsq.SQLExecutor, traits ...func(*Badge)) (*Badge, error) {",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("expected %s to contain:\n%s\ngot:\n%s", factoryFilename, want, source)
+		}
+	}
+
+	for _, unwanted := range []string{"record.ID =", "record.Version =", "record.CreatedAt =", "record.Nickname =", "record.Score ="} {
+		if strings.Contains(string(source), unwanted) {
+			t.Fatalf("expected %s not to set managed, nullable or plain numeric fields, found %q", factoryFilename, unwanted)
+		}
+	}
+
+	if output, err := exec.Command("go", "test", "./...").CombinedOutput(); err != nil {
+		t.Fatalf("generated factories do not pass: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// httpHandlersFilename 是 @TABLE(http=true) 的表共用的 net/http 处理器文件。
+const httpHandlersFilename = "http.tsq.go"
+
+type httpHandlersTemplateData struct {
+	Package    genmodel.PackageInfo
+	Tables     []httpTableTemplateData
+	TSQVersion string
+	// ParsesIntegers 表示有整数主键，需要导入 strconv。
+	ParsesIntegers bool
+}
+
+type httpTableTemplateData struct {
+	*genmodel.StructInfo
+	// PKType 是把解析出的 {id} 转成主键时用的类型表达式，不需要转换时为空。
+	PKType string
+	// PKParse 是 "int"、"uint"，字符串主键为空。
+	PKParse string
+	PKBits  int
+}
+
+// buildPackageHTTPModel 为声明了 http=true 的表生成一个共用的 http.tsq.go；没有这样的表时不生成，
+// 之前生成过的文件会按过期文件清理。
+func buildPackageHTTPModel(
+	list []*genmodel.StructInfo,
+	dir string,
+	httpTpl *template.Template,
+	resolver *ddlTypeResolver,
+) (*generationModel, error) {
+	if httpTpl == nil {
+		return nil, nil
+	}
+
+	tables := make([]*genmodel.StructInfo, 0, len(list))
+	for _, s := range list {
+		if s == nil || s.TableMeta == nil || s.IsResult || !s.HTTP || len(s.Fields) == 0 {
+			continue
+		}
+
+		tables = append(tables, s)
+	}
+
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].TypeInfo.TypeName < tables[j].TypeInfo.TypeName
+	})
+
+	data := httpHandlersTemplateData{
+		Package:    tables[0].TypeInfo.Package,
+		TSQVersion: tables[0].TSQVersion,
+	}
+
+	for _, table := range tables {
+		tableData, err := describeHTTPPrimaryKey(table, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%s: http=true: %w", table.TypeInfo.TypeName, err)
+		}
+
+		data.ParsesIntegers = data.ParsesIntegers || tableData.PKParse != ""
+		data.Tables = append(data.Tables, tableData)
+	}
+
+	return &generationModel{
+		Data:       data,
+		Template:   httpTpl,
+		Filename:   filepath.Join(dir, httpHandlersFilename),
+		ErrorLabel: "http template rendering failed",
+	}, nil
+}
+
+// describeHTTPPrimaryKey 决定怎样把路径里的 {id} 解析成主键：只支持整数和字符串主键，
+// 且主键类型要么是内建类型，要么声明在表所在的包里，生成的文件不必再导入别的包。
+func describeHTTPPrimaryKey(table *genmodel.StructInfo, resolver *ddlTypeResolver) (httpTableTemplateData, error) {
+	data := httpTableTemplateData{StructInfo: table}
+
+	field, ok := table.FieldMap[table.PK]
+	if !ok {
+		return data, fmt.Errorf("primary key field %s not found", table.PK)
+	}
+
+	varObj, _, err := resolver.lookupField(table, field)
+	if err != nil {
+		return data, err
+	}
+
+	pkType := varObj.Type()
+	if named, ok := pkType.(*types.Named); ok {
+		if pkg := named.Obj().Pkg(); pkg != nil && pkg.Path() != table.TypeInfo.Package.Path {
+			return data, fmt.Errorf("primary key type %s must be a built-in type or declared in package %s", pkType, table.TypeInfo.Package.Path)
+		}
+	}
+
+	basic, ok := pkType.Underlying().(*types.Basic)
+	if !ok {
+		return data, fmt.Errorf("primary key type %s must be an integer or a string", pkType)
+	}
+
+	// 前面已经排除了别的包里的类型，这里不带包名。
+	data.PKType = types.TypeString(pkType, func(*types.Package) string { return "" })
+	if data.PKType == "int64" || data.PKType == "uint64" || data.PKType == "string" {
+		data.PKType = ""
+	}
+
+	switch info := basic.Info(); {
+	case info&types.IsString != 0:
+	case info&types.IsUnsigned != 0:
+		data.PKParse = "uint"
+	case info&types.IsInteger != 0:
+		data.PKParse = "int"
+	default:
+		return data, fmt.Errorf("primary key type %s must be an integer or a string", pkType)
+	}
+
+	switch basic.Kind() {
+	case types.Int8, types.Uint8:
+		data.PKBits = 8
+	case types.Int16, types.Uint16:
+		data.PKBits = 16
+	case types.Int32, types.Uint32:
+		data.PKBits = 32
+	case types.Int64, types.Uint64:
+		data.PKBits = 64
+	}
+
+	return data, nil
+}
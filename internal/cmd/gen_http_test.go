@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsHTTPHandlersForOptedInTables(t *testing.T) {
+	t.Cleanup(func() {
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	model := `package gentest
+
+import "time"
+
+type Code string
+
+type Level uint16
+
+// @TABLE(name="countries", pk="Code,false", http=true)
+type Country struct {
+	Code Code   ` + "`db:\"code,size:2\" json:\"code\"`" + `
+	Name string ` + "`db:\"name\" json:\"name\"`" + `
+}
+
+// @TABLE(name="levels", pk="ID,false", http=true)
+type LevelRow struct {
+	ID   Level  ` + "`db:\"id\" json:\"id\"`" + `
+	Name string ` + "`db:\"name\" json:\"name\"`" + `
+}
+
+// @TABLE(name="notes")
+type Note struct {
+	ID      int64         ` + "`db:\"id\" json:\"id\"`" + `
+	Timeout time.Duration ` + "`db:\"timeout\" json:\"timeout\"`" + `
+}
+`
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, httpHandlersFilename))
+	if err != nil {
+		t.Fatalf("expected %s: %v", httpHandlersFilename, err)
+	}
+
+	for _, want := range []string{
+		"func NewCountryHandler(db tsq.SQLExecutor) *CountryHandler {",
+		"return QueryCountryByCode.GetOrErr(r.Context(), h.db, Code(id))",
+		"id, err := strconv.ParseUint(r.PathValue(\"id\"), 10, 16)",
+		"return QueryLevelRowByID.GetOrErr(r.Context(), h.db, Level(id))",
+		"dec.DisallowUnknownFields()",
+		"case tsq.IsOptimisticLockError(err):\n\t\t\tstatus, message = http.StatusConflict,",
+		"case tsq.IsDuplicateKeyError(err):\n\t\t\tstatus, message = http.StatusConflict,",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("expected %s to contain:\n%s\ngot:\n%s", httpHandlersFilename, want, source)
+		}
+	}
+
+	if strings.Contains(string(source), "NoteHandler") {
+		t.Fatalf("expected tables without http=true to get no handler, got:\n%s", source)
+	}
+
+	if output, err := exec.Command("go", "build", "./...").CombinedOutput(); err != nil {
+		t.Fatalf("generated handlers do not compile: %v\n%s", err, output)
+	}
+
+	// 主键类型来自别的包时，生成的文件还得导入它，这里直接报错。
+	model = strings.Replace(model, `// @TABLE(name="notes")`, `// @TABLE(name="notes", pk="Timeout,false", http=true)`, 1)
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+
+	err = GenCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "Note: http=true: primary key type time.Duration must be a built-in type or declared in package example.com/gentest") {
+		t.Fatalf("expected an unsupported primary key error, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// jsonField 是结构体 JSON 编码后的一个属性，OpenAPI 和 TypeScript 输出共用。
+type jsonField struct {
+	name  string
+	shape jsonShape
+	// optional 对应 json 标签的 omitempty：零值时属性不出现。
+	optional bool
+}
+
+// jsonShape 描述一个字段编码成 JSON 后的样子。kind 为空表示分类不了（例如用 ddl.types 映射的
+// decimal），不限定形状。
+type jsonShape struct {
+	kind     ddlColumnKind
+	bits     int
+	unsigned bool
+	nullable bool
+	// maxLength 只取 db 标签显式写的 size:，默认长度不算 JSON 契约。
+	maxLength int
+	// quoted 对应 json 标签的 ,string：数字和布尔值编码成字符串。
+	quoted bool
+
+	// enumType、enum 和 enumNames 来自包内具名类型的同类型常量，按声明顺序排列。
+	enumType  string
+	enum      []any
+	enumNames []string
+
+	// sqlNull 是 database/sql Null* 类型的值字段名（String、Int64 …），这些类型没有自定义
+	// JSON 编码，输出的是 {"String": ..., "Valid": ...} 这样的对象；value 是值字段的形状。
+	sqlNull string
+	value   *jsonShape
+}
+
+// describeJSONFields 按 encoding/json 的规则列出结构体的属性：属性名取 json 标签，跳过 json:"-"。
+func describeJSONFields(s *genmodel.StructInfo, resolver *ddlTypeResolver) ([]jsonField, error) {
+	fields := make([]jsonField, 0, len(s.Fields))
+
+	for _, field := range s.Fields {
+		if field.JsonTag == "-" {
+			continue
+		}
+
+		varObj, tag, err := resolver.lookupField(s, field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		_, jsonOptions, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+		options := strings.Split(jsonOptions, ",")
+
+		shape := describeJSONShape(varObj.Type(), tag, varObj.Pkg(), resolver.options)
+		shape.quoted = slices.Contains(options, "string") && (shape.kind == ddlColumnInt || shape.kind == ddlColumnFloat || shape.kind == ddlColumnBool)
+
+		fields = append(fields, jsonField{
+			name:     field.JsonTag,
+			shape:    shape,
+			optional: slices.Contains(options, "omitempty"),
+		})
+	}
+
+	return fields, nil
+}
+
+// describeJSONShape 复用 DDL 的列类型分类得到类型、位宽和可空性。
+func describeJSONShape(t types.Type, tag string, pkg *types.Package, options ddlTypeOptions) jsonShape {
+	if name, ok := jsonSQLNullFields[jsonNamedTypeName(t)]; ok {
+		value := describeJSONShape(types.Unalias(t).Underlying().(*types.Struct).Field(0).Type(), "", nil, options)
+
+		return jsonShape{sqlNull: name, value: &value}
+	}
+
+	desc, err := classifyDDLColumnType(t, tag, options)
+	if err != nil {
+		return jsonShape{}
+	}
+
+	shape := jsonShape{
+		kind:     desc.kind,
+		bits:     desc.bits,
+		unsigned: desc.unsigned,
+		nullable: desc.nullable,
+	}
+
+	switch desc.kind {
+	case ddlColumnInt:
+		// DDL 把 int 存成 32 位列，JSON 里它仍是 Go 的 64 位 int。
+		if jsonIsPlatformInt(t) {
+			shape.bits = 64
+		}
+	case ddlColumnString:
+		shape.maxLength = parseDDLTagOptions(reflect.StructTag(tag).Get("db")).size
+	}
+
+	shape.enumType, shape.enum, shape.enumNames = jsonEnumValues(t, pkg)
+
+	return shape
+}
+
+var jsonSQLNullFields = map[string]string{
+	importPathDatabaseSQL + ".NullBool":    "Bool",
+	importPathDatabaseSQL + ".NullFloat64": "Float64",
+	importPathDatabaseSQL + ".NullInt64":   "Int64",
+	importPathDatabaseSQL + ".NullString":  "String",
+	importPathDatabaseSQL + ".NullTime":    "Time",
+}
+
+func jsonIsPlatformInt(t types.Type) bool {
+	if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+
+	return ok && (basic.Kind() == types.Int || basic.Kind() == types.Uint)
+}
+
+func jsonNamedTypeName(t types.Type) string {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+// jsonEnumValues 返回 pkg 里声明的具名类型 t 的全部同类型常量。自定义了 JSON / 文本编码的类型
+// 编码后不是常量值，不算枚举。
+func jsonEnumValues(t types.Type, pkg *types.Package) (string, []any, []string) {
+	if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || pkg == nil || named.Obj().Pkg() != pkg {
+		return "", nil, nil
+	}
+
+	if _, ok := named.Underlying().(*types.Basic); !ok {
+		return "", nil, nil
+	}
+
+	methods := types.NewMethodSet(types.NewPointer(named))
+	for _, name := range []string{"MarshalJSON", "MarshalText"} {
+		if methods.Lookup(pkg, name) != nil {
+			return "", nil, nil
+		}
+	}
+
+	var consts []*types.Const
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(c.Type(), named) {
+			consts = append(consts, c)
+		}
+	}
+
+	if len(consts) == 0 {
+		return "", nil, nil
+	}
+
+	slices.SortFunc(consts, func(a, b *types.Const) int { return int(a.Pos() - b.Pos()) })
+
+	values := make([]any, 0, len(consts))
+	names := make([]string, 0, len(consts))
+
+	for _, c := range consts {
+		var value any
+
+		switch c.Val().Kind() {
+		case constant.Int:
+			if v, exact := constant.Int64Val(c.Val()); exact {
+				value = v
+			} else if v, exact := constant.Uint64Val(c.Val()); exact {
+				value = v
+			}
+		case constant.String:
+			value = constant.StringVal(c.Val())
+		case constant.Float:
+			value, _ = constant.Float64Val(c.Val())
+		case constant.Bool:
+			value = constant.BoolVal(c.Val())
+		}
+
+		if value == nil {
+			return "", nil, nil
+		}
+
+		values = append(values, value)
+		names = append(names, c.Name())
+	}
+
+	return named.Obj().Name(), values, names
+}
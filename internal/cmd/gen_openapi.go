@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// openAPIFilename 是 --emit openapi 写在包目录里的文件。
+const openAPIFilename = "openapi.tsq.json"
+
+// openAPIDocument 是只有 components 的 OpenAPI 3.1 文档，供手写的 paths 用 $ref 引用。
+// x-generated-by 让下次生成认出这是自己的文件。
+type openAPIDocument struct {
+	OpenAPI     string            `json:"openapi"`
+	Info        openAPIInfo       `json:"info"`
+	GeneratedBy string            `json:"x-generated-by"`
+	Components  openAPIComponents `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPISchema struct {
+	Ref             string                    `json:"$ref,omitempty"`
+	Type            any                       `json:"type,omitempty"`
+	Format          string                    `json:"format,omitempty"`
+	ContentEncoding string                    `json:"contentEncoding,omitempty"`
+	MaxLength       int                       `json:"maxLength,omitempty"`
+	Minimum         *int                      `json:"minimum,omitempty"`
+	Enum            []any                     `json:"enum,omitempty"`
+	EnumVarNames    []string                  `json:"x-enum-varnames,omitempty"`
+	Items           *openAPISchema            `json:"items,omitempty"`
+	Properties      map[string]*openAPISchema `json:"properties,omitempty"`
+	Required        []string                  `json:"required,omitempty"`
+	AllOf           []*openAPISchema          `json:"allOf,omitempty"`
+}
+
+func buildOpenAPIModels(input genOutputInput) ([]generationModel, error) {
+	schemas := map[string]*openAPISchema{
+		"PageRequest": openAPIPageRequestSchema(),
+	}
+
+	for _, s := range input.list {
+		if s == nil || s.TableMeta == nil || len(s.Fields) == 0 {
+			continue
+		}
+
+		name := s.TypeInfo.TypeName
+
+		schema, err := buildOpenAPIStructSchema(s, input.resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		schemas[name] = schema
+		schemas[name+"PageResponse"] = openAPIPageResponseSchema(name)
+	}
+
+	title := cmp.Or(genPluginPackageFor(input.list, input.dir).Path, filepath.Base(input.dir))
+
+	source, err := json.MarshalIndent(openAPIDocument{
+		OpenAPI:     "3.1.0",
+		Info:        openAPIInfo{Title: title, Version: input.version},
+		GeneratedBy: "tsq-" + input.version,
+		Components:  openAPIComponents{Schemas: schemas},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []generationModel{{
+		Filename: filepath.Join(input.dir, openAPIFilename),
+		Source:   append(source, '\n'),
+	}}, nil
+}
+
+// buildOpenAPIStructSchema 按 JSON 编码后的样子描述结构体：没有 omitempty 的字段都出现在输出里，
+// 因此列为 required；可为 NULL 的字段的类型带上 "null"。
+func buildOpenAPIStructSchema(s *genmodel.StructInfo, resolver *ddlTypeResolver) (*openAPISchema, error) {
+	fields, err := describeJSONFields(s, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &openAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*openAPISchema, len(fields)),
+	}
+
+	for _, field := range fields {
+		schema.Properties[field.name] = buildOpenAPIFieldSchema(field.shape)
+
+		if !field.optional {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+
+	return schema, nil
+}
+
+func buildOpenAPIFieldSchema(shape jsonShape) *openAPISchema {
+	if shape.sqlNull != "" {
+		return &openAPISchema{
+			Type: "object",
+			Properties: map[string]*openAPISchema{
+				shape.sqlNull: buildOpenAPIFieldSchema(*shape.value),
+				"Valid":       {Type: "boolean"},
+			},
+			Required: []string{shape.sqlNull, "Valid"},
+		}
+	}
+
+	schema := new(openAPISchema)
+
+	switch shape.kind {
+	case ddlColumnBool:
+		schema.Type = "boolean"
+	case ddlColumnInt:
+		schema.Type = "integer"
+		schema.Format = "int32"
+
+		if shape.bits == 64 {
+			schema.Format = "int64"
+		}
+
+		if shape.unsigned {
+			schema.Minimum = new(0)
+		}
+	case ddlColumnFloat:
+		schema.Type = "number"
+		schema.Format = "double"
+
+		if shape.bits == 32 {
+			schema.Format = "float"
+		}
+	case ddlColumnString:
+		schema.Type = "string"
+		schema.MaxLength = shape.maxLength
+	case ddlColumnBytes:
+		schema.Type = "string"
+		schema.ContentEncoding = "base64"
+	case ddlColumnTime:
+		schema.Type = "string"
+		schema.Format = "date-time"
+	default:
+		return schema
+	}
+
+	if shape.quoted {
+		schema.Type = "string"
+		schema.Minimum = nil
+	} else {
+		schema.Enum, schema.EnumVarNames = shape.enum, shape.enumNames
+	}
+
+	if shape.nullable {
+		schema.Type = []string{schema.Type.(string), "null"}
+
+		if schema.Enum != nil {
+			schema.Enum = append(slices.Clip(schema.Enum), nil)
+		}
+	}
+
+	return schema
+}
+
+// openAPIPageRequestSchema 描述 tsq.PageRequest 的 JSON。
+func openAPIPageRequestSchema() *openAPISchema {
+	return &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"size":     {Type: "integer"},
+			"page":     {Type: "integer"},
+			"order_by": {Type: "string"},
+			"order":    {Type: "string"},
+			"keyword":  {Type: "string"},
+		},
+		Required: []string{"size", "page", "order_by", "order", "keyword"},
+	}
+}
+
+// openAPIPageResponseSchema 描述 tsq.PageResponse[T]：内嵌的 PageRequest 字段平铺在同一层，
+// data 在没有数据时可能是 null。
+func openAPIPageResponseSchema(name string) *openAPISchema {
+	return &openAPISchema{
+		AllOf: []*openAPISchema{
+			{Ref: "#/components/schemas/PageRequest"},
+			{
+				Type: "object",
+				Properties: map[string]*openAPISchema{
+					"total":      {Type: "integer", Format: "int64"},
+					"total_page": {Type: "integer", Format: "int64"},
+					"data": {
+						Type:  []string{"array", "null"},
+						Items: &openAPISchema{Ref: "#/components/schemas/" + name},
+					},
+				},
+				Required: []string{"total", "total_page", "data"},
+			},
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsOpenAPIComponents(t *testing.T) {
+	t.Cleanup(func() {
+		checkFlag = false
+		emitFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusActive  Status = "active"
+	StatusBlocked Status = "blocked"
+)
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID       int64          `+"`db:\"id\" json:\"id\"`"+`
+	Name     string         `+"`db:\"name,size:64\" json:\"name\"`"+`
+	Nickname *string        `+"`db:\"nickname\" json:\"nickname,omitempty\"`"+`
+	Status   Status         `+"`db:\"status\" json:\"status\"`"+`
+	Visits   uint32         `+"`db:\"visits\" json:\"visits\"`"+`
+	Avatar   []byte         `+"`db:\"avatar\" json:\"avatar\"`"+`
+	Bio      sql.NullString `+"`db:\"bio\" json:\"bio\"`"+`
+	Secret   string         `+"`db:\"secret\" json:\"-\"`"+`
+	JoinedAt time.Time      `+"`db:\"joined_at\" json:\"joined_at\"`"+`
+}
+
+// @RESULT(name="UserName")
+type UserName struct {
+	UserID int64  `+"`json:\"user_id\" tsq:\"User.ID\"`"+`
+	Name   string `+"`json:\"name\" tsq:\"User.Name\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, genConfigFilename), "emit: [openapi]\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--emit", "swagger", "."})
+
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), `--emit: unknown output "swagger"`) {
+		t.Fatalf("expected an unknown output error, got %v", err)
+	}
+
+	emitFlag = nil
+	GenCmd.SetArgs([]string{"."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, openAPIFilename))
+	if err != nil {
+		t.Fatalf("expected tsq.yaml emit to write %s: %v", openAPIFilename, err)
+	}
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decode %s: %v", openAPIFilename, err)
+	}
+
+	schemas := doc.Components.Schemas
+	for _, name := range []string{"User", "UserPageResponse", "UserName", "UserNamePageResponse", "PageRequest"} {
+		if schemas[name] == nil {
+			t.Fatalf("expected a %s schema, got %v", name, slices.Sorted(maps.Keys(schemas)))
+		}
+	}
+
+	property := func(schema, name string) string {
+		t.Helper()
+
+		properties, _ := schemas[schema]["properties"].(map[string]any)
+
+		bs, err := json.Marshal(properties[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(bs)
+	}
+
+	for _, tc := range []struct{ name, want string }{
+		{"id", `{"format":"int64","type":"integer"}`},
+		{"name", `{"maxLength":64,"type":"string"}`},
+		{"nickname", `{"type":["string","null"]}`},
+		{"status", `{"enum":["active","blocked"],"type":"string","x-enum-varnames":["StatusActive","StatusBlocked"]}`},
+		{"visits", `{"format":"int32","minimum":0,"type":"integer"}`},
+		{"avatar", `{"contentEncoding":"base64","type":"string"}`},
+		{"bio", `{"properties":{"String":{"type":"string"},"Valid":{"type":"boolean"}},"required":["String","Valid"],"type":"object"}`},
+		{"secret", `null`},
+		{"joined_at", `{"format":"date-time","type":"string"}`},
+	} {
+		if got := property("User", tc.name); got != tc.want {
+			t.Fatalf("User.%s schema = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	required, _ := json.Marshal(schemas["User"]["required"])
+	if string(required) != `["avatar","bio","id","joined_at","name","status","visits"]` {
+		t.Fatalf("unexpected required list %s", required)
+	}
+
+	if got := property("UserName", "user_id"); got != `{"format":"int64","type":"integer"}` {
+		t.Fatalf("UserName.user_id schema = %s", got)
+	}
+
+	page, _ := json.Marshal(schemas["UserPageResponse"])
+	for _, want := range []string{`{"$ref":"#/components/schemas/PageRequest"}`, `"items":{"$ref":"#/components/schemas/User"}`, `"total_page"`} {
+		if !strings.Contains(string(page), want) {
+			t.Fatalf("expected the page response schema to contain %s, got %s", want, page)
+		}
+	}
+
+	// 不再生成时它按过期文件处理，--check 会指出来。
+	writeTestFile(t, filepath.Join(dir, genConfigFilename), "emit: []\n")
+	GenCmd.SetArgs([]string{"--check", "."})
+
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "STALE "+filepath.Join(dir, openAPIFilename)) {
+		t.Fatalf("expected %s to be stale, got %v", openAPIFilename, err)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// 可选输出的名字，用于 --emit 和 tsq.yaml 的 emit。
+const (
+	genOutputOpenAPI    = "openapi"
+	genOutputTypeScript = "typescript"
+	genOutputProto      = "proto"
+	genOutputRepository = "repository"
+	genOutputFactory    = "factory"
+)
+
+// genOutputs 按生成顺序列出全部可选输出。
+var genOutputs = []string{
+	genOutputOpenAPI,
+	genOutputTypeScript,
+	genOutputProto,
+	genOutputRepository,
+	genOutputFactory,
+}
+
+// genOutputBuilders 为每个可选输出生成文件。
+var genOutputBuilders = map[string]func(genOutputInput) ([]generationModel, error){
+	genOutputOpenAPI:    buildOpenAPIModels,
+	genOutputTypeScript: buildTypeScriptModels,
+	genOutputProto:      buildProtoModels,
+	genOutputRepository: buildRepositoryModels,
+	genOutputFactory:    buildFactoryModels,
+}
+
+// validateGenOutputs 检查 --emit / emit 里的名字。
+func validateGenOutputs(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(genOutputs, name) {
+			return fmt.Errorf("unknown output %q: use %s", name, strings.Join(genOutputs, ", "))
+		}
+	}
+
+	return nil
+}
+
+// genOutputInput 是可选输出共用的输入：解析出的结构体、包目录、类型解析器和包适用的 tsq.yaml。
+type genOutputInput struct {
+	list     []*genmodel.StructInfo
+	dir      string
+	resolver *ddlTypeResolver
+	version  string
+	config   *genConfig
+	// protoFields 是 tsq.json 里的 protobuf 字段编号。
+	protoFields map[string]ddlStateProtoMessage
+}
+
+// buildGenOutputModels 为选中的可选输出生成文件，和模板生成的文件一起进入生成计划。
+func buildGenOutputModels(outputs []string, input genOutputInput) ([]generationModel, error) {
+	var models []generationModel
+
+	for _, name := range genOutputs {
+		if !slices.Contains(outputs, name) {
+			continue
+		}
+
+		outputModels, err := genOutputBuilders[name](input)
+		if err != nil {
+			return nil, fmt.Errorf("%s output: %w", name, err)
+		}
+
+		models = append(models, outputModels...)
+	}
+
+	return models, nil
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/tmoeish/tsq/v4/internal/parser"
+)
+
+// genPackageArgs 要求至少一个包路径或模式。
+func genPackageArgs(_ *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return nil
+	}
+
+	return fmt.Errorf("tsq gen expects at least one package path or pattern, got %d", len(args))
+}
+
+// isGenPackagePattern 报告参数是否是 ./... 这样的包模式。
+func isGenPackagePattern(arg string) bool {
+	return strings.Contains(arg, "...")
+}
+
+// genPackageResult 收集一个包的输出，全部包生成完后按导入路径顺序打印，并发运行时输出也不会交错。
+type genPackageResult struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	report *ddlChangeReport
+	err    error
+}
+
+// runGenPackages 为多个包或包模式执行 tsq gen：一次 packages.Load 加载全部包，跳过没有
+// @TABLE / @RESULT 的包，其余并发生成，任一包失败（包括 --check 发现过期）都以非零状态退出。
+func runGenPackages(cmd *cobra.Command, patterns []string) error {
+	roots, err := loadGenPackages(patterns)
+	if err != nil {
+		return err
+	}
+
+	set := parser.NewPackageSet(roots)
+
+	targets := set.Annotated()
+	if len(targets) == 0 {
+		return fmt.Errorf("no package matching %s has @TABLE or @RESULT annotations", strings.Join(patterns, " "))
+	}
+
+	types := make(map[string]*packages.Package)
+	for _, pkg := range flattenLoadedPackages(roots) {
+		if pkg.PkgPath != "" {
+			types[pkg.PkgPath] = pkg
+		}
+	}
+
+	results := make([]genPackageResult, len(targets))
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+
+	var wg sync.WaitGroup
+
+	for i, pkg := range targets {
+		result := &results[i]
+
+		wg.Go(func() {
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			result.err = runGenPackage(genPackageRun{
+				path:   pkg.PkgPath,
+				stdout: &result.stdout,
+				stderr: &result.stderr,
+				report: func(report ddlChangeReport) error {
+					result.report = &report
+					return nil
+				},
+				dir:   loadedPackageDir(pkg),
+				set:   set,
+				types: types,
+			})
+		})
+	}
+
+	wg.Wait()
+
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+
+	var (
+		errs    []error
+		reports []ddlChangeReport
+	)
+
+	for i, pkg := range targets {
+		result := &results[i]
+
+		if _, err := result.stdout.WriteTo(stdout); err != nil {
+			return err
+		}
+
+		if _, err := result.stderr.WriteTo(stderr); err != nil {
+			return err
+		}
+
+		if result.report != nil {
+			reports = append(reports, *result.report)
+		}
+
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pkg.PkgPath, result.err))
+		}
+	}
+
+	if reportFlag != "" {
+		if err := writeDDLChangeReport(stdout, reportFlag, reports); err != nil {
+			return err
+		}
+	}
+
+	if v {
+		_, _ = fmt.Fprintf(stderr, "generated %d package(s), %d failed\n", len(targets), len(errs))
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadGenPackages 用一次 packages.Load 加载模式匹配的包及其依赖，模式按当前目录解析，与 go build 相同。
+func loadGenPackages(patterns []string) ([]*packages.Package, error) {
+	cfg, _, err := resolveDDLLoadRequest("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := buildDDLPatternOverlay(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overlay) > 0 {
+		cfg.Overlay = overlay
+	}
+
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages %s: %w", strings.Join(patterns, " "), err)
+	}
+
+	return roots, nil
+}
+
+// buildDDLPatternOverlay 把目录模式覆盖到的 *.tsq.go 换成只有 package 子句的空文件，与单包加载
+// （resolveDDLLoadRequest）一样不让过期的生成代码影响类型检查。导入路径模式找不到目录，按原样加载。
+func buildDDLPatternOverlay(patterns []string) (map[string][]byte, error) {
+	overlay := make(map[string][]byte)
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if !filepath.IsAbs(root) && !strings.HasPrefix(root, ".") || strings.Contains(root, "...") {
+			continue
+		}
+
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+
+		dirs := []string{absRoot}
+
+		if recursive {
+			dirs = dirs[:0]
+
+			err := filepath.WalkDir(absRoot, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil || !entry.IsDir() {
+					return err
+				}
+
+				if path != absRoot {
+					// 与 go 命令的 ./... 一致：跳过 testdata、vendor、以 . 或 _ 开头的目录和嵌套模块。
+					name := entry.Name()
+					if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+						return filepath.SkipDir
+					}
+
+					if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+						return filepath.SkipDir
+					}
+				}
+
+				dirs = append(dirs, path)
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, dir := range dirs {
+			dirOverlay, err := buildDDLGeneratedFileOverlay(dir)
+			if err != nil {
+				return nil, err
+			}
+
+			maps.Copy(overlay, dirOverlay)
+		}
+	}
+
+	return overlay, nil
+}
+
+func loadedPackageDir(pkg *packages.Package) string {
+	if pkg.Dir != "" {
+		return pkg.Dir
+	}
+
+	if len(pkg.GoFiles) > 0 {
+		return filepath.Dir(pkg.GoFiles[0])
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdGeneratesEveryAnnotatedPackageInPattern(t *testing.T) {
+	t.Cleanup(func() {
+		dryRunFlag = false
+		checkFlag = false
+		reportFlag = ""
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	for _, name := range []string{"base", "users", "posts", "plain"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 两个包都嵌入另一个包里的基础结构体。
+	writeTestFile(t, filepath.Join(dir, "base", "base.go"), `package base
+
+type Model struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Note string `+"`db:\"note\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "users", "model.go"), `package users
+
+import "example.com/gentest/base"
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	base.Model
+	Name string `+"`db:\"name\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "posts", "model.go"), `package posts
+
+import "example.com/gentest/base"
+
+// @TABLE(name="posts", pk="ID,true")
+type Post struct {
+	base.Model
+	Title string `+"`db:\"title\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "plain", "plain.go"), "package plain\n\n// Helper mentions @TABLE only inside a sentence.\nfunc Helper() {}\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stdout := new(bytes.Buffer)
+	GenCmd.SetOut(stdout)
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--dry-run", "--report", "-", "./..."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		filepath.Join(dir, "posts", "post.tsq.go"),
+		filepath.Join(dir, "users", "user.tsq.go"),
+		filepath.Join(dir, "users", "sqlite.sql"),
+	} {
+		if !strings.Contains(out, "CREATE "+want) {
+			t.Fatalf("expected the plan to create %s, got:\n%s", want, out)
+		}
+	}
+
+	for _, skipped := range []string{"plain", "base"} {
+		if strings.Contains(out, filepath.Join(dir, skipped)+string(filepath.Separator)) {
+			t.Fatalf("expected package %s without annotations to be skipped, got:\n%s", skipped, out)
+		}
+	}
+
+	// 报告按导入路径排成数组：posts 在 users 前面。
+	start := strings.Index(out, "[")
+	if start < 0 {
+		t.Fatalf("expected a JSON report array, got:\n%s", out)
+	}
+
+	var reports []ddlChangeReport
+	if err := json.NewDecoder(strings.NewReader(out[start:])).Decode(&reports); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out)
+	}
+
+	if len(reports) != 2 || reports[0].Package != "example.com/gentest/posts" || reports[1].Package != "example.com/gentest/users" {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+
+	// --check 汇总所有包：过期的包都列出来，整体非零退出。
+	dryRunFlag = false
+	reportFlag = ""
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--check", "./users", "./posts"})
+
+	err := GenCmd.Execute()
+	if err == nil {
+		t.Fatal("expected --check to fail for packages that were never generated")
+	}
+
+	for _, want := range []string{"example.com/gentest/users: generated files are out of date", "example.com/gentest/posts: generated files are out of date"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in the aggregated error, got:\n%v", want, err)
+		}
+	}
+
+	if _, statErr := os.Stat(filepath.Join(dir, "users", "user.tsq.go")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected --check to write nothing, got err=%v", statErr)
+	}
+}
+
+func TestGenCmdRejectsPatternWithoutAnnotatedPackages(t *testing.T) {
+	t.Cleanup(func() { GenCmd.SetArgs(nil) })
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	if err := os.Mkdir(filepath.Join(dir, "plain"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "plain", "plain.go"), "package plain\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"./..."})
+
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no package matching ./... has @TABLE or @RESULT annotations") {
+		t.Fatalf("expected a no-annotations error, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// genPluginProtocolVersion 是 tsq gen 与插件交换的 JSON 的版本。插件必须在响应里回填同一个
+// 版本；不兼容的改动要升版本号，让旧插件明确失败而不是写出错的文件。
+const genPluginProtocolVersion = 1
+
+var genPluginNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// genPlugin 是 --plugin name=path 声明的外部生成器。
+type genPlugin struct {
+	name string
+	path string
+}
+
+// genPluginRequest 是写给插件 stdin 的请求。
+type genPluginRequest struct {
+	Protocol   int                    `json:"protocol"`
+	Plugin     string                 `json:"plugin"`
+	TSQVersion string                 `json:"tsq_version"`
+	Package    genPluginPackage       `json:"package"`
+	Structs    []*genmodel.StructInfo `json:"structs"`
+	Schema     ddlSnapshot            `json:"schema"`
+}
+
+type genPluginPackage struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+}
+
+// genPluginResponse 是插件写到 stdout 的响应。Error 非空表示插件拒绝生成，tsq gen 以它报错。
+type genPluginResponse struct {
+	Protocol int             `json:"protocol"`
+	Files    []genPluginFile `json:"files"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// genPluginFile 是插件要写的一个文件，Path 相对包目录。
+type genPluginFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// parseGenPluginFlags 解析 --plugin name=path，名字不能重复。
+func parseGenPluginFlags(values []string) ([]genPlugin, error) {
+	plugins := make([]genPlugin, 0, len(values))
+	seen := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		name, path, ok := strings.Cut(value, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --plugin %q: expected name=path", value)
+		}
+
+		if !genPluginNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid --plugin name %q: use lowercase letters, digits, '-' and '_'", name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate --plugin name %q", name)
+		}
+
+		seen[name] = true
+
+		plugins = append(plugins, genPlugin{name: name, path: path})
+	}
+
+	return plugins, nil
+}
+
+// buildGenPluginModels 依次运行插件，把它们返回的文件变成生成模型，和模板生成的文件一起进入
+// 生成计划：--check / --dry-run、过期文件清理和不覆盖手写文件的检查对它们同样生效。
+// ddl 是本次的 DDL 产物：tsq.json、各方言 SQL 和迁移文件同样归 tsq 所有。
+func buildGenPluginModels(
+	plugins []genPlugin,
+	request genPluginRequest,
+	models []generationModel,
+	ddl ddlArtifacts,
+	stderr io.Writer,
+) ([]generationModel, error) {
+	owners := make(map[string]string, len(models)+len(ddl.models))
+	for _, model := range models {
+		owners[model.Filename] = "tsq"
+	}
+
+	for _, model := range ddl.models {
+		owners[model.Filename] = "tsq"
+	}
+
+	var pluginModels []generationModel
+
+	for _, plugin := range plugins {
+		request.Plugin = plugin.name
+
+		files, err := runGenPlugin(plugin, request, stderr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			filename, err := resolveGenPluginFilename(request.Package.Dir, file.Path)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %s: %w", plugin.name, err)
+			}
+
+			if owner, ok := owners[filename]; ok {
+				return nil, fmt.Errorf("plugin %s: %s is also generated by %s", plugin.name, filename, owner)
+			}
+
+			if err := checkGenPluginDDLPath(ddl.dir, filename); err != nil {
+				return nil, fmt.Errorf("plugin %s: %w", plugin.name, err)
+			}
+
+			owners[filename] = "plugin " + plugin.name
+
+			// 生成文件必须带 tsq 的文件头，下次生成才能确认是自己的文件并覆盖它。
+			if !isGeneratedArtifact([]byte(file.Content)) {
+				return nil, fmt.Errorf("plugin %s: %s must start with %q (or be JSON with a \"generated_by\": \"tsq-...\" field)",
+					plugin.name, file.Path, generatedFileHeaderPrefix)
+			}
+
+			pluginModels = append(pluginModels, generationModel{
+				Filename: filename,
+				Source:   []byte(file.Content),
+			})
+		}
+	}
+
+	return pluginModels, nil
+}
+
+// runGenPlugin 把请求写到插件的 stdin，读取 stdout 上的响应；插件的 stderr 原样转发。
+func runGenPlugin(plugin genPlugin, request genPluginRequest, stderr io.Writer) ([]genPluginFile, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: encode request: %w", plugin.name, err)
+	}
+
+	path := plugin.path
+	if strings.ContainsRune(path, filepath.Separator) || strings.ContainsRune(path, '/') {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output := new(bytes.Buffer)
+
+	command := exec.Command(path)
+	command.Stdin = bytes.NewReader(input)
+	command.Stdout = output
+	command.Stderr = stderr
+
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s (%s) failed: %w", plugin.name, plugin.path, err)
+	}
+
+	var response genPluginResponse
+
+	decoder := json.NewDecoder(output)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&response); err != nil {
+		return nil, fmt.Errorf("plugin %s: decode response: %w", plugin.name, err)
+	}
+
+	if response.Protocol != genPluginProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol %d, tsq gen speaks protocol %d", plugin.name, response.Protocol, genPluginProtocolVersion)
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", plugin.name, response.Error)
+	}
+
+	return response.Files, nil
+}
+
+// resolveGenPluginFilename 把插件给的相对路径落到包目录下，不允许绝对路径或跳出包目录。
+func resolveGenPluginFilename(dir, path string) (string, error) {
+	if path == "" || !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("file path %q must be relative to the package directory and stay inside it", path)
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(path)), nil
+}
+
+// checkGenPluginDDLPath 拒绝落在 DDL 目录里的 .sql / tsq.json 和迁移目录里的任何文件：
+// findStaleDDLFiles 会把这些位置上不在本次计划里的生成文件当作过期删掉。
+func checkGenPluginDDLPath(ddlDir, filename string) error {
+	dir, name := filepath.Dir(filename), filepath.Base(filename)
+
+	if slices.Contains(ddlMigrationDirs(ddlDir), dir) {
+		return fmt.Errorf("%s is inside the DDL migrations directory, which tsq gen owns", filename)
+	}
+
+	if dir == filepath.Clean(ddlDir) && (strings.HasSuffix(name, ".sql") || name == ddlStateFilename || name == legacyDDLStateFilename) {
+		return fmt.Errorf("%s is a DDL file name in the DDL directory %s, which tsq gen owns", filename, ddlDir)
+	}
+
+	return nil
+}
+
+func genPluginPackageFor(list []*genmodel.StructInfo, dir string) genPluginPackage {
+	pkg := genPluginPackage{Dir: dir}
+
+	for _, s := range list {
+		if s != nil && s.TypeInfo.Package.Path != "" {
+			pkg.Path = s.TypeInfo.Package.Path
+			pkg.Name = s.TypeInfo.Package.Name
+
+			break
+		}
+	}
+
+	return pkg
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGenTestPlugin 写一个把请求存到 request.json、再原样输出 response 的插件脚本。
+func writeGenTestPlugin(t *testing.T, dir string, response string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "plugin.sh")
+	script := "#!/bin/sh\ncat > " + filepath.Join(dir, "request.json") + "\ncat <<'EOF'\n" + response + "\nEOF\n"
+
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func genTestPluginResponse(t *testing.T, files ...genPluginFile) string {
+	t.Helper()
+
+	bs, err := json.Marshal(genPluginResponse{Protocol: genPluginProtocolVersion, Files: files})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(bs)
+}
+
+func TestParseGenPluginFlags(t *testing.T) {
+	plugins, err := parseGenPluginFlags([]string{"repo=./bin/tsq-repo", "dto=tsq-dto"})
+	if err != nil {
+		t.Fatalf("parseGenPluginFlags() error = %v", err)
+	}
+
+	if len(plugins) != 2 || plugins[0] != (genPlugin{name: "repo", path: "./bin/tsq-repo"}) || plugins[1].name != "dto" {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+
+	for _, tc := range []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"repo"}, "expected name=path"},
+		{[]string{"repo="}, "expected name=path"},
+		{[]string{"Repo=x"}, "invalid --plugin name"},
+		{[]string{"repo=a", "repo=b"}, "duplicate --plugin name"},
+	} {
+		if _, err := parseGenPluginFlags(tc.values); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("parseGenPluginFlags(%v): expected %q, got %v", tc.values, tc.want, err)
+		}
+	}
+}
+
+func TestGenCmdWritesAndChecksPluginFiles(t *testing.T) {
+	t.Cleanup(func() {
+		checkFlag = false
+		pluginFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Name string `+"`db:\"name,size:64\"`"+`
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	repo := genPluginFile{Path: "user_repo.tsq.go", Content: generatedFileHeaderPrefix + "plugin-repo. DO NOT EDIT.\n\npackage gentest\n"}
+	dto := genPluginFile{Path: "api/users.json", Content: `{"generated_by": "tsq-plugin-repo", "table": "users"}` + "\n"}
+	plugin := writeGenTestPlugin(t, dir, genTestPluginResponse(t, repo, dto))
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--plugin", "repo=" + plugin, "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	for _, file := range []genPluginFile{repo, dto} {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil || string(content) != file.Content {
+			t.Fatalf("expected the plugin to write %s, got %q (err=%v)", file.Path, content, err)
+		}
+	}
+
+	// 插件拿到解析后的结构体和当前 schema。
+	var request struct {
+		Protocol int    `json:"protocol"`
+		Plugin   string `json:"plugin"`
+		Package  struct {
+			Path string `json:"path"`
+			Name string `json:"name"`
+		} `json:"package"`
+		Structs []struct {
+			Table string
+		} `json:"structs"`
+		Schema ddlSnapshot `json:"schema"`
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(raw, &request); err != nil {
+		t.Fatalf("decode request: %v\n%s", err, raw)
+	}
+
+	if request.Protocol != genPluginProtocolVersion || request.Plugin != "repo" || request.Package.Path != "example.com/gentest" || request.Package.Name != "gentest" {
+		t.Fatalf("unexpected request header %+v", request)
+	}
+
+	if len(request.Structs) != 1 || request.Structs[0].Table != "users" || len(request.Schema.Tables) != 1 || request.Schema.Tables[0].Columns[1].Size != 64 {
+		t.Fatalf("unexpected request models:\n%s", raw)
+	}
+
+	// --check 覆盖插件文件：插件输出变了就算过期。StringArray 标志在多次 Execute 之间会累加，
+	// 每次都先清空。
+	pluginFlag = nil
+	GenCmd.SetArgs([]string{"--check", "--plugin", "repo=" + plugin, "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("expected --check to pass with up-to-date plugin files, got %v", err)
+	}
+
+	changed := repo
+	changed.Content += "\nconst Changed = true\n"
+	writeGenTestPlugin(t, dir, genTestPluginResponse(t, changed, dto))
+
+	pluginFlag = nil
+
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "UPDATE "+filepath.Join(dir, "user_repo.tsq.go")) {
+		t.Fatalf("expected --check to report the plugin file, got %v", err)
+	}
+
+	// 不再运行插件时，它留下的 *.tsq.go 按过期文件处理。
+	pluginFlag = nil
+	GenCmd.SetArgs([]string{"--check", "."})
+
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "STALE "+filepath.Join(dir, "user_repo.tsq.go")) {
+		t.Fatalf("expected the dropped plugin file to be stale, got %v", err)
+	}
+}
+
+func TestGenCmdRejectsUnsafePluginOutput(t *testing.T) {
+	t.Cleanup(func() {
+		pluginFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID int64 `+"`db:\"id\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "handwritten.go"), "package gentest\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	header := generatedFileHeaderPrefix + "plugin-bad. DO NOT EDIT.\n"
+
+	for _, tc := range []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"escapes the package", genTestPluginResponse(t, genPluginFile{Path: "../x.go", Content: header}), "must be relative to the package directory"},
+		{"no header", genTestPluginResponse(t, genPluginFile{Path: "x.go", Content: "package gentest\n"}), "must start with"},
+		{"handwritten file", genTestPluginResponse(t, genPluginFile{Path: "handwritten.go", Content: header}), "refusing to overwrite non-generated file"},
+		{"tsq's own file", genTestPluginResponse(t, genPluginFile{Path: "user.tsq.go", Content: header}), "is also generated by tsq"},
+		{"tsq's DDL file", genTestPluginResponse(t, genPluginFile{Path: "sqlite.sql", Content: "-- " + header}), "is also generated by tsq"},
+		{"SQL beside the DDL", genTestPluginResponse(t, genPluginFile{Path: "seed.sql", Content: "-- " + header}), "DDL directory"},
+		{"migrations directory", genTestPluginResponse(t, genPluginFile{Path: "migrations/sqlite/README.md", Content: header}), "DDL migrations directory"},
+		{"protocol", `{"protocol": 2, "files": []}`, "speaks protocol 2"},
+		{"plugin error", `{"protocol": 1, "error": "users has no primary key"}`, "plugin bad: users has no primary key"},
+	} {
+		plugin := writeGenTestPlugin(t, dir, tc.response)
+
+		GenCmd.SetOut(new(bytes.Buffer))
+		GenCmd.SetErr(new(bytes.Buffer))
+		GenCmd.SetArgs([]string{"--plugin", "bad=" + plugin, "."})
+
+		if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+
+		pluginFlag = nil
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "handwritten.go")); err != nil || string(content) != "package gentest\n" {
+		t.Fatalf("expected the handwritten file to be untouched, got %q (err=%v)", content, err)
+	}
+}
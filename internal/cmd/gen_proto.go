@@ -0,0 +1,632 @@
+package cmd
+
+import (
+	"fmt"
+	"go/types"
+	"maps"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/serenize/snaker"
+	"mvdan.cc/gofumpt/format"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// protoConverterFilename 是 --emit proto 写在包目录里的 Go 转换函数。.proto 文件按包名命名为
+// <package>.tsq.proto，避免不同包的文件在 protobuf 的全局注册表里重名。
+const protoConverterFilename = "proto.tsq.go"
+
+const (
+	protoImportTimestamppb = "google.golang.org/protobuf/types/known/timestamppb"
+	protoImportWrapperspb  = "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoFirstReservedNumber 到 protoLastReservedNumber 是 protobuf 自己保留的字段编号。
+const (
+	protoFirstReservedNumber = 19000
+	protoLastReservedNumber  = 19999
+)
+
+var protoPackagePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// protoGoReservedNames 是 protoc-gen-go 生成的消息方法名，同名字段会被加上下划线后缀。
+var protoGoReservedNames = []string{"Reset", "String", "ProtoMessage", "Marshal", "Unmarshal", "ExtensionRangeArray", "ExtensionMap", "Descriptor"}
+
+// protoMessage 是一个 @TABLE / @RESULT 类型对应的消息。
+type protoMessage struct {
+	name   string
+	fields []protoField
+}
+
+// protoField 是消息里的一个字段。值为 NULL 的字段用包装类型（时间用 Timestamp），protobuf 里
+// 没有设置就是 NULL。
+type protoField struct {
+	// goName 是结构体字段名，name 是 .proto 里的字段名，protoName 是 protoc-gen-go 生成的字段名。
+	goName    string
+	name      string
+	protoName string
+
+	scalar protoScalar
+	// value 是去掉指针或 Null 包装后的 Go 类型，和 scalar 的 Go 类型不同时转换时要显式转型。
+	value types.Type
+	// pointer 表示字段是指针；nullValue 非空时字段是 database/sql 或 null.* 的 Null 类型，
+	// 是其中值字段的名字。
+	pointer   bool
+	nullValue string
+}
+
+func (f protoField) nullable() bool {
+	return f.pointer || f.nullValue != ""
+}
+
+func (f protoField) protoType() string {
+	switch {
+	case f.scalar.timestamp:
+		return "google.protobuf.Timestamp"
+	case f.nullable():
+		return "google.protobuf." + f.scalar.wrapper + "Value"
+	default:
+		return f.scalar.proto
+	}
+}
+
+// protoScalar 是 Go 基本类型对应的 protobuf 标量、protoc-gen-go 里的 Go 类型和 wrapperspb 的
+// 构造函数名。timestamp 表示 time.Time。
+type protoScalar struct {
+	proto     string
+	goType    types.Type
+	wrapper   string
+	timestamp bool
+}
+
+var protoScalars = map[types.BasicKind]protoScalar{
+	types.Bool:    {proto: "bool", goType: types.Typ[types.Bool], wrapper: "Bool"},
+	types.Int8:    {proto: "int32", goType: types.Typ[types.Int32], wrapper: "Int32"},
+	types.Int16:   {proto: "int32", goType: types.Typ[types.Int32], wrapper: "Int32"},
+	types.Int32:   {proto: "int32", goType: types.Typ[types.Int32], wrapper: "Int32"},
+	types.Int:     {proto: "int64", goType: types.Typ[types.Int64], wrapper: "Int64"},
+	types.Int64:   {proto: "int64", goType: types.Typ[types.Int64], wrapper: "Int64"},
+	types.Uint8:   {proto: "uint32", goType: types.Typ[types.Uint32], wrapper: "UInt32"},
+	types.Uint16:  {proto: "uint32", goType: types.Typ[types.Uint32], wrapper: "UInt32"},
+	types.Uint32:  {proto: "uint32", goType: types.Typ[types.Uint32], wrapper: "UInt32"},
+	types.Uint:    {proto: "uint64", goType: types.Typ[types.Uint64], wrapper: "UInt64"},
+	types.Uint64:  {proto: "uint64", goType: types.Typ[types.Uint64], wrapper: "UInt64"},
+	types.Float32: {proto: "float", goType: types.Typ[types.Float32], wrapper: "Float"},
+	types.Float64: {proto: "double", goType: types.Typ[types.Float64], wrapper: "Double"},
+	types.String:  {proto: "string", goType: types.Typ[types.String], wrapper: "String"},
+}
+
+var protoBytesScalar = protoScalar{proto: "bytes", goType: types.NewSlice(types.Typ[types.Uint8]), wrapper: "Bytes"}
+
+// protoGoPackage 是 protoc-gen-go 生成代码的导入路径和包名。
+type protoGoPackage struct {
+	path string
+	name string
+}
+
+// resolveProtoGoPackage 解析 proto.go_package：可以写成 "path;name"，默认是 <包导入路径>/<包名>pb。
+func resolveProtoGoPackage(config *genConfig, pkg genPluginPackage) protoGoPackage {
+	goPackage := ""
+	if config != nil {
+		goPackage = config.Proto.GoPackage
+	}
+
+	if goPackage == "" {
+		return protoGoPackage{path: pkg.Path + "/" + pkg.Name + "pb", name: pkg.Name + "pb"}
+	}
+
+	if importPath, name, ok := strings.Cut(goPackage, ";"); ok {
+		return protoGoPackage{path: importPath, name: name}
+	}
+
+	return protoGoPackage{path: goPackage, name: path.Base(goPackage)}
+}
+
+// describeProtoMessages 按类型名排序列出消息，字段顺序同结构体；proto:"-" 的字段不进消息。
+func describeProtoMessages(list []*genmodel.StructInfo, resolver *ddlTypeResolver) ([]protoMessage, error) {
+	var messages []protoMessage
+
+	for _, s := range list {
+		if s == nil || s.TableMeta == nil || len(s.Fields) == 0 {
+			continue
+		}
+
+		message := protoMessage{name: s.TypeInfo.TypeName}
+
+		for _, field := range s.Fields {
+			varObj, tag, err := resolver.lookupField(s, field)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.TypeInfo.TypeName, field.Name, err)
+			}
+
+			if reflect.StructTag(tag).Get("proto") == "-" {
+				continue
+			}
+
+			item, ok := describeProtoField(varObj.Type())
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: %s has no protobuf mapping; tag the field proto:\"-\" to leave it out",
+					s.TypeInfo.TypeName, field.Name, varObj.Type())
+			}
+
+			item.goName = field.Name
+			item.name = snaker.CamelToSnake(field.Name)
+			item.protoName = protoGoFieldName(item.name)
+			message.fields = append(message.fields, item)
+		}
+
+		messages = append(messages, message)
+	}
+
+	slices.SortFunc(messages, func(a, b protoMessage) int { return strings.Compare(a.name, b.name) })
+
+	return messages, nil
+}
+
+func describeProtoField(t types.Type) (protoField, bool) {
+	if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+		scalar, ok := describeProtoScalar(pointer.Elem())
+
+		return protoField{scalar: scalar, value: pointer.Elem(), pointer: true}, ok
+	}
+
+	if name, value, ok := protoNullValueField(t); ok {
+		scalar, ok := describeProtoScalar(value.Type())
+
+		return protoField{scalar: scalar, value: value.Type(), nullValue: name}, ok
+	}
+
+	scalar, ok := describeProtoScalar(t)
+
+	return protoField{scalar: scalar, value: t}, ok
+}
+
+func describeProtoScalar(t types.Type) (protoScalar, bool) {
+	if jsonNamedTypeName(t) == "time.Time" {
+		return protoScalar{timestamp: true}, true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		scalar, ok := protoScalars[u.Kind()]
+
+		return scalar, ok
+	case *types.Slice:
+		if basic, ok := u.Elem().Underlying().(*types.Basic); ok && basic.Kind() == types.Uint8 {
+			return protoBytesScalar, true
+		}
+	}
+
+	return protoScalar{}, false
+}
+
+// protoNullValueField 认出 database/sql 和 gopkg.in/nullbio/null 的 Null 类型：结构体只有一个值字段
+// 加上 Valid bool（null.* 里 sql.Null* 是内嵌的）。
+func protoNullValueField(t types.Type) (string, *types.Var, bool) {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return "", nil, false
+	}
+
+	pkgPath := named.Obj().Pkg().Path()
+	if pkgPath != importPathDatabaseSQL && !strings.HasPrefix(pkgPath, nullbioImportPrefix) {
+		return "", nil, false
+	}
+
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return "", nil, false
+	}
+
+	if st.NumFields() == 1 && st.Field(0).Embedded() {
+		return protoNullValueField(st.Field(0).Type())
+	}
+
+	if st.NumFields() != 2 || st.Field(1).Name() != "Valid" {
+		return "", nil, false
+	}
+
+	return st.Field(0).Name(), st.Field(0), true
+}
+
+// protoGoFieldName 按 protoc-gen-go 的规则把 .proto 字段名转成 Go 字段名。
+func protoGoFieldName(name string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		switch {
+		case c == '_' && i == 0:
+			b.WriteByte('X')
+		case c == '_' && i+1 < len(name) && isASCIILower(name[i+1]):
+		case c >= '0' && c <= '9':
+			b.WriteByte(c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+
+			b.WriteByte(c)
+
+			for ; i+1 < len(name) && isASCIILower(name[i+1]); i++ {
+				b.WriteByte(name[i+1])
+			}
+		}
+	}
+
+	goName := b.String()
+	if slices.Contains(protoGoReservedNames, goName) {
+		goName += "_"
+	}
+
+	return goName
+}
+
+func isASCIILower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// protoMessageFieldNames 是 tsq.json 记录字段编号用的消息字段名。
+func protoMessageFieldNames(messages []protoMessage) map[string][]string {
+	names := make(map[string][]string, len(messages))
+
+	for _, message := range messages {
+		fields := make([]string, 0, len(message.fields))
+		for _, field := range message.fields {
+			fields = append(fields, field.name)
+		}
+
+		names[message.name] = fields
+	}
+
+	return names
+}
+
+// assignProtoFieldNumbers 沿用 tsq.json 里记过的编号，包括删掉后又加回来的字段；新字段从消息用过的
+// 最大编号往后排。删掉的字段（以及删掉的消息的全部字段）转入 Reserved，编号不再分给别的字段。
+func assignProtoFieldNumbers(previous map[string]ddlStateProtoMessage, messages map[string][]string) map[string]ddlStateProtoMessage {
+	assigned := make(map[string]ddlStateProtoMessage, len(previous)+len(messages))
+
+	for _, name := range slices.Sorted(maps.Keys(previous)) {
+		if _, ok := messages[name]; !ok {
+			assigned[name] = assignProtoMessageNumbers(previous[name], nil)
+		}
+	}
+
+	for name, fields := range messages {
+		assigned[name] = assignProtoMessageNumbers(previous[name], fields)
+	}
+
+	return assigned
+}
+
+func assignProtoMessageNumbers(previous ddlStateProtoMessage, fields []string) ddlStateProtoMessage {
+	known := make(map[string]int, len(previous.Fields)+len(previous.Reserved))
+	maps.Copy(known, previous.Reserved)
+	maps.Copy(known, previous.Fields)
+
+	last := 0
+	for _, number := range known {
+		last = max(last, number)
+	}
+
+	message := ddlStateProtoMessage{Fields: make(map[string]int, len(fields))}
+
+	for _, field := range fields {
+		if number, ok := known[field]; ok {
+			message.Fields[field] = number
+			continue
+		}
+
+		last++
+		if last >= protoFirstReservedNumber && last <= protoLastReservedNumber {
+			last = protoLastReservedNumber + 1
+		}
+
+		message.Fields[field] = last
+	}
+
+	for field, number := range known {
+		if _, ok := message.Fields[field]; ok {
+			continue
+		}
+
+		if message.Reserved == nil {
+			message.Reserved = make(map[string]int)
+		}
+
+		message.Reserved[field] = number
+	}
+
+	return message
+}
+
+// mergeDDLStateProto 合并分支各自分配的字段编号。先记录的一方优先；另一方新加的字段编号已被占用时
+// 丢掉，下次 tsq gen --emit proto 重新分配。
+func mergeDDLStateProto(states []*ddlStateFile) map[string]ddlStateProtoMessage {
+	var merged map[string]ddlStateProtoMessage
+
+	for _, state := range states {
+		for _, name := range slices.Sorted(maps.Keys(state.Proto)) {
+			if merged == nil {
+				merged = make(map[string]ddlStateProtoMessage)
+			}
+
+			message := merged[name]
+			other := state.Proto[name]
+
+			used := make(map[int]bool)
+			for _, number := range message.Fields {
+				used[number] = true
+			}
+
+			for _, number := range message.Reserved {
+				used[number] = true
+			}
+
+			for _, group := range []struct {
+				from map[string]int
+				to   *map[string]int
+			}{{other.Fields, &message.Fields}, {other.Reserved, &message.Reserved}} {
+				for _, field := range slices.Sorted(maps.Keys(group.from)) {
+					number := group.from[field]
+					if _, ok := message.Fields[field]; ok {
+						continue
+					}
+
+					if _, ok := message.Reserved[field]; ok || used[number] {
+						continue
+					}
+
+					if *group.to == nil {
+						*group.to = make(map[string]int)
+					}
+
+					(*group.to)[field] = number
+					used[number] = true
+				}
+			}
+
+			merged[name] = message
+		}
+	}
+
+	return merged
+}
+
+// buildProtoModels 写 .proto 文件和 ToProto / <Type>FromProto 转换函数。字段编号取自 tsq.json。
+func buildProtoModels(input genOutputInput) ([]generationModel, error) {
+	messages, err := describeProtoMessages(input.list, input.resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	pkg := genPluginPackageFor(input.list, input.dir)
+	goPackage := resolveProtoGoPackage(input.config, pkg)
+
+	protoPackage := pkg.Name
+	if input.config != nil && input.config.Proto.Package != "" {
+		protoPackage = input.config.Proto.Package
+	}
+
+	protoSource := renderProtoFile(messages, input.protoFields, protoPackage, goPackage, pkg.Path, input.version)
+
+	converterSource, err := renderProtoConverters(messages, goPackage, pkg, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return []generationModel{
+		{
+			Filename: filepath.Join(input.dir, pkg.Name+".tsq.proto"),
+			Source:   protoSource,
+		},
+		{
+			Filename: filepath.Join(input.dir, protoConverterFilename),
+			Source:   converterSource,
+		},
+	}, nil
+}
+
+func renderProtoFile(messages []protoMessage, numbers map[string]ddlStateProtoMessage, protoPackage string, goPackage protoGoPackage, source, version string) []byte {
+	var (
+		body       strings.Builder
+		timestamps bool
+		wrappers   bool
+	)
+
+	for _, message := range messages {
+		assigned := numbers[message.name]
+
+		fmt.Fprintf(&body, "\nmessage %s {\n", message.name)
+
+		if len(assigned.Reserved) > 0 {
+			reserved := slices.Sorted(maps.Values(assigned.Reserved))
+			names := slices.Sorted(maps.Keys(assigned.Reserved))
+
+			numberList := make([]string, 0, len(reserved))
+			for _, number := range reserved {
+				numberList = append(numberList, fmt.Sprint(number))
+			}
+
+			fmt.Fprintf(&body, "  reserved %s;\n  reserved \"%s\";\n\n", strings.Join(numberList, ", "), strings.Join(names, `", "`))
+		}
+
+		fields := slices.SortedFunc(slices.Values(message.fields), func(a, b protoField) int {
+			return assigned.Fields[a.name] - assigned.Fields[b.name]
+		})
+
+		for _, field := range fields {
+			timestamps = timestamps || field.scalar.timestamp
+			wrappers = wrappers || (field.nullable() && !field.scalar.timestamp)
+
+			fmt.Fprintf(&body, "  %s %s = %d;\n", field.protoType(), field.name, assigned.Fields[field.name])
+		}
+
+		body.WriteString("}\n")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s%s. DO NOT EDIT.\n", generatedFileHeaderPrefix, version)
+
+	if source != "" {
+		fmt.Fprintf(&b, "// Source: %s\n", source)
+	}
+
+	fmt.Fprintf(&b, "\nsyntax = \"proto3\";\n\npackage %s;\n", protoPackage)
+
+	if timestamps || wrappers {
+		b.WriteString("\n")
+	}
+
+	if timestamps {
+		b.WriteString("import \"google/protobuf/timestamp.proto\";\n")
+	}
+
+	if wrappers {
+		b.WriteString("import \"google/protobuf/wrappers.proto\";\n")
+	}
+
+	fmt.Fprintf(&b, "\noption go_package = \"%s;%s\";\n", goPackage.path, goPackage.name)
+	b.WriteString(body.String())
+
+	return []byte(b.String())
+}
+
+// renderProtoConverters 为每个消息写 (*T).ToProto() 和 TFromProto()，两者都把 nil 原样返回。
+func renderProtoConverters(messages []protoMessage, goPackage protoGoPackage, pkg genPluginPackage, input genOutputInput) ([]byte, error) {
+	imports := map[string]string{goPackage.path: goPackage.name}
+
+	var typesPkg *types.Package
+
+	for _, s := range input.list {
+		if s != nil && s.TableMeta != nil {
+			_, p, err := input.resolver.lookupNamedStruct(s.TypeInfo)
+			if err != nil {
+				return nil, err
+			}
+
+			typesPkg = p
+
+			break
+		}
+	}
+
+	qualifier := func(p *types.Package) string {
+		if p == typesPkg {
+			return ""
+		}
+
+		imports[p.Path()] = p.Name()
+
+		return p.Name()
+	}
+
+	var body strings.Builder
+
+	for _, message := range messages {
+		pbType := goPackage.name + "." + message.name
+
+		fmt.Fprintf(&body, "\n// ToProto converts %s to its protobuf message.\n", message.name)
+		fmt.Fprintf(&body, "func (t *%s) ToProto() *%s {\nif t == nil {\nreturn nil\n}\n\nm := &%s{\n", message.name, pbType, pbType)
+
+		var optional strings.Builder
+
+		for _, field := range message.fields {
+			value := "t." + field.goName
+
+			switch {
+			case field.scalar.timestamp && field.pointer:
+				imports[protoImportTimestamppb] = "timestamppb"
+				fmt.Fprintf(&optional, "if %s != nil {\nm.%s = timestamppb.New(*%s)\n}\n", value, field.protoName, value)
+			case field.scalar.timestamp && field.nullValue != "":
+				imports[protoImportTimestamppb] = "timestamppb"
+				fmt.Fprintf(&optional, "if %s.Valid {\nm.%s = timestamppb.New(%s.%s)\n}\n", value, field.protoName, value, field.nullValue)
+			case field.scalar.timestamp:
+				imports[protoImportTimestamppb] = "timestamppb"
+				fmt.Fprintf(&body, "%s: timestamppb.New(%s),\n", field.protoName, value)
+			case field.pointer:
+				imports[protoImportWrapperspb] = "wrapperspb"
+				fmt.Fprintf(&optional, "if %s != nil {\nm.%s = wrapperspb.%s(%s)\n}\n",
+					value, field.protoName, field.scalar.wrapper, protoConvert("*"+value, field.value, field.scalar.goType, qualifier))
+			case field.nullValue != "":
+				imports[protoImportWrapperspb] = "wrapperspb"
+				fmt.Fprintf(&optional, "if %s.Valid {\nm.%s = wrapperspb.%s(%s)\n}\n",
+					value, field.protoName, field.scalar.wrapper, protoConvert(value+"."+field.nullValue, field.value, field.scalar.goType, qualifier))
+			default:
+				fmt.Fprintf(&body, "%s: %s,\n", field.protoName, protoConvert(value, field.value, field.scalar.goType, qualifier))
+			}
+		}
+
+		fmt.Fprintf(&body, "}\n\n%s\nreturn m\n}\n", optional.String())
+
+		fmt.Fprintf(&body, "\n// %sFromProto converts a protobuf message to %s.\n", message.name, message.name)
+		fmt.Fprintf(&body, "func %sFromProto(m *%s) *%s {\nif m == nil {\nreturn nil\n}\n\nt := new(%s)\n", message.name, pbType, message.name, message.name)
+
+		for _, field := range message.fields {
+			target := "t." + field.goName
+			value := "m." + field.protoName
+
+			switch {
+			case field.scalar.timestamp && field.pointer:
+				fmt.Fprintf(&body, "if %s != nil {\nv := %s.AsTime()\n%s = &v\n}\n", value, value, target)
+			case field.scalar.timestamp && field.nullValue != "":
+				fmt.Fprintf(&body, "if %s != nil {\n%s.%s = %s.AsTime()\n%s.Valid = true\n}\n", value, target, field.nullValue, value, target)
+			case field.scalar.timestamp:
+				fmt.Fprintf(&body, "if %s != nil {\n%s = %s.AsTime()\n}\n", value, target, value)
+			case field.pointer:
+				fmt.Fprintf(&body, "if %s != nil {\nv := %s\n%s = &v\n}\n",
+					value, protoConvert(value+".GetValue()", field.scalar.goType, field.value, qualifier), target)
+			case field.nullValue != "":
+				fmt.Fprintf(&body, "if %s != nil {\n%s.%s = %s\n%s.Valid = true\n}\n",
+					value, target, field.nullValue, protoConvert(value+".GetValue()", field.scalar.goType, field.value, qualifier), target)
+			default:
+				fmt.Fprintf(&body, "%s = %s\n", target, protoConvert(value, field.scalar.goType, field.value, qualifier))
+			}
+		}
+
+		body.WriteString("\nreturn t\n}\n")
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s%s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", generatedFileHeaderPrefix, input.version, pkg.Name)
+
+	for _, importPath := range slices.Sorted(maps.Keys(imports)) {
+		if name := imports[importPath]; name != path.Base(importPath) {
+			fmt.Fprintf(&b, "%s ", name)
+		}
+
+		fmt.Fprintf(&b, "%q\n", importPath)
+	}
+
+	b.WriteString(")\n")
+	b.WriteString(body.String())
+
+	src, err := format.Source([]byte(b.String()), format.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("go code formatting failed: %s: %w", protoConverterFilename, err)
+	}
+
+	return src, nil
+}
+
+// protoConvert 在 from 和 to 不是同一个类型时给 expr 加上转型。
+func protoConvert(expr string, from, to types.Type, qualifier types.Qualifier) string {
+	if types.Identical(from, to) {
+		return expr
+	}
+
+	return types.TypeString(to, qualifier) + "(" + expr + ")"
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsProtoWithStableFieldNumbers(t *testing.T) {
+	t.Cleanup(func() {
+		allowDestructiveFlag = false
+		emitFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	model := `package gentest
+
+import (
+	"time"
+
+	"gopkg.in/nullbio/null.v6"
+)
+
+type Level int8
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID        int64       ` + "`db:\"id\" json:\"id\"`" + `
+	Avatar    []byte      ` + "`db:\"avatar\" json:\"avatar\"`" + `
+	Bio       null.String ` + "`db:\"bio\" json:\"bio\"`" + `
+	Level     Level       ` + "`db:\"level\" json:\"level\"`" + `
+	Nickname  *string     ` + "`db:\"nickname\" json:\"nickname\"`" + `
+	Secret    string      ` + "`db:\"secret\" json:\"-\" proto:\"-\"`" + `
+	CreatedAt time.Time   ` + "`db:\"created_at\" json:\"created_at\"`" + `
+	UpdatedAt null.Time   ` + "`db:\"updated_at\" json:\"updated_at\"`" + `
+	Visits    uint16      ` + "`db:\"visits\" json:\"visits\"`" + `
+}
+`
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--emit", "proto", "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	readFile := func(name string) string {
+		t.Helper()
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+
+		return string(content)
+	}
+
+	protoFile := readFile("gentest.tsq.proto")
+	for _, want := range []string{
+		generatedFileHeaderPrefix,
+		"package gentest;\n",
+		"import \"google/protobuf/timestamp.proto\";\nimport \"google/protobuf/wrappers.proto\";\n",
+		"option go_package = \"example.com/gentest/gentestpb;gentestpb\";\n",
+		`message User {
+  bytes avatar = 1;
+  google.protobuf.StringValue bio = 2;
+  google.protobuf.Timestamp created_at = 3;
+  int64 id = 4;
+  int32 level = 5;
+  google.protobuf.StringValue nickname = 6;
+  google.protobuf.Timestamp updated_at = 7;
+  uint32 visits = 8;
+}
+`,
+	} {
+		if !strings.Contains(protoFile, want) {
+			t.Fatalf("expected the .proto file to contain:\n%s\ngot:\n%s", want, protoFile)
+		}
+	}
+
+	converters := readFile(protoConverterFilename)
+	for _, want := range []string{
+		"package gentest\n",
+		`"example.com/gentest/gentestpb"`,
+		"func (t *User) ToProto() *gentestpb.User {",
+		"Level:     int32(t.Level),",
+		"m.Bio = wrapperspb.String(t.Bio.String)",
+		"m.Nickname = wrapperspb.String(*t.Nickname)",
+		"m.UpdatedAt = timestamppb.New(t.UpdatedAt.Time)",
+		"func UserFromProto(m *gentestpb.User) *User {",
+		"t.Level = Level(m.Level)",
+		"t.Visits = uint16(m.Visits)",
+		"t.Bio.String = m.Bio.GetValue()\n\t\tt.Bio.Valid = true",
+		"t.CreatedAt = m.CreatedAt.AsTime()",
+	} {
+		if !strings.Contains(converters, want) {
+			t.Fatalf("expected %s to contain:\n%s\ngot:\n%s", protoConverterFilename, want, converters)
+		}
+	}
+
+	if strings.Contains(converters, "Secret") {
+		t.Fatalf("expected proto:\"-\" to leave Secret out, got:\n%s", converters)
+	}
+
+	// 删掉一个字段、加上一个字段：旧字段的编号不变，新字段往后排，删掉的编号保留。
+	model = strings.Replace(model, "\tBio       null.String `db:\"bio\" json:\"bio\"`\n", "\tEmail     string      `db:\"email\" json:\"email\"`\n", 1)
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+
+	emitFlag = nil
+	GenCmd.SetArgs([]string{"--emit", "proto", "--allow-destructive", "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	protoFile = readFile("gentest.tsq.proto")
+	for _, want := range []string{
+		"  reserved 2;\n  reserved \"bio\";\n",
+		"  bytes avatar = 1;\n  google.protobuf.Timestamp created_at = 3;\n",
+		"  uint32 visits = 8;\n  string email = 9;\n",
+	} {
+		if !strings.Contains(protoFile, want) {
+			t.Fatalf("expected the .proto file to contain:\n%s\ngot:\n%s", want, protoFile)
+		}
+	}
+
+	if state := readFile(ddlStateFilename); !strings.Contains(state, `"reserved": {
+        "bio": 2
+      }`) {
+		t.Fatalf("expected tsq.json to keep the removed field number, got:\n%s", state)
+	}
+}
+
+func TestAssignProtoFieldNumbersSkipsReservedRange(t *testing.T) {
+	got := assignProtoFieldNumbers(map[string]ddlStateProtoMessage{
+		"User": {Fields: map[string]int{"id": 18999}},
+		"Gone": {Fields: map[string]int{"id": 1}},
+	}, map[string][]string{"User": {"id", "name"}})
+
+	if got["User"].Fields["name"] != 20000 {
+		t.Fatalf("expected the next number after 18999 to skip 19000-19999, got %v", got["User"])
+	}
+
+	if got["Gone"].Reserved["id"] != 1 || len(got["Gone"].Fields) != 0 {
+		t.Fatalf("expected a removed message to reserve its numbers, got %v", got["Gone"])
+	}
+}
+
+func TestMergeDDLStateProtoKeepsTheFirstBranchNumbers(t *testing.T) {
+	got := mergeDDLStateProto([]*ddlStateFile{
+		{Proto: map[string]ddlStateProtoMessage{"User": {Fields: map[string]int{"id": 1, "email": 2}}}},
+		{Proto: map[string]ddlStateProtoMessage{"User": {Fields: map[string]int{"id": 1, "phone": 2, "name": 3}}}},
+	})
+
+	want := map[string]int{"id": 1, "email": 2, "name": 3}
+	if len(got["User"].Fields) != len(want) {
+		t.Fatalf("mergeDDLStateProto() = %v, want fields %v", got, want)
+	}
+
+	for name, number := range want {
+		if got["User"].Fields[name] != number {
+			t.Fatalf("mergeDDLStateProto() = %v, want fields %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// repositoryFilename 是 --emit repository 写在包目录里的文件。
+const repositoryFilename = "repository.tsq.go"
+
+type repositoryTemplateData struct {
+	Package    genmodel.PackageInfo
+	Tables     []repositoryTableTemplateData
+	TSQVersion string
+	// Imports 是方法签名和托管字段用到的外部包（导入路径 → 包名）。
+	Imports map[string]string
+	// NeedsTime 表示有托管时间字段，假实现要写当前时间。
+	NeedsTime bool
+}
+
+type repositoryTableTemplateData struct {
+	*genmodel.StructInfo
+	// SQLType 是默认实现的非导出类型名。
+	SQLType string
+	PKType  string
+	PKVar   string
+	Methods []repositoryMethodTemplateData
+	Uniques []repositoryUniqueTemplateData
+}
+
+// repositoryMethodTemplateData 描述接口里的一个查询方法：Kind 为 get（GetOrErr）、list（List）
+// 或 listIn（按主键批量取，包装 ListXxxByPKInOrErr）。Match 是假实现筛选 row 的条件。
+type repositoryMethodTemplateData struct {
+	Name   string
+	Kind   string
+	Query  string
+	Doc    string
+	Params []repositoryParamTemplateData
+	Match  string
+}
+
+type repositoryParamTemplateData struct {
+	Name     string
+	Type     string
+	Variadic bool
+}
+
+// repositoryUniqueTemplateData 是假实现写入前检查的唯一索引；有 deleted_at 的表和 DDL 一样把它算进索引。
+type repositoryUniqueTemplateData struct {
+	Name  string
+	Match string
+}
+
+// buildRepositoryModels 为每个 @TABLE 生成 XxxRepository 接口、基于 tsq.SQLExecutor 的默认实现
+// 和内存假实现，全部写进一个 repository.tsq.go。
+func buildRepositoryModels(input genOutputInput) ([]generationModel, error) {
+	tables := make([]*genmodel.StructInfo, 0, len(input.list))
+	for _, s := range input.list {
+		if s == nil || s.TableMeta == nil || s.IsResult || len(s.Fields) == 0 {
+			continue
+		}
+
+		tables = append(tables, s)
+	}
+
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	slices.SortFunc(tables, func(a, b *genmodel.StructInfo) int {
+		return strings.Compare(a.TypeInfo.TypeName, b.TypeInfo.TypeName)
+	})
+
+	if err := validateRepositorySymbolCollisions(input.list, tables); err != nil {
+		return nil, err
+	}
+
+	data := repositoryTemplateData{
+		Package:    tables[0].TypeInfo.Package,
+		TSQVersion: input.version,
+		Imports:    make(map[string]string),
+	}
+
+	for _, table := range tables {
+		tableData, err := describeRepositoryTable(table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.TypeInfo.TypeName, err)
+		}
+
+		usesTime, err := collectRepositoryImports(table, data.Imports)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.TypeInfo.TypeName, err)
+		}
+
+		data.NeedsTime = data.NeedsTime || usesTime || table.CreatedAtField != "" || table.UpdatedAtField != "" || table.DeletedAtField != ""
+		data.Tables = append(data.Tables, tableData)
+	}
+
+	tpl, err := template.New("tsq_repository.go.tmpl").Funcs(funcMap()).Parse(defaultRepositoryTpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse repository template: %w", err)
+	}
+
+	return []generationModel{{
+		Data:       data,
+		Template:   tpl,
+		Filename:   filepath.Join(input.dir, repositoryFilename),
+		ErrorLabel: "repository template rendering failed",
+	}}, nil
+}
+
+func describeRepositoryTable(table *genmodel.StructInfo) (repositoryTableTemplateData, error) {
+	typeName := table.TypeInfo.TypeName
+
+	pkField, ok := table.FieldMap[table.PK]
+	if !ok {
+		return repositoryTableTemplateData{}, fmt.Errorf("primary key field %s not found", table.PK)
+	}
+
+	data := repositoryTableTemplateData{
+		StructInfo: table,
+		SQLType:    lowerInitial(typeName) + "SQLRepository",
+		PKType:     fieldType(pkField),
+		PKVar:      repositoryParamName(table.PK),
+	}
+
+	variants := []string{""}
+	if table.DeletedAtField != "" {
+		variants = append(variants, "Active")
+	}
+
+	for _, active := range variants {
+		activeCond, activeDoc := "", ""
+		if active != "" {
+			activeCond, activeDoc = "row.Active()", "active "
+		}
+
+		params := func(fields []string) ([]repositoryParamTemplateData, string) {
+			list := make([]repositoryParamTemplateData, 0, len(fields))
+			conds := []string{activeCond}
+
+			for _, f := range fields {
+				name := repositoryParamName(f)
+				list = append(list, repositoryParamTemplateData{Name: name, Type: fieldType(table.FieldMap[f])})
+				// 和 SQL 的 = 一样，NULL 参数不匹配任何行。
+				conds = append(conds, fmt.Sprintf("!isNull(%s) && compactJSON(row.%s) == compactJSON(%s)", name, f, name))
+			}
+
+			return list, repositoryMatch(conds)
+		}
+
+		data.Methods = append(data.Methods,
+			repositoryMethodTemplateData{
+				Name:   "Get" + active + "By" + table.PK,
+				Kind:   "get",
+				Query:  "Query" + active + typeName + "By" + table.PK,
+				Doc:    fmt.Sprintf("returns the %s%s with the given primary key or sql.ErrNoRows.", activeDoc, typeName),
+				Params: []repositoryParamTemplateData{{Name: data.PKVar, Type: data.PKType}},
+				Match:  repositoryMatch([]string{activeCond, fmt.Sprintf("row.%s == %s", table.PK, data.PKVar)}),
+			},
+			repositoryMethodTemplateData{
+				Name:   "List" + active + "By" + table.PK + "In",
+				Kind:   "listIn",
+				Query:  "List" + active + typeName + "By" + table.PK + "InOrErr",
+				Doc:    fmt.Sprintf("returns %s%s records in the order of the given primary keys and fails if any is missing.", activeDoc, typeName),
+				Params: []repositoryParamTemplateData{{Name: fieldSliceVarName(table.PK), Type: data.PKType, Variadic: true}},
+				Match:  repositoryMatch([]string{activeCond}),
+			},
+		)
+
+		for _, ux := range table.UxList {
+			if len(ux.Fields) == 1 && ux.Fields[0] == table.PK {
+				continue
+			}
+
+			list, match := params(ux.Fields)
+			data.Methods = append(data.Methods, repositoryMethodTemplateData{
+				Name:   "Get" + active + "By" + joinAnd(ux.Fields),
+				Kind:   "get",
+				Query:  "Query" + active + typeName + "By" + joinAnd(ux.Fields),
+				Doc:    fmt.Sprintf("returns the %s%s matching unique index %s or sql.ErrNoRows.", activeDoc, typeName, ux.Name),
+				Params: list,
+				Match:  match,
+			})
+		}
+
+		for _, idx := range table.QueryList {
+			if idx.IsSet {
+				continue
+			}
+
+			list, match := params(idx.Fields)
+			data.Methods = append(data.Methods, repositoryMethodTemplateData{
+				Name:   "List" + active + "By" + idx.Name,
+				Kind:   "list",
+				Query:  "Query" + active + typeName + "By" + idx.Name,
+				Doc:    fmt.Sprintf("returns the %s%s records matching index %s.", activeDoc, typeName, idx.SourceName),
+				Params: list,
+				Match:  match,
+			})
+		}
+
+		data.Methods = append(data.Methods, repositoryMethodTemplateData{
+			Name:  "List" + active,
+			Kind:  "list",
+			Query: "Query" + active + typeName,
+			Doc:   fmt.Sprintf("returns every %s%s record.", activeDoc, typeName),
+			Match: repositoryMatch([]string{activeCond}),
+		})
+	}
+
+	// 三种数据库的唯一索引都允许多行取 NULL：record 的任一索引字段为 NULL 时不算冲突。
+	for _, ux := range table.UxList {
+		fields := indexFieldNames(table, ux.Fields)
+		conds := make([]string, 0, 2*len(fields))
+
+		for _, f := range fields {
+			conds = append(conds, fmt.Sprintf("!isNull(record.%s)", f))
+		}
+
+		for _, f := range fields {
+			conds = append(conds, fmt.Sprintf("compactJSON(row.%s) == compactJSON(record.%s)", f, f))
+		}
+
+		data.Uniques = append(data.Uniques, repositoryUniqueTemplateData{Name: ux.Name, Match: repositoryMatch(conds)})
+	}
+
+	return data, nil
+}
+
+// repositoryParamName 在 fieldVarName 之外再避开生成方法里已经占用的 ctx、r、row、record。
+func repositoryParamName(field string) string {
+	name := fieldVarName(field)
+	if slices.Contains([]string{"ctx", "r", "row", "record"}, name) {
+		return name + "_"
+	}
+
+	return name
+}
+
+// repositoryMatch 用 && 连起非空条件，没有条件时恒为 true。
+func repositoryMatch(conds []string) string {
+	conds = slices.DeleteFunc(slices.Clone(conds), func(cond string) bool { return cond == "" })
+	if len(conds) == 0 {
+		return "true"
+	}
+
+	return strings.Join(conds, " && ")
+}
+
+// collectRepositoryImports 收集方法签名和托管字段类型引用的外部包。database/sql 和 time 用生成代码
+// 固定的别名，由模板自己导入；返回值表示签名里是否用到了 time。
+func collectRepositoryImports(table *genmodel.StructInfo, imports map[string]string) (bool, error) {
+	fields := []string{table.PK, table.CreatedAtField, table.UpdatedAtField, table.DeletedAtField}
+	for _, ux := range table.UxList {
+		fields = append(fields, ux.Fields...)
+	}
+
+	for _, idx := range table.QueryList {
+		fields = append(fields, idx.Fields...)
+	}
+
+	return collectFieldImports(table, fields, imports)
+}
+
+// collectFieldImports 收集 fields 的类型引用的外部包，database/sql 和 time 除外；返回值表示是否用到了 time。
+func collectFieldImports(table *genmodel.StructInfo, fields []string, imports map[string]string) (bool, error) {
+	usesTime := false
+
+	for _, name := range fields {
+		field, ok := table.FieldMap[name]
+		if name == "" || !ok {
+			continue
+		}
+
+		pkg := field.Type.Package
+		usesTime = usesTime || pkg.Path == importPathTime
+
+		if pkg.Path == "" || pkg.Name == "" || pkg.Path == importPathDatabaseSQL || pkg.Path == importPathTime {
+			continue
+		}
+
+		for path, alias := range imports {
+			if alias == pkg.Name && path != pkg.Path {
+				return false, fmt.Errorf("package name %s refers to both %s and %s", alias, path, pkg.Path)
+			}
+		}
+
+		imports[pkg.Path] = pkg.Name
+	}
+
+	return usesTime, nil
+}
+
+// validateRepositorySymbolCollisions 检查生成的接口和实现类型名不和包里的表、结果类型撞名。
+func validateRepositorySymbolCollisions(list, tables []*genmodel.StructInfo) error {
+	seen := make(map[string]string)
+	for _, s := range list {
+		if s != nil {
+			seen[s.TypeInfo.TypeName] = s.TypeInfo.TypeName
+		}
+	}
+
+	for _, table := range tables {
+		typeName := table.TypeInfo.TypeName
+		for _, symbol := range []string{
+			typeName + "Repository",
+			"New" + typeName + "Repository",
+			typeName + "MemoryRepository",
+			"New" + typeName + "MemoryRepository",
+			lowerInitial(typeName) + "SQLRepository",
+		} {
+			if owner, ok := seen[symbol]; ok {
+				return fmt.Errorf("generated symbol %s collides between %s and %s", symbol, owner, typeName)
+			}
+
+			seen[symbol] = typeName
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsRepositoriesWithMemoryFakes(t *testing.T) {
+	t.Cleanup(func() {
+		emitFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+
+	model := `package gentest
+
+import (
+	"database/sql"
+	"time"
+)
+
+// @TABLE(
+//	name="users",
+//	pk="ID,true",
+//	version,
+//	created_at,
+//	updated_at,
+//	deleted_at,
+//	ux=[{fields=["Email"]}, {fields=["Nickname"]}, {fields=["Handle"]}],
+//	idx=[{fields=["Team"]}],
+// )
+type User struct {
+	ID        int64     ` + "`db:\"id\" json:\"id\"`" + `
+	Email     string    ` + "`db:\"email\" json:\"email\"`" + `
+	Team      string    ` + "`db:\"team\" json:\"team\"`" + `
+	Nickname  *string   ` + "`db:\"nickname\" json:\"nickname\"`" + `
+	Handle    sql.NullString ` + "`db:\"handle\" json:\"handle\"`" + `
+	Version   int64     ` + "`db:\"version\" json:\"version\"`" + `
+	CreatedAt time.Time ` + "`db:\"created_at\" json:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`db:\"updated_at\" json:\"updated_at\"`" + `
+	DeletedAt int64     ` + "`db:\"deleted_at\" json:\"deleted_at\"`" + `
+}
+
+// @TABLE(name="tags", pk="Name,false")
+type Tag struct {
+	Name string ` + "`db:\"name\" json:\"name\"`" + `
+}
+`
+	writeTestFile(t, filepath.Join(dir, "model.go"), model)
+
+	// 直接在生成的模块里跑一遍假实现，验证它和数据库一样报唯一键冲突、乐观锁冲突并隐藏软删除的行。
+	writeTestFile(t, filepath.Join(dir, "repository_test.go"), `package gentest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/tmoeish/tsq/v4"
+)
+
+func TestUserMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+
+	var repo UserRepository = NewUserMemoryRepository()
+
+	alice := &User{Email: "alice@example.com", Team: "core"}
+	if err := repo.Insert(ctx, alice); err != nil || alice.ID != 1 || alice.CreatedAt.IsZero() {
+		t.Fatalf("Insert() = %v, record %+v", err, alice)
+	}
+
+	if err := repo.Insert(ctx, &User{Email: "alice@example.com"}); !tsq.IsDuplicateKeyError(err) {
+		t.Fatalf("expected a duplicate key error, got %v", err)
+	}
+
+	// 唯一索引允许多行 NULL，按 NULL 查找也和 SQL 的 = 一样查不到。
+	nick := "al"
+	bob := &User{Email: "bob@example.com", Nickname: &nick, Handle: sql.NullString{String: "bob", Valid: true}}
+	if err := repo.Insert(ctx, bob); err != nil {
+		t.Fatalf("expected NULL nickname and handle to be accepted twice, got %v", err)
+	}
+
+	if err := repo.Insert(ctx, &User{Email: "carol@example.com", Nickname: &nick}); !tsq.IsDuplicateKeyError(err) {
+		t.Fatalf("expected a duplicate nickname error, got %v", err)
+	}
+
+	if err := repo.Insert(ctx, &User{Email: "dave@example.com", Handle: sql.NullString{String: "bob", Valid: true}}); !tsq.IsDuplicateKeyError(err) {
+		t.Fatalf("expected a duplicate handle error, got %v", err)
+	}
+
+	if _, err := repo.GetByNickname(ctx, nil); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a NULL nickname, got %v", err)
+	}
+
+	if _, err := repo.GetByHandle(ctx, sql.NullString{}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a NULL handle, got %v", err)
+	}
+
+	if got, err := repo.GetByNickname(ctx, &nick); err != nil || got.ID != bob.ID {
+		t.Fatalf("GetByNickname() = %+v, %v", got, err)
+	}
+
+	stale := *alice
+	alice.Team = "infra"
+	if err := repo.Update(ctx, alice); err != nil || alice.Version != stale.Version+1 {
+		t.Fatalf("Update() = %v, record %+v", err, alice)
+	}
+
+	if err := repo.Update(ctx, &stale); !tsq.IsOptimisticLockError(err) {
+		t.Fatalf("expected an optimistic lock error, got %v", err)
+	}
+
+	if got, err := repo.ListByTeam(ctx, "infra"); err != nil || len(got) != 1 || got[0].Version != alice.Version {
+		t.Fatalf("ListByTeam() = %+v, %v", got, err)
+	}
+
+	if err := repo.SoftDelete(ctx, alice, 0); err != nil {
+		t.Fatalf("SoftDelete() = %v", err)
+	}
+
+	if _, err := repo.GetActiveByEmail(ctx, "alice@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a soft-deleted user, got %v", err)
+	}
+
+	if got, err := repo.ListByIDIn(ctx, alice.ID); err != nil || len(got) != 1 || got[0].DeletedAt == 0 {
+		t.Fatalf("ListByIDIn() = %+v, %v", got, err)
+	}
+
+	tags := NewTagMemoryRepository()
+	if err := tags.Insert(ctx, &Tag{Name: "go"}); err != nil {
+		t.Fatalf("Insert() = %v", err)
+	}
+
+	if err := tags.Insert(ctx, &Tag{Name: "go"}); !tsq.IsDuplicateKeyError(err) {
+		t.Fatalf("expected a duplicate primary key error, got %v", err)
+	}
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--emit", "repository", "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, repositoryFilename))
+	if err != nil {
+		t.Fatalf("expected %s: %v", repositoryFilename, err)
+	}
+
+	for _, want := range []string{
+		"type UserRepository interface {",
+		"GetActiveByEmail(ctx context.Context, email string) (*User, error)",
+		"return ListUserByIDInOrErr(ctx, r.db, iDs...)",
+		"SoftDelete(ctx context.Context, record *User, dt int64) error",
+		"func NewTagMemoryRepository() *TagMemoryRepository {",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("expected %s to contain:\n%s\ngot:\n%s", repositoryFilename, want, source)
+		}
+	}
+
+	if output, err := exec.Command("go", "test", "./...").CombinedOutput(); err != nil {
+		t.Fatalf("generated repositories do not pass: %v\n%s", err, output)
+	}
+}
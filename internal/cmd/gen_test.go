@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"text/template"
@@ -14,29 +15,14 @@ import (
 	"github.com/tmoeish/tsq/v4/internal/genmodel"
 )
 
-func TestGenArgsRejectsMissingOrExtraPackagePaths(t *testing.T) {
-	t.Cleanup(func() {
-		dryRunFlag = false
-		checkFlag = false
-	})
+func TestGenArgsRequireAtLeastOnePackagePath(t *testing.T) {
+	err := genPackageArgs(nil, nil)
+	if err == nil || err.Error() != "tsq gen expects at least one package path or pattern, got 0" {
+		t.Fatalf("unexpected arg error %v", err)
+	}
 
-	for _, tc := range []struct {
-		name string
-		args []string
-		want string
-	}{
-		{name: "missing", args: nil, want: "tsq gen expects exactly one package path, got 0"},
-		{name: "extra", args: []string{"./a", "./b"}, want: "tsq gen expects exactly one package path, got 2"},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			err := exactOnePackageArg(nil, tc.args)
-			if err == nil {
-				t.Fatal("expected exact arg validation to fail")
-			}
-			if err.Error() != tc.want {
-				t.Fatalf("unexpected arg error %q, want %q", err.Error(), tc.want)
-			}
-		})
+	if err := genPackageArgs(nil, []string{"./a", "./b/..."}); err != nil {
+		t.Fatalf("expected several paths and patterns to be accepted, got %v", err)
 	}
 }
 
@@ -193,6 +179,23 @@ type User struct {
 			t.Fatalf("expected runtime.tsq.go to contain %q, got:\n%s", want, rendered)
 		}
 	}
+
+	tableFile, err := os.ReadFile(filepath.Join(dir, "user.tsq.go"))
+	if err != nil {
+		t.Fatalf("failed to read user.tsq.go: %v", err)
+	}
+
+	rendered = string(tableFile)
+	for _, want := range []string{
+		"func (t *User) ScanDest(cols []string) ([]any, bool)",
+		`case "email":` + "\n\t\t\tdest[i] = &t.Email",
+		"func (t *User) MutationValues() []any",
+		"\t\tt.Email,\n\t\tt.ID,",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected user.tsq.go to contain %q, got:\n%s", want, rendered)
+		}
+	}
 }
 
 func TestGenCmdKeepsDeletedAtInRuntimeAndDDLIndexes(t *testing.T) {
@@ -320,7 +323,7 @@ type User struct {
 	if got := stderr.String(); !strings.Contains(got, "sqlite=sqlite.sql mysql=mysql.sql postgres=postgres.sql") {
 		t.Fatalf("expected stderr guidance to mention schema files, got:\n%s", got)
 	}
-	if got := stderr.String(); !strings.Contains(got, "ddl:\n  <users>:\n    columns:\n      add column name\n") {
+	if got := stderr.String(); !strings.Contains(got, "ddl:\n  <users>:\n    columns:\n      add column name [blocking]\n") {
 		t.Fatalf("expected stderr summary to mention actual ddl diff, got:\n%s", got)
 	}
 
@@ -385,6 +388,206 @@ type User struct {
 	}
 }
 
+func TestGenCmdRefusesDestructiveChanges(t *testing.T) {
+	t.Cleanup(func() {
+		dryRunFlag = false
+		checkFlag = false
+		allowDestructiveFlag = false
+		reportFlag = ""
+		v = false
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users")
+type User struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Name string `+"`db:\"name\"`"+`
+}
+`)
+	// The recorded schema still has an age column that the struct dropped.
+	writeMigrateTestHistory(t, dir, ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", Kind: ddlColumnString, Size: ddlDefaultStringSize},
+			{Name: "age", Kind: ddlColumnInt, Bits: 64},
+		},
+	}}})
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stderr := new(bytes.Buffer)
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(stderr)
+	GenCmd.SetArgs([]string{"--dry-run", "."})
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("dry-run GenCmd.Execute() error = %v", err)
+	}
+	if got := stderr.String(); !strings.Contains(got, "drop column age [destructive]") {
+		t.Fatalf("expected dry-run to classify the dropped column, got:\n%s", got)
+	}
+
+	dryRunFlag = false
+	reportPath := filepath.Join(dir, "ddl-report.json")
+	GenCmd.SetArgs([]string{"--report", reportPath, "."})
+	err := GenCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "refusing to record destructive schema changes") ||
+		!strings.Contains(err.Error(), "users: drop column age (drops the column and its values)") {
+		t.Fatalf("expected destructive change to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "runtime.tsq.go")); !os.IsNotExist(err) {
+		t.Fatalf("expected a refused run to write nothing, got err=%v", err)
+	}
+
+	reportBytes, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+
+	var report ddlChangeReport
+	if err := json.Unmarshal(reportBytes, &report); err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	if !report.Refused || report.Summary.Destructive != 1 || report.Package != "." {
+		t.Fatalf("unexpected report: %s", reportBytes)
+	}
+
+	reportFlag = ""
+	GenCmd.SetArgs([]string{"--allow-destructive", "."})
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() with --allow-destructive error = %v", err)
+	}
+
+	state, err := loadDDLStateFile(dir)
+	if err != nil {
+		t.Fatalf("loadDDLStateFile() error = %v", err)
+	}
+	if len(state.Records) != 1 || !slices.Contains(state.Records[0].Tables[0].Columns, "drop column age") {
+		t.Fatalf("expected the acknowledged drop to be recorded, got %#v", state.Records)
+	}
+}
+
+func TestGenCmdAcknowledgesListedDropOnce(t *testing.T) {
+	t.Cleanup(func() {
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users", allow_drop=["age"])
+type User struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Name string `+"`db:\"name\"`"+`
+}
+`)
+	writeMigrateTestHistory(t, dir, ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", Kind: ddlColumnString, Size: ddlDefaultStringSize},
+			{Name: "age", Kind: ddlColumnInt, Bits: 64},
+		},
+	}}})
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stderr := new(bytes.Buffer)
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(stderr)
+	GenCmd.SetArgs([]string{"."})
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("expected allow_drop to acknowledge the dropped column, got %v", err)
+	}
+	if strings.Contains(stderr.String(), "warning:") {
+		t.Fatalf("expected no warning while allow_drop is in use, got:\n%s", stderr)
+	}
+
+	// 记录之后这条注解再也确认不了什么，每次生成都提醒删掉。
+	stderr.Reset()
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+	if got := stderr.String(); !strings.Contains(got, "warning: users: allow_drop entry age matches no dropped column; remove it") {
+		t.Fatalf("expected a warning for the spent allow_drop entry, got:\n%s", got)
+	}
+}
+
+func TestGenCmdRebasesMergedDDLHistory(t *testing.T) {
+	t.Cleanup(func() {
+		dryRunFlag = false
+		checkFlag = false
+		rebaseFlag = false
+		v = false
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users")
+type User struct {
+	ID    int64  `+"`db:\"id\"`"+`
+	Name  string `+"`db:\"name\"`"+`
+	Age   int32  `+"`db:\"age\"`"+`
+	Email string `+"`db:\"email\"`"+`
+}
+`)
+
+	users := func(columns ...ddlSnapshotColumn) ddlSnapshot {
+		return ddlSnapshot{Tables: []ddlSnapshotTable{{
+			Name: "users",
+			Columns: append([]ddlSnapshotColumn{
+				{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+				{Name: "name", Kind: ddlColumnString, Size: ddlDefaultStringSize},
+			}, columns...),
+		}}}
+	}
+
+	// Both branches added a column to users after the same base and then merged.
+	base := writeMigrateTestHistory(t, dir, users())
+	ours := appendMigrateTestRecord(t, base, users(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}), "2026-06-01 00:00:00")
+	theirs := appendMigrateTestRecord(t, base, users(ddlSnapshotColumn{Name: "email", Kind: ddlColumnString, Size: ddlDefaultStringSize}), "2026-06-02 00:00:00")
+	writeTestFile(t, filepath.Join(dir, ddlStateFilename), string(rebaseTestConflict(t, ours, theirs)))
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stderr := new(bytes.Buffer)
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(stderr)
+	GenCmd.SetArgs([]string{"--dry-run", "."})
+	if err := GenCmd.Execute(); err == nil || !strings.Contains(err.Error(), "run tsq gen --rebase") {
+		t.Fatalf("expected a conflicted tsq.json to ask for --rebase, got %v", err)
+	}
+
+	dryRunFlag = false
+	GenCmd.SetArgs([]string{"--rebase", "."})
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() with --rebase error = %v", err)
+	}
+	if got := stderr.String(); !strings.Contains(got, "rebased the merged DDL history: kept 2026-06-01 00:00:00; re-derived 2026-06-02 00:00:00") {
+		t.Fatalf("expected rebase guidance, got:\n%s", got)
+	}
+
+	state, err := loadDDLStateFile(dir)
+	if err != nil {
+		t.Fatalf("loadDDLStateFile() error = %v", err)
+	}
+	if len(state.Records) != 2 || state.Records[0].Sequence != "2026-06-01 00:00:00" ||
+		state.Records[1].Parent != state.Records[0].ID || !slices.Contains(state.Records[1].Tables[0].Columns, "add column email") {
+		t.Fatalf("expected the replaced branch to be re-derived into one new record, got %#v", state.Records)
+	}
+	if err := validateDDLHistoryChain(state); err != nil {
+		t.Fatalf("expected a linear history after rebase, got %v", err)
+	}
+}
+
 func TestPrintDDLChangeSummary(t *testing.T) {
 	t.Run("changed", func(t *testing.T) {
 		buf := new(bytes.Buffer)
@@ -498,6 +701,43 @@ func TestPrintDDLChangeSummary(t *testing.T) {
 		}
 	})
 
+	t.Run("irreversible changes are listed", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		printDDLChangeSummary(buf, ddlArtifacts{
+			hasChange: true,
+			recordTables: []ddlStateRecordTable{
+				{Table: "new_table", Columns: []string{"drop table"}},
+			},
+			irreversible: buildDDLIrreversibleNotes(ddlChangeSet{
+				Tables: []string{"new_table"},
+				ByTable: map[string][]ddlChange{
+					"new_table": {{kind: ddlChangeDropTable, table: "new_table", oldTable: &ddlSnapshotTable{Name: "new_table"}}},
+				},
+			}),
+		})
+		if got := buf.String(); got != "ddl:\n  <new_table>:\n    drop table\n  irreversible:\n    new_table: drop table loses its rows; down recreates it empty\n" {
+			t.Fatalf("unexpected irreversible summary %q", got)
+		}
+	})
+
+	t.Run("classified changes are labelled", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		printDDLChangeSummary(buf, ddlArtifacts{
+			hasChange: true,
+			recordTables: []ddlStateRecordTable{
+				{Table: "item", Columns: []string{"add column sku", "drop column spu_name"}, Indexes: []string{"add index idx_sku"}},
+			},
+			assessments: []ddlChangeAssessment{
+				{Table: "item", Change: "add column sku", Class: ddlChangeSafe},
+				{Table: "item", Change: "drop column spu_name", Class: ddlChangeDestructive, Acknowledged: true},
+				{Table: "item", Change: "add index idx_sku", Class: ddlChangeBlocking},
+			},
+		})
+		if got := buf.String(); got != "ddl:\n  <item>:\n    columns:\n      add column sku\n      drop column spu_name [destructive, acknowledged]\n    indexes:\n      add index idx_sku [blocking]\n" {
+			t.Fatalf("unexpected classified summary %q", got)
+		}
+	})
+
 	t.Run("unchanged", func(t *testing.T) {
 		buf := new(bytes.Buffer)
 		printDDLChangeSummary(buf, ddlArtifacts{})
@@ -511,6 +751,7 @@ func TestGenCmdAppendsSQLiteRebuildDDLForTypeChange(t *testing.T) {
 	t.Cleanup(func() {
 		dryRunFlag = false
 		checkFlag = false
+		allowDestructiveFlag = false
 		v = false
 		GenCmd.SetArgs(nil)
 	})
@@ -545,9 +786,10 @@ type User struct {
 }
 `)
 
+	// int64 -> string 属于破坏性变更，需要显式放行。
 	GenCmd.SetOut(new(bytes.Buffer))
 	GenCmd.SetErr(new(bytes.Buffer))
-	GenCmd.SetArgs([]string{"."})
+	GenCmd.SetArgs([]string{"--allow-destructive", "."})
 	if err := GenCmd.Execute(); err != nil {
 		t.Fatalf("second GenCmd.Execute() error = %v", err)
 	}
@@ -1926,7 +2168,7 @@ func genTestModuleFile(t *testing.T) string {
 	return "module example.com/gentest\n\n" +
 		"go 1.24.2\n\n" +
 		"require github.com/tmoeish/tsq/v4 v4.0.2\n\n" +
-		"replace github.com/tmoeish/tsq/v4 => " + wd + "\n"
+		"replace github.com/tmoeish/tsq/v4 => " + filepath.Join(wd, "..", "..") + "\n"
 }
 
 func tidyGenTestModule(t *testing.T) {
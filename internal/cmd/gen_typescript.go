@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tmoeish/tsq/v4/internal/genmodel"
+)
+
+// typeScriptFilename 是 --emit typescript 写在包目录里的文件。
+const typeScriptFilename = "models.tsq.ts"
+
+var typeScriptIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// buildTypeScriptModels 为每个 @TABLE / @RESULT 写一个 interface，字段名取 json 标签；包内的
+// 枚举类型写成字面量联合类型，另带 PageRequest 和泛型的 PageResponse<T>。类型跟随 encoding/json
+// 实际写出的形状：64 位整数是 JSON 数字，超过 2^53 会丢精度，只有带 ,string 选项的字段是 string。
+func buildTypeScriptModels(input genOutputInput) ([]generationModel, error) {
+	var (
+		interfaces strings.Builder
+		enums      = make(map[string]jsonShape)
+	)
+
+	// 按类型名排序，输出不随解析顺序变化。
+	list := slices.SortedFunc(slices.Values(input.list), func(a, b *genmodel.StructInfo) int {
+		return strings.Compare(a.TypeInfo.TypeName, b.TypeInfo.TypeName)
+	})
+
+	for _, s := range list {
+		if s == nil || s.TableMeta == nil || len(s.Fields) == 0 {
+			continue
+		}
+
+		fields, err := describeJSONFields(s, input.resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.TypeInfo.TypeName, err)
+		}
+
+		fmt.Fprintf(&interfaces, "\nexport interface %s {\n", s.TypeInfo.TypeName)
+
+		for _, field := range fields {
+			if field.shape.enumType != "" && !field.shape.quoted {
+				enums[field.shape.enumType] = field.shape
+			}
+
+			optional := ""
+			if field.optional {
+				optional = "?"
+			}
+
+			fmt.Fprintf(&interfaces, "  %s%s: %s;\n", typeScriptPropertyName(field.name), optional, typeScriptType(field.shape))
+		}
+
+		interfaces.WriteString("}\n")
+	}
+
+	source := new(strings.Builder)
+	fmt.Fprintf(source, "%s%s. DO NOT EDIT.\n", generatedFileHeaderPrefix, input.version)
+
+	if path := genPluginPackageFor(input.list, input.dir).Path; path != "" {
+		fmt.Fprintf(source, "// Source: %s\n", path)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(enums)) {
+		literals := make([]string, 0, len(enums[name].enum))
+
+		for _, value := range enums[name].enum {
+			literal, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			literals = append(literals, string(literal))
+		}
+
+		fmt.Fprintf(source, "\n/** %s */\nexport type %s = %s;\n", strings.Join(enums[name].enumNames, ", "), name, strings.Join(literals, " | "))
+	}
+
+	source.WriteString(`
+export interface PageRequest {
+  size: number;
+  page: number;
+  order_by: string;
+  order: string;
+  keyword: string;
+}
+
+export interface PageResponse<T> extends PageRequest {
+  total: number;
+  total_page: number;
+  data: T[] | null;
+}
+`)
+
+	source.WriteString(interfaces.String())
+
+	return []generationModel{{
+		Filename: filepath.Join(input.dir, typeScriptFilename),
+		Source:   []byte(source.String()),
+	}}, nil
+}
+
+func typeScriptType(shape jsonShape) string {
+	if shape.sqlNull != "" {
+		return fmt.Sprintf("{ %s: %s; Valid: boolean }", shape.sqlNull, typeScriptType(*shape.value))
+	}
+
+	var tsType string
+
+	switch {
+	case shape.kind == "":
+		return "unknown"
+	case shape.quoted:
+		tsType = "string"
+	case shape.enumType != "":
+		tsType = shape.enumType
+	case shape.kind == ddlColumnBool:
+		tsType = "boolean"
+	case shape.kind == ddlColumnInt || shape.kind == ddlColumnFloat:
+		tsType = "number"
+	default:
+		// 字符串、时间（RFC 3339）和 base64 编码的 []byte。
+		tsType = "string"
+	}
+
+	if shape.nullable {
+		tsType += " | null"
+	}
+
+	return tsType
+}
+
+func typeScriptPropertyName(name string) string {
+	if typeScriptIdentifierPattern.MatchString(name) {
+		return name
+	}
+
+	quoted, _ := json.Marshal(name)
+
+	return string(quoted)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenCmdEmitsTypeScriptInterfaces(t *testing.T) {
+	t.Cleanup(func() {
+		emitFlag = nil
+		GenCmd.SetArgs(nil)
+	})
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelBasic Level = iota
+	LevelExpert
+)
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID       int64          `+"`db:\"id\" json:\"id\"`"+`
+	Visits   int32          `+"`db:\"visits\" json:\"visits\"`"+`
+	Balance  int64          `+"`db:\"balance\" json:\"balance,string\"`"+`
+	Level    *Level         `+"`db:\"level\" json:\"level\"`"+`
+	Nickname *string        `+"`db:\"nickname\" json:\"nickname,omitempty\"`"+`
+	Bio      sql.NullString `+"`db:\"bio\" json:\"bio\"`"+`
+	Active   bool           `+"`db:\"active\" json:\"is-active\"`"+`
+	JoinedAt time.Time      `+"`db:\"joined_at\" json:\"joined_at\"`"+`
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	GenCmd.SetOut(new(bytes.Buffer))
+	GenCmd.SetErr(new(bytes.Buffer))
+	GenCmd.SetArgs([]string{"--emit", "typescript", "."})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, typeScriptFilename))
+	if err != nil {
+		t.Fatalf("expected %s: %v", typeScriptFilename, err)
+	}
+
+	for _, want := range []string{
+		generatedFileHeaderPrefix,
+		"// Source: example.com/gentest\n",
+		"/** LevelBasic, LevelExpert */\nexport type Level = 0 | 1;\n",
+		"export interface PageResponse<T> extends PageRequest {\n  total: number;\n  total_page: number;\n  data: T[] | null;\n}\n",
+		`export interface User {
+  "is-active": boolean;
+  balance: string;
+  bio: { String: string; Valid: boolean };
+  id: number;
+  joined_at: string;
+  level: Level | null;
+  nickname?: string | null;
+  visits: number;
+}
+`,
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Fatalf("expected %s to contain:\n%s\ngot:\n%s", typeScriptFilename, want, source)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/tmoeish/tsq/v4/internal/parser"
+)
+
+// genWatchPollInterval 是 --watch 检查文件变化的间隔。用轮询而不是文件系统通知：一个包只有几十个
+// 文件，轮询足够便宜，也不受编辑器"写临时文件再改名"这类保存方式的影响。
+var genWatchPollInterval = 200 * time.Millisecond
+
+// genWatchRecordCommand 是 --watch 时确认记录 schema 变更要输入的命令。
+const genWatchRecordCommand = "record"
+
+func validateGenWatchFlags(args []string) error {
+	switch {
+	case dryRunFlag || checkFlag:
+		return errors.New("--watch cannot be used with --dry-run or --check")
+	case squashFlag || rebaseFlag:
+		return errors.New("--watch cannot be used with --squash or --rebase")
+	case len(args) > 1 || isGenPackagePattern(args[0]):
+		return errors.New("--watch works on a single package")
+	case watchDebounceFlag <= 0:
+		return fmt.Errorf("--debounce must be positive, got %s", watchDebounceFlag)
+	}
+
+	return nil
+}
+
+// genWatchFileState 用修改时间和大小判断文件是否被改过，内容哈希用来跳过只改了时间的保存。
+type genWatchFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// runGenWatch 先生成一次，然后在包的 .go 文件（不含 *.tsq.go）或 tsq.yaml 变化并稳定 --debounce
+// 之后重新生成。依赖包只在第一次加载，之后只重新检查这个包自己的文件（见 genWatchLoader）。schema 变更只打印摘要，输入 record 确认后（或带 --watch-record 时）才写进历史。
+func runGenWatch(cmd *cobra.Command, packagePath string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	dir, err := resolveDDLStateDir(packagePath)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadGenConfig(dir)
+	if err != nil {
+		return err
+	}
+
+	watched := []string{filepath.Join(dir, genConfigFilename)}
+	if config.path != "" && config.path != watched[0] {
+		watched = append(watched, config.path)
+	}
+
+	stdout, stderr := cmd.OutOrStdout(), cmd.ErrOrStderr()
+
+	loader := newGenWatchLoader(packagePath, dir)
+
+	generate := func(record bool) (held bool, err error) {
+		root, err := loader.load()
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", maybeANSI(stderr, ansiRed, "Error"), err)
+			return false, err
+		}
+
+		run := genPackageRun{
+			path:   root.PkgPath,
+			stdout: stdout,
+			stderr: stderr,
+			report: func(report ddlChangeReport) error {
+				return writeDDLChangeReport(stdout, reportFlag, report)
+			},
+			watch: true,
+			dir:   dir,
+			set:   parser.NewPackageSet([]*packages.Package{root}),
+			types: loader.typesByPath(root),
+		}
+
+		if !record && !watchRecordFlag {
+			run.holdDDLHistory = func(ddlArtifacts) { held = true }
+		}
+
+		if err := runGenPackage(run); err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", maybeANSI(stderr, ansiRed, "Error"), err)
+			return false, err
+		}
+
+		if held {
+			_, _ = fmt.Fprintf(stderr, "%s: schema changes are not recorded yet; type %q and press Enter to write them to tsq.json and the SQL files\n",
+				maybeANSI(stderr, ansiBoldCyan, "DDL"),
+				genWatchRecordCommand,
+			)
+		}
+
+		return held, nil
+	}
+
+	states, err := scanGenWatchFiles(dir, watched)
+	if err != nil {
+		return err
+	}
+
+	sources, err := hashGenWatchFiles(states)
+	if err != nil {
+		return err
+	}
+
+	pending, _ := generate(false)
+
+	if _, err := fmt.Fprintf(stderr, "watching %s for changes (Ctrl-C to stop)\n", dir); err != nil {
+		return err
+	}
+
+	input := readGenWatchInput(ctx, cmd.InOrStdin())
+
+	ticker := time.NewTicker(genWatchPollInterval)
+	defer ticker.Stop()
+
+	var changedAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-input:
+			if !ok {
+				input = nil
+				continue
+			}
+
+			if strings.TrimSpace(line) != genWatchRecordCommand {
+				continue
+			}
+
+			if !pending {
+				_, _ = fmt.Fprintf(stderr, "%s: no pending schema changes to record\n", maybeANSI(stderr, ansiBoldCyan, "DDL"))
+				continue
+			}
+
+			// 破坏性变更被拒绝时变更仍然待记录，可以加 allow_destructive 或 allow_drop 注解后再输入 record。
+			if _, err := generate(true); err == nil {
+				pending = false
+			}
+		case <-ticker.C:
+			current, err := scanGenWatchFiles(dir, watched)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "%s: %v\n", maybeANSI(stderr, ansiRed, "Error"), err)
+				continue
+			}
+
+			if !maps.Equal(current, states) {
+				states = current
+				changedAt = time.Now()
+
+				continue
+			}
+
+			if changedAt.IsZero() || time.Since(changedAt) < watchDebounceFlag {
+				continue
+			}
+
+			changedAt = time.Time{}
+
+			currentSources, err := hashGenWatchFiles(current)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "%s: %v\n", maybeANSI(stderr, ansiRed, "Error"), err)
+				continue
+			}
+
+			if maps.Equal(currentSources, sources) {
+				continue
+			}
+
+			sources = currentSources
+
+			if _, err := fmt.Fprintf(stderr, "%s regenerating %s\n", time.Now().Format(time.TimeOnly), packagePath); err != nil {
+				return err
+			}
+
+			pending, _ = generate(false)
+		}
+	}
+}
+
+// scanGenWatchFiles 返回包目录里参与生成的 .go 文件和 extra（tsq.yaml）的状态；不存在的 extra 不列出。
+func scanGenWatchFiles(dir string, extra []string) (map[string]genWatchFileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries)+len(extra))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, parser.GoFileSuffix) || strings.HasSuffix(name, parser.TSQFileSuffix) || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		names = append(names, filepath.Join(dir, name))
+	}
+
+	names = append(names, extra...)
+
+	states := make(map[string]genWatchFileState, len(names))
+
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		states[name] = genWatchFileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return states, nil
+}
+
+func hashGenWatchFiles(states map[string]genWatchFileState) (map[string][sha256.Size]byte, error) {
+	hashes := make(map[string][sha256.Size]byte, len(states))
+
+	for name := range states {
+		content, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[name] = sha256.Sum256(content)
+	}
+
+	return hashes, nil
+}
+
+// readGenWatchInput 在后台逐行读取 r，读完或出错时关闭返回的 channel。
+func readGenWatchInput(ctx context.Context, r io.Reader) <-chan string {
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return lines
+}
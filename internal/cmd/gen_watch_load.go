@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/tmoeish/tsq/v4/internal/parser"
+)
+
+// genWatchLoader 在 --watch 的多次生成之间保留加载结果。第一次用 packages.Load 加载被监听的包和它的
+// 全部依赖；之后每次只重新解析并类型检查被监听包自己的文件，依赖包直接用缓存的类型信息。被监听包
+// import 了缓存里没有的包时退回完整加载。
+type genWatchLoader struct {
+	packagePath string
+	dir         string
+
+	root *packages.Package
+	// deps 按导入路径保存第一次加载得到的所有依赖包（不含被监听的包）
+	deps map[string]*packages.Package
+	// fullLoads 是完整加载的次数
+	fullLoads int
+}
+
+func newGenWatchLoader(packagePath, dir string) *genWatchLoader {
+	return &genWatchLoader{packagePath: packagePath, dir: dir}
+}
+
+// load 返回被监听包的最新加载结果
+func (l *genWatchLoader) load() (*packages.Package, error) {
+	if l.root != nil {
+		root, ok, err := l.recheck()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			l.root = root
+			return root, nil
+		}
+	}
+
+	return l.loadAll()
+}
+
+// typesByPath 返回 runGenPackage 解析字段类型用的包表：缓存的依赖包加上 root
+func (l *genWatchLoader) typesByPath(root *packages.Package) map[string]*packages.Package {
+	byPath := maps.Clone(l.deps)
+	byPath[root.PkgPath] = root
+
+	return byPath
+}
+
+// loadAll 完整加载被监听的包和它的依赖，并刷新依赖包缓存
+func (l *genWatchLoader) loadAll() (*packages.Package, error) {
+	cfg, pattern, err := resolveDDLLoadRequest(l.packagePath, l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	l.fullLoads++
+
+	if len(pkgs) != 1 || pkgs[0].PkgPath == "" {
+		return nil, fmt.Errorf("failed to load package %s", l.packagePath)
+	}
+
+	root := pkgs[0]
+
+	deps := make(map[string]*packages.Package)
+	for _, pkg := range flattenLoadedPackages(pkgs) {
+		if pkg != root && pkg.PkgPath != "" {
+			deps[pkg.PkgPath] = pkg
+		}
+	}
+
+	l.root, l.deps = root, deps
+
+	return root, nil
+}
+
+// recheck 只重新解析并类型检查被监听包的文件。*.tsq.go 和 buildDDLGeneratedFileOverlay 一样当作空文件，
+// 上一次生成的代码不会影响这一次。ok 为 false 表示需要完整加载。
+func (l *genWatchLoader) recheck() (*packages.Package, bool, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		fileSet = token.NewFileSet()
+		goFiles []string
+		syntax  []*ast.File
+		imports = make(map[string]*packages.Package)
+	)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, parser.GoFileSuffix) || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		if match, err := build.Default.MatchFile(l.dir, name); err != nil || !match {
+			continue
+		}
+
+		path := filepath.Join(l.dir, name)
+		goFiles = append(goFiles, path)
+
+		if strings.HasSuffix(name, parser.TSQFileSuffix) {
+			continue
+		}
+
+		// 语法错误不在这里报告：和 packages.Load 一样保留能解析出来的部分，错误由之后的注解解析报告
+		file, err := goparser.ParseFile(fileSet, path, nil, goparser.ParseComments)
+		if file == nil {
+			return nil, false, err
+		}
+
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+
+			dep, ok := l.deps[importPath]
+			if !ok {
+				return nil, false, nil
+			}
+
+			imports[importPath] = dep
+		}
+
+		syntax = append(syntax, file)
+	}
+
+	if len(syntax) == 0 {
+		return nil, false, nil
+	}
+
+	config := &types.Config{
+		Importer: genWatchImporter(imports),
+		Sizes:    l.root.TypesSizes,
+		// 类型错误和 packages.Load 时一样忽略，生成只需要结构体字段的类型
+		Error: func(error) {},
+	}
+
+	if l.root.Module != nil && l.root.Module.GoVersion != "" {
+		config.GoVersion = "go" + l.root.Module.GoVersion
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+
+	typesPkg, _ := config.Check(l.root.PkgPath, fileSet, syntax, info)
+
+	return &packages.Package{
+		ID:              l.root.ID,
+		Name:            typesPkg.Name(),
+		PkgPath:         l.root.PkgPath,
+		Dir:             l.root.Dir,
+		GoFiles:         goFiles,
+		CompiledGoFiles: slices.Clone(goFiles),
+		Imports:         imports,
+		Types:           typesPkg,
+		Fset:            fileSet,
+		Syntax:          syntax,
+		TypesInfo:       info,
+		TypesSizes:      l.root.TypesSizes,
+		Module:          l.root.Module,
+	}, true, nil
+}
+
+// genWatchImporter 从缓存的依赖包里返回类型信息
+type genWatchImporter map[string]*packages.Package
+
+func (imports genWatchImporter) Import(path string) (*types.Package, error) {
+	pkg, ok := imports[path]
+	if !ok || pkg.Types == nil {
+		return nil, fmt.Errorf("package %s not loaded", path)
+	}
+
+	return pkg.Types, nil
+}
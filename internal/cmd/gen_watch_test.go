@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+)
+
+// syncBuffer 让测试读取 --watch 在另一个 goroutine 里写的输出。
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func waitForGenWatch(t *testing.T, what string, output *syncBuffer, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, output:\n%s", what, output.String())
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestValidateGenWatchFlags(t *testing.T) {
+	t.Cleanup(func() {
+		checkFlag = false
+		rebaseFlag = false
+		watchDebounceFlag = 300 * time.Millisecond
+	})
+
+	watchDebounceFlag = 300 * time.Millisecond
+
+	if err := validateGenWatchFlags([]string{"./internal/database"}); err != nil {
+		t.Fatalf("validateGenWatchFlags() error = %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		setup func()
+		want  string
+	}{
+		{"pattern", []string{"./..."}, func() {}, "single package"},
+		{"several packages", []string{"./a", "./b"}, func() {}, "single package"},
+		{"check", []string{"."}, func() { checkFlag = true }, "--dry-run or --check"},
+		{"rebase", []string{"."}, func() { checkFlag = false; rebaseFlag = true }, "--squash or --rebase"},
+		{"debounce", []string{"."}, func() { rebaseFlag = false; watchDebounceFlag = 0 }, "--debounce must be positive"},
+	} {
+		tc.setup()
+
+		if err := validateGenWatchFlags(tc.args); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected an error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestGenWatchRecordsSchemaChangesOnlyAfterConfirmation(t *testing.T) {
+	pollInterval := genWatchPollInterval
+	t.Cleanup(func() {
+		genWatchPollInterval = pollInterval
+		watchDebounceFlag = 300 * time.Millisecond
+	})
+
+	genWatchPollInterval = 10 * time.Millisecond
+	watchDebounceFlag = 50 * time.Millisecond
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID   int64  `+"`db:\"id\"`"+`
+	Name string `+"`db:\"name\"`"+`
+}
+`)
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stdin, typed := io.Pipe()
+	stderr := new(syncBuffer)
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+	cmd.SetIn(stdin)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(stderr)
+
+	done := make(chan error, 1)
+
+	go func() { done <- runGenWatch(cmd, ".") }()
+
+	t.Cleanup(func() {
+		cancel()
+		_ = typed.Close()
+
+		if err := <-done; err != nil {
+			t.Errorf("runGenWatch() error = %v", err)
+		}
+	})
+
+	generated := filepath.Join(dir, "user.tsq.go")
+	state := filepath.Join(dir, ddlStateFilename)
+
+	waitForGenWatch(t, "the initial generation", stderr, func() bool { return strings.Contains(stderr.String(), "watching ") })
+
+	if _, err := os.Stat(generated); err != nil {
+		t.Fatalf("expected %s to be generated: %v", generated, err)
+	}
+
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Fatalf("expected tsq.json to wait for confirmation, got err=%v", err)
+	}
+
+	if _, err := io.WriteString(typed, genWatchRecordCommand+"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForGenWatch(t, "tsq.json", stderr, func() bool {
+		_, err := os.Stat(state)
+		return err == nil
+	})
+
+	recorded, err := os.ReadFile(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	ID    int64  `+"`db:\"id\"`"+`
+	Name  string `+"`db:\"name\"`"+`
+	Email string `+"`db:\"email\"`"+`
+}
+`)
+
+	waitForGenWatch(t, "the regenerated Go file", stderr, func() bool {
+		source, err := os.ReadFile(generated)
+		return err == nil && strings.Contains(string(source), "email")
+	})
+	waitForGenWatch(t, "the record prompt", stderr, func() bool {
+		return strings.Count(stderr.String(), "schema changes are not recorded yet") == 2
+	})
+
+	if current, err := os.ReadFile(state); err != nil || !bytes.Equal(current, recorded) {
+		t.Fatalf("expected tsq.json to stay unchanged until confirmation, err=%v", err)
+	}
+
+	if _, err := io.WriteString(typed, genWatchRecordCommand+"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForGenWatch(t, "the second record", stderr, func() bool {
+		current, err := os.ReadFile(state)
+		return err == nil && strings.Contains(string(current), "email")
+	})
+
+	if _, err := io.WriteString(typed, genWatchRecordCommand+"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForGenWatch(t, "the nothing-to-record notice", stderr, func() bool {
+		return strings.Contains(stderr.String(), "no pending schema changes to record")
+	})
+}
+
+func TestGenWatchLoaderRechecksOnlyTheWatchedPackage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), genTestModuleFile(t))
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+import "github.com/tmoeish/tsq/v4"
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	Sort tsq.Order `+"`db:\"sort\"`"+`
+	ID   int64     `+"`db:\"id\"`"+`
+}
+`)
+	writeTestFile(t, filepath.Join(dir, "user.tsq.go"), "package gentest\n\nthis is not Go\n")
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	loader := newGenWatchLoader(".", dir)
+
+	lookupUser := func(root *packages.Package) *types.Struct {
+		t.Helper()
+
+		obj := root.Types.Scope().Lookup("User")
+		if obj == nil {
+			t.Fatalf("type User not found in %s", root.PkgPath)
+		}
+
+		return obj.Type().Underlying().(*types.Struct)
+	}
+
+	root, err := loader.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loader.fullLoads != 1 || lookupUser(root).NumFields() != 2 {
+		t.Fatalf("expected one full load with 2 User fields, got %d loads and %d fields", loader.fullLoads, lookupUser(root).NumFields())
+	}
+
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+import "github.com/tmoeish/tsq/v4"
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	Sort  tsq.Order `+"`db:\"sort\"`"+`
+	ID    int64     `+"`db:\"id\"`"+`
+	Email string    `+"`db:\"email\"`"+`
+}
+`)
+
+	root, err = loader.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loader.fullLoads != 1 {
+		t.Fatalf("expected the dependencies to stay loaded, got %d full loads", loader.fullLoads)
+	}
+
+	user := lookupUser(root)
+	if user.NumFields() != 3 || user.Field(2).Name() != "Email" || user.Field(0).Type().String() != "github.com/tmoeish/tsq/v4.Order" {
+		t.Fatalf("expected User to be re-checked against the cached tsq package, got %s", user)
+	}
+
+	if _, ok := loader.typesByPath(root)["github.com/tmoeish/tsq/v4"]; !ok {
+		t.Fatalf("expected the cached tsq package in the type table")
+	}
+
+	writeTestFile(t, filepath.Join(dir, "model.go"), `package gentest
+
+import (
+	"image/color"
+
+	"github.com/tmoeish/tsq/v4"
+)
+
+// @TABLE(name="users", pk="ID,true")
+type User struct {
+	Sort tsq.Order `+"`db:\"sort\"`"+`
+	ID   int64      `+"`db:\"id\"`"+`
+	Tint color.RGBA `+"`db:\"tint\"`"+`
+}
+`)
+
+	root, err = loader.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loader.fullLoads != 2 || lookupUser(root).Field(2).Type().String() != "image/color.RGBA" {
+		t.Fatalf("expected a new import to reload the package, got %d full loads and %s", loader.fullLoads, lookupUser(root))
+	}
+}
@@ -21,6 +21,8 @@ type generationModel struct {
 	Template   *template.Template
 	Filename   string
 	ErrorLabel string
+	// Source 非空时是插件给出的现成内容，不经过模板和格式化。
+	Source []byte
 }
 
 type generationPlanStatus string
@@ -50,6 +52,8 @@ func buildGenerationModels(
 	tableTpl *template.Template,
 	resultTpl *template.Template,
 	runtimeTpl *template.Template,
+	httpTpl *template.Template,
+	resolver *ddlTypeResolver,
 ) ([]generationModel, error) {
 	if err := validateGeneratedFilenameCollisions(list); err != nil {
 		return nil, err
@@ -97,7 +101,7 @@ func buildGenerationModels(
 		models = append(models, model)
 	}
 
-	runtimeModel, err := buildPackageRuntimeModel(list, dir, runtimeTpl)
+	runtimeModel, err := buildPackageRuntimeModel(list, dir, runtimeTpl, resolver)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +110,15 @@ func buildGenerationModels(
 		models = append(models, *runtimeModel)
 	}
 
+	httpModel, err := buildPackageHTTPModel(list, dir, httpTpl, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpModel != nil {
+		models = append(models, *httpModel)
+	}
+
 	return models, nil
 }
 
@@ -113,6 +126,7 @@ func buildPackageRuntimeModel(
 	list []*genmodel.StructInfo,
 	dir string,
 	runtimeTpl *template.Template,
+	resolver *ddlTypeResolver,
 ) (*generationModel, error) {
 	if runtimeTpl == nil {
 		return nil, nil
@@ -139,11 +153,6 @@ func buildPackageRuntimeModel(
 		return tables[i].Table < tables[j].Table
 	})
 
-	resolver, err := newDDLTypeResolver(tables[0].TypeInfo.Package.Path, dir)
-	if err != nil {
-		return nil, err
-	}
-
 	templateTables := make([]runtimeTableTemplateData, 0, len(tables))
 	for _, table := range tables {
 		schemaColumns, err := buildRuntimeSchemaColumns(table, resolver)
@@ -191,6 +200,7 @@ func buildRuntimeSchemaColumns(
 			PrimaryKey:    field.Name == table.PK,
 			AutoIncrement: field.Name == table.PK && table.AI,
 			Default:       ddlManagedDefaultClause(table, field, desc),
+			RenamedFrom:   desc.renamedFrom,
 		})
 	}
 
@@ -215,6 +225,10 @@ func summarizeGenerationModels(models []generationModel) generationStats {
 }
 
 func renderGenerationModelSource(model generationModel) ([]byte, error) {
+	if model.Source != nil {
+		return model.Source, nil
+	}
+
 	buf := new(bytes.Buffer)
 	if err := model.Template.Execute(buf, model.Data); err != nil {
 		bs := prettyJSON(model.Data)
@@ -324,7 +338,7 @@ func findStaleGeneratedFiles(dir string, plannedFiles map[string]struct{}) ([]st
 			return nil, err
 		}
 
-		if !bytes.HasPrefix(content, []byte(generatedFileHeaderPrefix)) {
+		if !isGeneratedArtifact(content) {
 			continue
 		}
 
@@ -336,8 +350,25 @@ func findStaleGeneratedFiles(dir string, plannedFiles map[string]struct{}) ([]st
 	return stale, nil
 }
 
+// isGeneratedArtifact 报告内容是否由 tsq 生成：带 Go 生成文件头，或是 DDL 产物认得的 SQL 文件头、
+// generated_by 字段。
+func isGeneratedArtifact(content []byte) bool {
+	if bytes.HasPrefix(content, []byte(generatedFileHeaderPrefix)) || isGeneratedDDLArtifact(content) {
+		return true
+	}
+
+	// OpenAPI 文档顶层只允许 x- 开头的扩展字段。
+	var meta struct {
+		GeneratedBy string `json:"x-generated-by"`
+	}
+
+	return json.Unmarshal(content, &meta) == nil && strings.HasPrefix(meta.GeneratedBy, "tsq-")
+}
+
+// isGeneratedFilename 报告文件名是否是 tsq 生成文件的 <name>.tsq.<ext> 形式，例如 user.tsq.go、
+// openapi.tsq.json。
 func isGeneratedFilename(name string) bool {
-	return strings.HasSuffix(name, ".tsq.go") || strings.HasSuffix(name, ".result.tsq.go")
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), ".tsq")
 }
 
 func generationPlanStatusFor(filename string, src []byte) (generationPlanStatus, error) {
@@ -0,0 +1,731 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/serenize/snaker"
+	"github.com/spf13/cobra"
+	"mvdan.cc/gofumpt/format"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+	"github.com/tmoeish/tsq/v4/internal/buildinfo"
+)
+
+const nullbioImportPath = "gopkg.in/nullbio/null.v6"
+
+var (
+	//go:embed introspect.go.tmpl
+	introspectTpl string
+
+	introspectDriverFlag  string
+	introspectDSNFlag     string
+	introspectOutFlag     string
+	introspectPackageFlag string
+	introspectForceFlag   bool
+	// introspectRecordBaselineFlag 让 introspect 在来源库里记下基线；默认只读。
+	introspectRecordBaselineFlag bool
+)
+
+func init() {
+	IntrospectCmd.Flags().StringVar(&introspectDriverFlag, "driver", "", "database driver: sqlite, mysql, or postgres")
+	IntrospectCmd.Flags().StringVar(&introspectDSNFlag, "dsn", "", "data source name of the database to introspect")
+	IntrospectCmd.Flags().StringVar(&introspectOutFlag, "out", "", "directory to write the Go structs and tsq.json to")
+	IntrospectCmd.Flags().StringVar(&introspectPackageFlag, "package", "", "Go package name of the generated files (default: derived from --out)")
+	IntrospectCmd.Flags().BoolVar(&introspectForceFlag, "force", false, "overwrite existing struct files and tsq.json")
+	IntrospectCmd.Flags().BoolVar(&introspectRecordBaselineFlag, "record-baseline", false,
+		"record the baseline as the applied initial migration in the introspected database")
+}
+
+// IntrospectCmd reverse-engineers @TABLE structs and a tsq.json baseline from a live database.
+var IntrospectCmd = &cobra.Command{
+	Use:   "introspect",
+	Short: "Generate @TABLE structs from an existing database",
+	Long: `Generate annotated Go structs from the tables of an existing database, and seed
+tsq.json with a baseline snapshot so that the next tsq gen records no schema change.
+
+Each table becomes one <table>.table.go file in --out with:
+  - @TABLE(name, pk, ux, idx) taken from the primary key and the indexes
+  - db tags carrying size:<n> for sized strings, and type:<SQL_TYPE> when the
+    column type cannot be expressed through the Go type
+  - nullable columns mapped to gopkg.in/nullbio/null.v6 types
+
+Limitations:
+  - tables without a single-column primary key are skipped with a warning
+  - indexes on expressions, or whose name is already used by another table,
+    are skipped with a warning
+  - column defaults and foreign keys are not carried over
+  - bookkeeping tables whose names start with _tsq_ or __tsq_rebuild_ are ignored
+
+The database is only read. Because it already matches the baseline, tsq
+migrate up against it would replay the baseline's CREATE statements; pass
+--record-baseline to also record the baseline as the applied "initial"
+migration in its _tsq_schema_migrations table, so that tsq migrate up only
+runs the changes made after introspection. With --record-baseline, write
+access is checked before any file is written, and a database that already
+has other tsq migrate history is refused.
+
+Existing struct files and tsq.json are never overwritten unless --force is set.
+The files are a starting point: rename types and fields, and add created_at,
+version, or search options afterwards, then run tsq gen.`,
+	Example: strings.Join([]string{
+		"  tsq introspect --driver sqlite --dsn ./app.db --out ./db",
+		"  tsq introspect --driver postgres --dsn \"$DATABASE_URL\" --out ./internal/database --package database",
+		"  tsq introspect --driver sqlite --dsn ./app.db --out ./db --record-baseline",
+	}, "\n"),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		return runIntrospect(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	},
+}
+
+type introspectedTable struct {
+	Package  string
+	Imports  []string
+	TypeName string
+	Table    string
+	PK       string
+	Uniques  []introspectedIndex
+	Indexes  []introspectedIndex
+	Fields   []introspectedField
+
+	snapshot ddlSnapshotTable
+}
+
+type introspectedIndex struct {
+	Name      string
+	FieldList string
+}
+
+type introspectedField struct {
+	Name   string
+	GoType string
+	DBTag  string
+}
+
+func runIntrospect(ctx context.Context, stdout, stderr io.Writer) error {
+	if introspectDriverFlag == "" {
+		return errors.New("tsq introspect requires --driver")
+	}
+
+	if introspectDSNFlag == "" {
+		return errors.New("tsq introspect requires --dsn")
+	}
+
+	if introspectOutFlag == "" {
+		return errors.New("tsq introspect requires --out")
+	}
+
+	sqlDriver, dialect, err := resolveDatabaseDriver("introspect", introspectDriverFlag)
+	if err != nil {
+		return err
+	}
+
+	packageName, err := resolveIntrospectPackageName(introspectOutFlag, introspectPackageFlag)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(sqlDriver, introspectDSNFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open %s database: %w", sqlDriver, err)
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tables, err := introspectDatabase(ctx, db, dialect, stderr)
+	if err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		return errors.New("no tables to introspect")
+	}
+
+	files := make(map[string][]byte, len(tables)+1)
+	snapshot := ddlSnapshot{Tables: make([]ddlSnapshotTable, 0, len(tables))}
+
+	for i := range tables {
+		tables[i].Package = packageName
+
+		source, err := renderIntrospectedTable(tables[i])
+		if err != nil {
+			return err
+		}
+
+		files[filepath.Join(introspectOutFlag, introspectFilename(tables[i].Table))] = source
+		snapshot.Tables = append(snapshot.Tables, tables[i].snapshot)
+	}
+
+	state, initial, err := renderIntrospectBaseline(snapshot)
+	if err != nil {
+		return err
+	}
+
+	files[filepath.Join(introspectOutFlag, ddlStateFilename)] = state
+
+	if !introspectRecordBaselineFlag {
+		if err := writeIntrospectedFiles(introspectOutFlag, files, introspectForceFlag); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(stdout, "Introspected %d table(s) into %s; run tsq gen %s next. "+
+			"The database was not changed: rerun with --record-baseline before running tsq migrate up against it.\n",
+			len(tables), introspectOutFlag, introspectOutFlag)
+
+		return err
+	}
+
+	baseline, err := openIntrospectBaseline(ctx, db, dialect, initial)
+	if err != nil {
+		return err
+	}
+
+	defer baseline.close()
+
+	if err := writeIntrospectedFiles(introspectOutFlag, files, introspectForceFlag); err != nil {
+		return err
+	}
+
+	if err := baseline.record(ctx); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Introspected %d table(s) into %s and recorded them as migration %s; run tsq gen %s next.\n",
+		len(tables), introspectOutFlag, initialMigrationSequence, introspectOutFlag)
+
+	return err
+}
+
+// introspectBaseline 把基线记成数据库里已经执行过的 initial 迁移，否则之后的 tsq migrate up
+// 会在来源库上重放 CREATE INDEX 而失败。
+type introspectBaseline struct {
+	migrator *migrator
+	step     migrationStep
+	applied  bool
+}
+
+// openIntrospectBaseline 在写文件之前检查记录表：已经有别的迁移记录的数据库不是 introspect 的对象。
+// 还没记过基线时先建好记录表，只读账号在这里就失败，不会留下写了一半的输出。
+func openIntrospectBaseline(
+	ctx context.Context,
+	db *sql.DB,
+	dialect tsqdialect.Dialect,
+	initial map[string]ddlStateDialectSQL,
+) (*introspectBaseline, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dialect.Name(), err)
+	}
+
+	step := newMigrationStep(initialMigrationSequence, initial[string(dialect.Name())].SQL)
+	baseline := &introspectBaseline{
+		migrator: &migrator{dialect: dialect, conn: conn, steps: []migrationStep{step}},
+		step:     step,
+	}
+
+	applied, err := baseline.migrator.loadApplied(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", migrationsRegistryName, err)
+	}
+
+	for sequence, record := range applied {
+		if sequence != step.Sequence || record.Checksum != step.Checksum {
+			_ = conn.Close()
+			return nil, fmt.Errorf("database already has tsq migrate history (%s applied at %s): run tsq migrate against its tsq.json instead", sequence, record.AppliedAt)
+		}
+
+		baseline.applied = true
+	}
+
+	if !baseline.applied {
+		if err := baseline.migrator.ensureRegistry(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return baseline, nil
+}
+
+func (b *introspectBaseline) record(ctx context.Context) error {
+	if b.applied {
+		return nil
+	}
+
+	return b.migrator.adopt(ctx, b.step)
+}
+
+func (b *introspectBaseline) close() {
+	_ = b.migrator.conn.Close()
+}
+
+// resolveIntrospectPackageName 默认取输出目录名，去掉 Go 标识符不允许的字符。
+func resolveIntrospectPackageName(outDir, explicit string) (string, error) {
+	if explicit != "" {
+		if !token.IsIdentifier(explicit) {
+			return "", fmt.Errorf("invalid --package %q: not a Go identifier", explicit)
+		}
+
+		return explicit, nil
+	}
+
+	abs, err := filepath.Abs(outDir)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+
+		return -1
+	}, filepath.Base(abs))
+
+	if !token.IsIdentifier(name) || token.IsKeyword(name) {
+		return "", fmt.Errorf("cannot derive a package name from %s: pass --package", outDir)
+	}
+
+	return name, nil
+}
+
+func introspectDatabase(
+	ctx context.Context,
+	db *sql.DB,
+	dialect tsqdialect.Dialect,
+	stderr io.Writer,
+) ([]introspectedTable, error) {
+	names, err := dialect.ListTables(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	sort.Strings(names)
+
+	var (
+		result     []introspectedTable
+		typeNames  = make(map[string]struct{}, len(names))
+		indexNames = make(map[string]string)
+	)
+
+	for _, name := range names {
+		if isDDLBookkeepingTable(name) {
+			continue
+		}
+
+		columns, exists, err := dialect.InspectTableColumns(ctx, db, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect table %s: %w", name, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		indexes, err := dialect.ListIndexes(ctx, db, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list indexes of table %s: %w", name, err)
+		}
+
+		table, warnings := buildIntrospectedTable(dialect, name, columns, indexes, typeNames, indexNames)
+		for _, warning := range warnings {
+			_, _ = fmt.Fprintf(stderr, "warning: table %s: %s\n", name, warning)
+		}
+
+		if table != nil {
+			result = append(result, *table)
+		}
+	}
+
+	return result, nil
+}
+
+// buildIntrospectedTable 同时产出结构体和它在 tsq gen 眼里的快照：两者由同一份推导得出，
+// 所以写进 tsq.json 的基线与下一次 tsq gen 从结构体算出的快照一致。
+func buildIntrospectedTable(
+	dialect tsqdialect.Dialect,
+	name string,
+	columns []tsqdialect.DDLColumnSpec,
+	indexes []tsqdialect.NamedIndexDefinition,
+	typeNames map[string]struct{},
+	indexNames map[string]string,
+) (*introspectedTable, []string) {
+	var primaryKeys []tsqdialect.DDLColumnSpec
+
+	for _, column := range columns {
+		if column.PrimaryKey {
+			primaryKeys = append(primaryKeys, column)
+		}
+	}
+
+	if len(primaryKeys) != 1 {
+		return nil, []string{"skipped: tsq requires a single-column primary key"}
+	}
+
+	table := &introspectedTable{
+		TypeName: uniqueIntrospectName(introspectIdentifier(name, "Table"), typeNames),
+		Table:    name,
+		snapshot: ddlSnapshotTable{Name: name},
+	}
+
+	fieldNames := make(map[string]struct{}, len(columns))
+	fieldByColumn := make(map[string]string, len(columns))
+	imports := make(map[string]struct{})
+
+	// 结构体字段保持数据库里的列序，只把主键提到最前。
+	ordered := slices.Clone(columns)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].PrimaryKey && !ordered[j].PrimaryKey
+	})
+
+	var warnings []string
+
+	for _, column := range ordered {
+		field, snapshot, importPath := introspectColumn(dialect, column)
+		if column.Type.Nullable && !snapshot.Nullable {
+			warnings = append(warnings, fmt.Sprintf("column %s is nullable, but %s fields are declared NOT NULL", column.Name, field.GoType))
+		}
+		field.Name = uniqueIntrospectName(introspectIdentifier(column.Name, "Column"), fieldNames)
+
+		if importPath != "" {
+			imports[importPath] = struct{}{}
+		}
+
+		fieldByColumn[column.Name] = field.Name
+		table.Fields = append(table.Fields, field)
+		table.snapshot.Columns = append(table.snapshot.Columns, snapshot)
+	}
+
+	// 快照的列序与 orderedDDLFields 一致：主键在前，其余按列名。
+	sort.SliceStable(table.snapshot.Columns, func(i, j int) bool {
+		left, right := table.snapshot.Columns[i], table.snapshot.Columns[j]
+		if left.PrimaryKey != right.PrimaryKey {
+			return left.PrimaryKey
+		}
+
+		return left.Name < right.Name
+	})
+
+	table.PK = fieldByColumn[primaryKeys[0].Name]
+	if !primaryKeys[0].AutoIncrement {
+		table.PK += ",false"
+	}
+
+	for path := range imports {
+		table.Imports = append(table.Imports, path)
+	}
+
+	sort.Strings(table.Imports)
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+
+	seenFields := make(map[string]string, len(indexes))
+
+	for _, idx := range indexes {
+		if idx.PrimaryKey || idx.Constraint {
+			continue
+		}
+
+		fields := make([]string, 0, len(idx.Fields))
+		for _, column := range idx.Fields {
+			if field, ok := fieldByColumn[column]; ok {
+				fields = append(fields, field)
+			}
+		}
+
+		key := strings.Join(fields, ",")
+
+		switch {
+		case len(fields) == 0 || len(fields) != len(idx.Fields):
+			warnings = append(warnings, fmt.Sprintf("skipped index %s: it does not cover plain columns only", idx.Name))
+			continue
+		case indexNames[idx.Name] != "":
+			warnings = append(warnings, fmt.Sprintf("skipped index %s: the name is already used by table %s", idx.Name, indexNames[idx.Name]))
+			continue
+		case seenFields[key] != "":
+			warnings = append(warnings, fmt.Sprintf("skipped index %s: it duplicates index %s", idx.Name, seenFields[key]))
+			continue
+		}
+
+		indexNames[idx.Name] = name
+		seenFields[key] = idx.Name
+
+		item := introspectedIndex{Name: idx.Name, FieldList: `"` + strings.Join(fields, `", "`) + `"`}
+		if idx.Unique {
+			table.Uniques = append(table.Uniques, item)
+		} else {
+			table.Indexes = append(table.Indexes, item)
+		}
+
+		table.snapshot.Indexes = append(table.snapshot.Indexes, ddlSnapshotIndex{
+			Name:   idx.Name,
+			Fields: slices.Clone(idx.Fields),
+			Unique: idx.Unique,
+		})
+	}
+
+	return table, warnings
+}
+
+// introspectColumn 先按列类型选 Go 类型；选出的类型在当前方言下渲染不回原列类型时
+// （例如 TEXT、DECIMAL(10,2)），再用 type:<原生类型> 覆盖。
+func introspectColumn(dialect tsqdialect.Dialect, live tsqdialect.DDLColumnSpec) (introspectedField, ddlSnapshotColumn, string) {
+	column := ddlSnapshotColumn{
+		Name:          live.Name,
+		Kind:          ddlColumnKind(live.Type.Kind),
+		Bits:          live.Type.Bits,
+		Unsigned:      live.Type.Unsigned,
+		Nullable:      live.Type.Nullable,
+		RawType:       live.Type.RawType,
+		PrimaryKey:    live.PrimaryKey,
+		AutoIncrement: live.AutoIncrement,
+	}
+
+	tagSize := 0
+
+	switch column.Kind {
+	case ddlColumnInt:
+		column.Bits = introspectIntegerBits(column.Bits)
+	case ddlColumnFloat:
+		if column.Bits != 32 {
+			column.Bits = 64
+		}
+	case ddlColumnString:
+		tagSize = live.Type.Size
+	case ddlColumnBytes:
+		// tsq 没有可空的字节切片类型，[]byte 字段总是 NOT NULL。
+		column.Nullable = false
+	case ddlColumnBool, ddlColumnTime:
+	default:
+		// 没有对应 kind 的列按字符串读写，类型完全交给 type:。
+		column.Kind = ddlColumnString
+		column.Bits, column.Unsigned = 0, false
+
+		if column.RawType == "" {
+			column.RawType = live.NativeType
+		}
+	}
+
+	if column.Kind == ddlColumnString {
+		column.Size = normalizeDDLStringSize(tagSize, 0)
+	}
+
+	if column.RawType == "" && live.NativeType != "" &&
+		(introspectKeepsNativeType(live) || !tsqdialect.DDLColumnTypesEquivalent(dialect, live, ddlColumnSpecFromSnapshot(column))) {
+		column.RawType = live.NativeType
+	}
+
+	tag := []string{live.Name}
+	if tagSize > 0 {
+		tag = append(tag, "size:"+strconv.Itoa(tagSize))
+	}
+
+	if column.RawType != "" {
+		tag = append(tag, "type:"+column.RawType)
+	}
+
+	goType, importPath := introspectGoType(column)
+
+	return introspectedField{GoType: goType, DBTag: strings.Join(tag, ",")}, column, importPath
+}
+
+// introspectKeepsNativeType 列出检查时被方言折叠、但按 kind 重新渲染会改变语义的类型：
+// 不定长文本会变成 VARCHAR(255)，定点小数会变成浮点数。
+func introspectKeepsNativeType(live tsqdialect.DDLColumnSpec) bool {
+	base, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(live.NativeType)), "(")
+
+	switch strings.TrimSpace(base) {
+	case "DECIMAL", "NUMERIC":
+		return true
+	default:
+		return live.Type.Kind == tsqdialect.DDLColumnKindString && live.Type.Size == 0
+	}
+}
+
+func introspectIntegerBits(bits int) int {
+	switch bits {
+	case 8, 16, 32:
+		return bits
+	default:
+		return 64
+	}
+}
+
+// introspectGoType 是 classifyDDLColumnType 的逆映射：返回的类型经 tsq gen 推导后
+// 得到同样的 kind、位宽、符号和可空性。
+func introspectGoType(column ddlSnapshotColumn) (string, string) {
+	var base, nullable string
+
+	switch column.Kind {
+	case ddlColumnBool:
+		base, nullable = "bool", "Bool"
+	case ddlColumnBytes:
+		return "[]byte", ""
+	case ddlColumnFloat:
+		base = "float" + strconv.Itoa(column.Bits)
+		nullable = "Float" + strconv.Itoa(column.Bits)
+	case ddlColumnInt:
+		base = "int" + strconv.Itoa(column.Bits)
+		nullable = "Int" + strconv.Itoa(column.Bits)
+
+		if column.Unsigned {
+			base, nullable = "u"+base, "U"+strings.ToLower(nullable)
+		}
+	case ddlColumnTime:
+		if column.Nullable {
+			return "null.Time", nullbioImportPath
+		}
+
+		return "time.Time", importPathTime
+	default:
+		base, nullable = "string", "String"
+	}
+
+	if column.Nullable {
+		return "null." + nullable, nullbioImportPath
+	}
+
+	return base, ""
+}
+
+// introspectIdentifier 把表名或列名转成导出的 Go 标识符；转不出时用 fallback 加序号兜底。
+func introspectIdentifier(name, fallback string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	identifier := snaker.SnakeToCamel(strings.ToLower(strings.Trim(cleaned, "_")))
+	if identifier == "" {
+		return fallback
+	}
+
+	if !unicode.IsLetter(rune(identifier[0])) {
+		identifier = fallback + identifier
+	}
+
+	return identifier
+}
+
+func uniqueIntrospectName(name string, used map[string]struct{}) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = struct{}{}
+			return candidate
+		}
+
+		candidate = name + strconv.Itoa(i)
+	}
+}
+
+func introspectFilename(table string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return unicode.ToLower(r)
+		}
+
+		return '_'
+	}, table)
+
+	// 避开 go build 按后缀识别的 _test.go 和 _<GOOS>.go 之类的文件名。
+	return name + ".table.go"
+}
+
+func renderIntrospectedTable(table introspectedTable) ([]byte, error) {
+	tpl, err := template.New("introspect").Parse(introspectTpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse introspect template: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, table); err != nil {
+		return nil, fmt.Errorf("failed to render struct for table %s: %w", table.Table, err)
+	}
+
+	src, err := format.Source(buf.Bytes(), format.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("go code formatting failed for table %s: %w", table.Table, err)
+	}
+
+	return src, nil
+}
+
+// renderIntrospectBaseline 写出与首次 tsq gen 相同形状的 tsq.json：只有快照和初始 SQL，没有历史记录。
+func renderIntrospectBaseline(snapshot ddlSnapshot) ([]byte, map[string]ddlStateDialectSQL, error) {
+	version := stableVersion(buildinfo.Version())
+
+	initial := make(map[string]ddlStateDialectSQL, len(ddlDialects))
+	for _, dialect := range ddlDialects {
+		initial[ddlDialectName(dialect)] = ddlStateDialectSQL{
+			SQL: string(renderDDLSnapshotAggregateFile(version, snapshot, dialect)),
+		}
+	}
+
+	state, err := marshalDDLStateFile(version, nil, snapshot, initial, 0, nil, nil)
+
+	return state, initial, err
+}
+
+func writeIntrospectedFiles(outDir string, files map[string][]byte, force bool) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if !force {
+		var existing []string
+
+		for _, name := range names {
+			if _, err := os.Stat(name); err == nil {
+				existing = append(existing, "  "+name)
+			}
+		}
+
+		if len(existing) > 0 {
+			return fmt.Errorf("refusing to overwrite existing files:\n%s\nrerun with --force to replace them", strings.Join(existing, "\n"))
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %s: %w", outDir, err)
+	}
+
+	for _, name := range names {
+		if err := os.WriteFile(name, files[name], 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
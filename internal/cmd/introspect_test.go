@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func runIntrospectCmd(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+
+	reset := func() {
+		introspectDriverFlag = ""
+		introspectDSNFlag = ""
+		introspectOutFlag = ""
+		introspectPackageFlag = ""
+		introspectForceFlag = false
+		introspectRecordBaselineFlag = false
+		IntrospectCmd.SetArgs(nil)
+	}
+
+	reset()
+	t.Cleanup(reset)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	IntrospectCmd.SetOut(stdout)
+	IntrospectCmd.SetErr(stderr)
+	IntrospectCmd.SetArgs(args)
+
+	err := IntrospectCmd.Execute()
+
+	return stdout.String(), stderr.String(), err
+}
+
+func seedIntrospectTestDB(t *testing.T, dsn string) {
+	t.Helper()
+
+	db := openMigrateTestDB(t, dsn)
+	for _, statement := range []string{
+		`CREATE TABLE accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email VARCHAR(160) NOT NULL,
+			nickname TEXT,
+			balance REAL NOT NULL,
+			active BOOLEAN NOT NULL,
+			last_seen_at DATETIME,
+			price DECIMAL(10,2),
+			user_id INTEGER NOT NULL
+		)`,
+		`CREATE UNIQUE INDEX ux_accounts_email ON accounts (email)`,
+		`CREATE INDEX idx_accounts_user ON accounts (user_id, nickname)`,
+		`CREATE TABLE tags (code VARCHAR(16) PRIMARY KEY, label VARCHAR(64) NOT NULL)`,
+		`CREATE TABLE pairs (a INTEGER, b INTEGER, PRIMARY KEY (a, b))`,
+		`CREATE TABLE _tsq_managed_tables (name TEXT PRIMARY KEY)`,
+	} {
+		if _, err := db.ExecContext(context.Background(), statement); err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+}
+
+func TestIntrospectCmdRequiresDriverDSNAndOut(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, err := runIntrospectCmd(t, "--dsn", "x", "--out", dir); err == nil || !strings.Contains(err.Error(), "requires --driver") {
+		t.Fatalf("expected missing driver error, got %v", err)
+	}
+
+	if _, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", "x"); err == nil || !strings.Contains(err.Error(), "requires --out") {
+		t.Fatalf("expected missing out error, got %v", err)
+	}
+
+	if _, _, err := runIntrospectCmd(t, "--driver", "oracle", "--dsn", "x", "--out", dir); err == nil || !strings.Contains(err.Error(), "unsupported introspect driver") {
+		t.Fatalf("expected unsupported driver error, got %v", err)
+	}
+}
+
+func TestIntrospectCmdWritesStructsAndBaseline(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	out := filepath.Join(dir, "db")
+	seedIntrospectTestDB(t, dsn)
+
+	_, stderr, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", dsn, "--out", out)
+	if err != nil {
+		t.Fatalf("introspect error = %v", err)
+	}
+
+	if !strings.Contains(stderr, "table pairs: skipped: tsq requires a single-column primary key") {
+		t.Fatalf("expected a warning for the composite primary key, got:\n%s", stderr)
+	}
+
+	content, err := os.ReadFile(filepath.Join(out, "accounts.table.go"))
+	if err != nil {
+		t.Fatalf("read accounts.table.go: %v", err)
+	}
+
+	for _, want := range []string{
+		"package db",
+		`import "gopkg.in/nullbio/null.v6"`,
+		`//	pk="ID",`,
+		`//		{name="ux_accounts_email", fields=["Email"]},`,
+		`//		{name="idx_accounts_user", fields=["UserID", "Nickname"]},`,
+		"ID         int64       `db:\"id\"`",
+		"Email      string      `db:\"email,size:160\"`",
+		"Nickname   null.String `db:\"nickname,type:TEXT\"`",
+		"Balance    float64     `db:\"balance\"`",
+		"LastSeenAt null.Time   `db:\"last_seen_at\"`",
+		"Price      null.String `db:\"price,type:DECIMAL(10,2)\"`",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("expected accounts.table.go to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	tags, err := os.ReadFile(filepath.Join(out, "tags.table.go"))
+	if err != nil || !strings.Contains(string(tags), `//	pk="Code,false",`) {
+		t.Fatalf("expected tags to keep a manual primary key, got %v:\n%s", err, tags)
+	}
+
+	for _, name := range []string{"pairs.table.go", "_tsq_managed_tables.table.go"} {
+		if _, err := os.Stat(filepath.Join(out, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be skipped, got err=%v", name, err)
+		}
+	}
+
+	state, err := loadDDLStateFile(out)
+	if err != nil || state == nil {
+		t.Fatalf("expected a tsq.json baseline, got %v", err)
+	}
+
+	if len(state.Records) != 0 || state.InitialDialects["sqlite"].SQL == "" {
+		t.Fatalf("expected an initial-only baseline, got %+v", state)
+	}
+
+	want := []ddlSnapshotColumn{
+		{Name: "code", Kind: ddlColumnString, Size: 16, PrimaryKey: true},
+		{Name: "label", Kind: ddlColumnString, Size: 64},
+	}
+	if got := state.Snapshot.Tables[1].Columns; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tags snapshot = %+v, want %+v", got, want)
+	}
+
+	if _, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", dsn, "--out", out); err == nil || !strings.Contains(err.Error(), "refusing to overwrite existing files") {
+		t.Fatalf("expected a second run to refuse overwriting, got %v", err)
+	}
+
+	if _, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", dsn, "--out", out, "--force"); err != nil {
+		t.Fatalf("expected --force to overwrite, got %v", err)
+	}
+
+	if diff, err := runDiff(t, "--driver", "sqlite", "--dsn", dsn, out); err == nil || strings.Contains(diff, "accounts") {
+		t.Fatalf("expected only the skipped table to drift, got %v:\n%s", err, diff)
+	}
+}
+
+func TestIntrospectBaselineMatchesNextGen(t *testing.T) {
+	t.Cleanup(func() {
+		dryRunFlag = false
+		checkFlag = false
+		v = false
+		GenCmd.SetArgs(nil)
+	})
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("resolve repo root: %v", err)
+	}
+
+	// 生成的结构体引用 null.v6，模块需要真正指向仓库根目录才能解析到它。
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "go.mod"), "module example.com/introspecttest\n\n"+
+		"go 1.24.2\n\n"+
+		"require github.com/tmoeish/tsq/v4 v4.0.2\n\n"+
+		"replace github.com/tmoeish/tsq/v4 => "+root+"\n")
+	seedIntrospectTestDB(t, filepath.Join(dir, "app.db"))
+
+	if _, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", filepath.Join(dir, "app.db"), "--out", filepath.Join(dir, "db"), "--record-baseline"); err != nil {
+		t.Fatalf("introspect error = %v", err)
+	}
+
+	baseline, err := os.ReadFile(filepath.Join(dir, "db", ddlStateFilename))
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+
+	chdirForGenTest(t, dir)
+	tidyGenTestModule(t)
+
+	stdout := new(bytes.Buffer)
+	GenCmd.SetOut(stdout)
+	GenCmd.SetErr(stdout)
+	GenCmd.SetArgs([]string{"./db"})
+
+	if err := GenCmd.Execute(); err != nil {
+		t.Fatalf("GenCmd.Execute() error = %v\n%s", err, stdout.String())
+	}
+
+	after, err := os.ReadFile(filepath.Join(dir, "db", ddlStateFilename))
+	if err != nil {
+		t.Fatalf("read tsq.json: %v", err)
+	}
+
+	if !bytes.Equal(baseline, after) {
+		t.Fatalf("expected tsq gen to keep the introspected baseline, got:\n%s", after)
+	}
+
+	// 来源库已经是基线的样子，up 不能再执行 initial 里的 CREATE INDEX。
+	out, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", filepath.Join(dir, "app.db"), "./db")
+	if err != nil || !strings.Contains(out, "database is up to date") {
+		t.Fatalf("expected migrate up on the introspected database to find nothing to do, got %q, %v", out, err)
+	}
+}
+
+func TestIntrospectCmdRefusesMigratedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	seedIntrospectTestDB(t, dsn)
+
+	args := []string{"--driver", "sqlite", "--dsn", dsn, "--out", filepath.Join(dir, "db"), "--record-baseline"}
+	if _, _, err := runIntrospectCmd(t, args...); err != nil {
+		t.Fatalf("introspect error = %v", err)
+	}
+
+	// 同一个库重新 introspect 时基线不变，已有的 initial 记录照旧有效。
+	if _, _, err := runIntrospectCmd(t, append(args, "--force")...); err != nil {
+		t.Fatalf("introspect --force on the same database error = %v", err)
+	}
+
+	if _, err := openMigrateTestDB(t, dsn).ExecContext(context.Background(), `ALTER TABLE tags ADD COLUMN note TEXT`); err != nil {
+		t.Fatalf("alter tags: %v", err)
+	}
+
+	if _, _, err := runIntrospectCmd(t, append(args, "--force")...); err == nil || !strings.Contains(err.Error(), "already has tsq migrate history") {
+		t.Fatalf("expected a changed database with a recorded baseline to be refused, got %v", err)
+	}
+}
+
+func TestIntrospectCmdLeavesDatabaseReadOnlyByDefault(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	seedIntrospectTestDB(t, dsn)
+
+	readOnly := "file:" + dsn + "?mode=ro"
+
+	stdout, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", readOnly, "--out", filepath.Join(dir, "db"))
+	if err != nil || !strings.Contains(stdout, "rerun with --record-baseline") {
+		t.Fatalf("expected introspect to work with read-only access, got %q, %v", stdout, err)
+	}
+
+	var count int
+	if err := openMigrateTestDB(t, dsn).QueryRowContext(context.Background(),
+		`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, migrationsRegistryName).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("expected no %s table, got %d, %v", migrationsRegistryName, count, err)
+	}
+
+	// 记录基线需要写权限，检查在写任何文件之前。
+	out := filepath.Join(dir, "recorded")
+	if _, _, err := runIntrospectCmd(t, "--driver", "sqlite", "--dsn", readOnly, "--out", out, "--record-baseline"); err == nil {
+		t.Fatal("expected --record-baseline to fail on a read-only database")
+	}
+
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("expected no files to be written, got err=%v", err)
+	}
+}
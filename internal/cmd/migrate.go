@@ -0,0 +1,942 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
+	_ "modernc.org/sqlite"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+	"github.com/tmoeish/tsq/v4/internal/parser"
+)
+
+const (
+	migrationsRegistryName          = "_tsq_schema_migrations"
+	migrationLockTableName          = "_tsq_migration_lock"
+	migrationLockName               = "tsq_migrate"
+	migrationLockKey          int64 = 8_391_175_185_376_437_099 // "tsq_lock" 的大端字节，用作 postgres advisory lock 键。
+	migrationLockPollInterval       = 200 * time.Millisecond
+	initialMigrationSequence        = "initial"
+	squashSequencePrefix            = "squash "
+)
+
+const (
+	migrationStatusApplied  = "applied"
+	migrationStatusPending  = "pending"
+	migrationStatusModified = "modified"
+	migrationStatusUnknown  = "unknown"
+	migrationStatusSquashed = "squashed"
+)
+
+var (
+	migrateDriverFlag      string
+	migrateDSNFlag         string
+	migrateLockTimeoutFlag time.Duration
+
+	migrateDownToFlag            string
+	migrateAllowIrreversibleFlag bool
+)
+
+func init() {
+	MigrateCmd.PersistentFlags().StringVar(&migrateDriverFlag, "driver", "", "database driver: sqlite, mysql, or postgres")
+	MigrateCmd.PersistentFlags().StringVar(&migrateDSNFlag, "dsn", "", "data source name of the target database")
+	MigrateCmd.PersistentFlags().DurationVar(&migrateLockTimeoutFlag, "lock-timeout", time.Minute, "how long up waits for another migration to release the lock")
+
+	migrateDownCmd.Flags().StringVar(&migrateDownToFlag, "to", "", "revert every applied step after this migration; use initial to keep only the initial schema")
+	migrateDownCmd.Flags().BoolVar(&migrateAllowIrreversibleFlag, "allow-irreversible", false, "revert steps whose forward DDL dropped data")
+	_ = migrateDownCmd.MarkFlagRequired("to")
+
+	MigrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migratePlanCmd)
+}
+
+// MigrateCmd applies the DDL history recorded in tsq.json to a live database.
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate <up|status|plan> <package-or-dir>",
+	Short: "Apply the generated DDL history to a database",
+	Long: `Apply the DDL history that tsq gen records in tsq.json to a live database.
+
+Each migration step is either the initial schema or one dated history record,
+rendered for the dialect selected by --driver. Applied steps are tracked in the
+_tsq_schema_migrations table together with a checksum of their statements.
+
+Subcommands:
+  - up:     apply pending steps in history order
+  - down:   revert applied steps after --to, newest first, using the inverse
+            DDL that tsq gen stores with each history record
+  - status: list applied, pending, and modified steps
+  - plan:   print the SQL that up would run
+
+Execution behavior:
+  - up holds a migration lock so concurrent deploys apply each step once
+    (advisory locks on mysql and postgres, a lock row on sqlite)
+  - sqlite and postgres run each step in one transaction
+  - mysql commits DDL implicitly, so a failed step may be partially applied
+  - up and plan refuse to continue when an applied step no longer matches
+    the history, for example after editing tsq.json by hand
+  - down restores dropped tables and columns without their data, so it
+    refuses such steps unless --allow-irreversible is set
+  - after tsq gen --squash, a database that already ran every folded step
+    records the squashed initial schema without executing it`,
+	Example: strings.Join([]string{
+		"  tsq migrate status --driver sqlite --dsn ./app.db ./examples/academy",
+		"  tsq migrate plan --driver postgres --dsn \"$DATABASE_URL\" ./internal/database",
+		"  tsq migrate up --driver mysql --dsn \"user:pass@tcp(localhost:3306)/app\" ./internal/database",
+		"  tsq migrate down --to \"2026-05-29 11:20:41\" --driver sqlite --dsn ./app.db ./examples/academy",
+	}, "\n"),
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up <package-or-dir>",
+	Short: "Apply pending migration steps",
+	Args:  exactOnePackageArgFor("migrate up"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, args[0], func(ctx context.Context, m *migrator) error {
+			return m.up(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), migrateLockTimeoutFlag)
+		})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down <package-or-dir>",
+	Short: "Revert applied migration steps after --to",
+	Args:  exactOnePackageArgFor("migrate down"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, args[0], func(ctx context.Context, m *migrator) error {
+			return m.down(ctx, cmd.OutOrStdout(), migrateDownToFlag, migrateAllowIrreversibleFlag, migrateLockTimeoutFlag)
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status <package-or-dir>",
+	Short: "List applied and pending migration steps",
+	Args:  exactOnePackageArgFor("migrate status"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, args[0], func(ctx context.Context, m *migrator) error {
+			return m.status(ctx, cmd.OutOrStdout())
+		})
+	},
+}
+
+var migratePlanCmd = &cobra.Command{
+	Use:   "plan <package-or-dir>",
+	Short: "Print the SQL of pending migration steps",
+	Args:  exactOnePackageArgFor("migrate plan"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, args[0], func(ctx context.Context, m *migrator) error {
+			return m.plan(ctx, cmd.OutOrStdout())
+		})
+	},
+}
+
+// migrationStep 是迁移的最小执行单位：初始 schema 或一条历史记录。
+type migrationStep struct {
+	Sequence   string
+	SQL        string   // tsq.json 中记录的原始 DDL，含注释。
+	Statements []string // 去掉注释和事务控制语句后的可执行语句。
+	Checksum   string
+
+	// 逆向 DDL；初始 schema 和早于逆向 DDL 的历史记录没有。
+	HasDown        bool
+	DownStatements []string
+	Irreversible   []string
+}
+
+type appliedMigration struct {
+	Sequence  string
+	Checksum  string
+	AppliedAt string
+
+	// adopted 表示这是压缩后的初始 schema：数据库执行过被压缩的全部步骤，但还没有补记这一行。
+	adopted bool
+}
+
+type migrationStepStatus struct {
+	Sequence  string
+	Status    string
+	AppliedAt string
+}
+
+type migrator struct {
+	dialect tsqdialect.Dialect
+	db      *sql.DB
+	conn    *sql.Conn
+	steps   []migrationStep
+	squash  *ddlStateSquash
+}
+
+func runMigration(cmd *cobra.Command, packagePath string, run func(context.Context, *migrator) error) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	m, err := openMigrator(ctx, packagePath, migrateDriverFlag, migrateDSNFlag)
+	if err != nil {
+		return err
+	}
+
+	defer m.close()
+
+	return run(ctx, m)
+}
+
+func openMigrator(ctx context.Context, packagePath, driverName, dsn string) (*migrator, error) {
+	if driverName == "" {
+		return nil, errors.New("tsq migrate requires --driver")
+	}
+
+	if dsn == "" {
+		return nil, errors.New("tsq migrate requires --dsn")
+	}
+
+	sqlDriver, dialect, err := resolveDatabaseDriver("migrate", driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := resolveDDLHistoryDir(packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, squash, err := loadMigrationSteps(dir, string(dialect.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(sqlDriver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database"+": %w", sqlDriver, err)
+	}
+
+	// 锁、记录表和迁移语句共用一条连接：mysql / postgres 的 advisory lock 属于会话。
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database"+": %w", sqlDriver, err)
+	}
+
+	return &migrator{dialect: dialect, db: db, conn: conn, steps: steps, squash: squash}, nil
+}
+
+func (m *migrator) close() {
+	_ = m.conn.Close()
+	_ = m.db.Close()
+}
+
+func resolveDatabaseDriver(command, driverName string) (string, tsqdialect.Dialect, error) {
+	switch strings.ToLower(driverName) {
+	case "sqlite", "sqlite3":
+		return "sqlite", tsqdialect.SQLiteDialect{}, nil
+	case "mysql":
+		return "mysql", tsqdialect.MySQLDialect{}, nil
+	case "postgres", "postgresql", "pq":
+		return "postgres", tsqdialect.PostgresDialect{}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported %s driver %q: use sqlite, mysql, or postgres", command, driverName)
+	}
+}
+
+// resolveDDLStateDir 优先把参数当作目录，这样只有 tsq.json 的目录也能迁移或比对；
+// 否则按 Go 包路径解析。
+func resolveDDLStateDir(packagePath string) (string, error) {
+	if info, err := os.Stat(packagePath); err == nil && info.IsDir() {
+		return packagePath, nil
+	}
+
+	return parser.PackageDir(packagePath)
+}
+
+// resolveDDLHistoryDir 找到 tsq.json 所在目录：参数目录里没有 tsq.json 时，按包适用的 tsq.yaml
+// 换到 ddl.dir。
+func resolveDDLHistoryDir(packagePath string) (string, error) {
+	dir, err := resolveDDLStateDir(packagePath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ddlStateFilename)); err == nil {
+		return dir, nil
+	}
+
+	config, err := loadGenConfig(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return config.ddlDir(dir), nil
+}
+
+func loadMigrationSteps(dir, dialectName string) ([]migrationStep, *ddlStateSquash, error) {
+	state, err := loadDDLStateFile(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if state == nil {
+		return nil, nil, fmt.Errorf("no DDL history found in %s: run tsq gen first", dir)
+	}
+
+	initial, ok := state.InitialDialects[dialectName]
+	if !ok || strings.TrimSpace(initial.SQL) == "" {
+		return nil, nil, fmt.Errorf("DDL history in %s has no initial schema for dialect %s", dir, dialectName)
+	}
+
+	// 压缩后的初始 schema 换一个迁移名，不会和数据库里旧的 initial 记录比对校验和。
+	baseline := initialMigrationSequence
+	if state.Squash != nil {
+		baseline = state.Squash.Sequence
+	}
+
+	steps := []migrationStep{newMigrationStep(baseline, initial.SQL)}
+
+	// 与 sqlite.sql 等聚合文件一致：RenderedRecords 之前的记录已并入初始 SQL。
+	for _, record := range state.Records[state.RenderedRecords:] {
+		diff := record.Dialects[dialectName]
+
+		step := newMigrationStep(record.Sequence, diff.AggregateSQL)
+		step.HasDown = strings.TrimSpace(diff.DownSQL) != ""
+		step.DownStatements = executableDDLStatements(diff.DownSQL)
+		step.Irreversible = record.Irreversible
+
+		if len(diff.ManualDown) > 0 {
+			step.Irreversible = append(slices.Clone(step.Irreversible), "down SQL requires a manual change on "+dialectName+": "+strings.Join(diff.ManualDown, ", "))
+		}
+
+		steps = append(steps, step)
+	}
+
+	seen := make(map[string]struct{}, len(steps))
+	for _, step := range steps {
+		if _, ok := seen[step.Sequence]; ok {
+			return nil, nil, fmt.Errorf("DDL history in %s repeats migration %s", dir, step.Sequence)
+		}
+
+		seen[step.Sequence] = struct{}{}
+	}
+
+	return steps, state.Squash, nil
+}
+
+func newMigrationStep(sequence, sqlText string) migrationStep {
+	statements := executableDDLStatements(sqlText)
+	sum := sha256.Sum256([]byte(strings.Join(statements, ";\n")))
+
+	return migrationStep{
+		Sequence:   sequence,
+		SQL:        sqlText,
+		Statements: statements,
+		Checksum:   hex.EncodeToString(sum[:]),
+	}
+}
+
+func executableDDLStatements(sqlText string) []string {
+	statements := make([]string, 0)
+	for _, statement := range splitDDLStatements(sqlText) {
+		if !isTransactionControlStatement(statement) {
+			statements = append(statements, statement)
+		}
+	}
+
+	return statements
+}
+
+// splitDDLStatements 按分号拆分 DDL，跳过注释以及引号内的分号。
+// 输入是 tsq gen 生成的 DDL，不需要处理存储过程之类的语法。
+func splitDDLStatements(sqlText string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
+	)
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+
+		current.Reset()
+	}
+
+	for i := 0; i < len(sqlText); i++ {
+		ch := sqlText[i]
+
+		switch {
+		case quote != 0:
+			current.WriteByte(ch)
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			current.WriteByte(ch)
+		case ch == '-' && i+1 < len(sqlText) && sqlText[i+1] == '-':
+			for i < len(sqlText) && sqlText[i] != '\n' {
+				i++
+			}
+
+			current.WriteByte('\n')
+		case ch == '/' && i+1 < len(sqlText) && sqlText[i+1] == '*':
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				i = len(sqlText)
+			} else {
+				i += end + 3
+			}
+
+			current.WriteByte(' ')
+		case ch == ';':
+			flush()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+
+	flush()
+
+	return statements
+}
+
+// isTransactionControlStatement 识别 sqlite 重建表时自带的 BEGIN / COMMIT；
+// 迁移由 tsq migrate 自己管理事务。
+func isTransactionControlStatement(statement string) bool {
+	switch strings.ToUpper(strings.Join(strings.Fields(statement), " ")) {
+	case "BEGIN", "BEGIN TRANSACTION", "COMMIT", "COMMIT TRANSACTION", "END", "END TRANSACTION":
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *migrator) up(ctx context.Context, out, errOut io.Writer, lockTimeout time.Duration) error {
+	if err := m.ensureRegistry(ctx); err != nil {
+		return err
+	}
+
+	unlock, err := m.lock(ctx, lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// 拿到锁之后重新读取，以免重复执行其他进程刚应用的步骤。
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, err := m.pending(applied)
+	if err != nil {
+		return err
+	}
+
+	for _, sequence := range m.unknownApplied(applied) {
+		if _, err := fmt.Fprintf(errOut, "warning: applied migration %s is missing from tsq.json\n", sequence); err != nil {
+			return err
+		}
+	}
+
+	if baseline := applied[m.steps[0].Sequence]; baseline.adopted {
+		if err := m.adopt(ctx, m.steps[0]); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(out, "adopted %s (database already at %s)\n", baseline.Sequence, m.squash.Through); err != nil {
+			return err
+		}
+	}
+
+	if len(pending) == 0 {
+		_, err := fmt.Fprintln(out, "database is up to date")
+		return err
+	}
+
+	for _, step := range pending {
+		if err := m.apply(ctx, step); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(out, "applied %s (%d statements)\n", step.Sequence, len(step.Statements)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *migrator) down(ctx context.Context, out io.Writer, to string, allowIrreversible bool, lockTimeout time.Duration) error {
+	target := slices.IndexFunc(m.steps, func(step migrationStep) bool { return step.Sequence == to })
+	if target < 0 && m.squash != nil && slices.Contains(m.squash.Replaces, to) {
+		return fmt.Errorf("migration %s was squashed into %s and can no longer be reverted to", to, m.squash.Sequence)
+	}
+
+	if target < 0 {
+		return fmt.Errorf("unknown migration %q: --to must name a step listed by tsq migrate status", to)
+	}
+
+	if err := m.ensureRegistry(ctx); err != nil {
+		return err
+	}
+
+	unlock, err := m.lock(ctx, lockTimeout)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.pending(applied); err != nil {
+		return err
+	}
+
+	var reverts []migrationStep
+
+	for i := len(m.steps) - 1; i > target; i-- {
+		if _, ok := applied[m.steps[i].Sequence]; ok {
+			reverts = append(reverts, m.steps[i])
+		}
+	}
+
+	// 先检查所有步骤，避免回滚到一半才发现某一步无法回滚。
+	for _, step := range reverts {
+		if !step.HasDown {
+			return fmt.Errorf("migration %s has no down SQL: it was recorded before tsq gen stored inverse DDL", step.Sequence)
+		}
+
+		if len(step.Irreversible) > 0 && !allowIrreversible {
+			return fmt.Errorf("migration %s is irreversible (%s); rerun with --allow-irreversible to restore the schema without the data", step.Sequence, strings.Join(step.Irreversible, "; "))
+		}
+	}
+
+	if len(reverts) == 0 {
+		_, err := fmt.Fprintf(out, "nothing to revert after %s\n", to)
+		return err
+	}
+
+	for _, step := range reverts {
+		if err := m.revert(ctx, step); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(out, "reverted %s (%d statements)\n", step.Sequence, len(step.DownStatements)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *migrator) status(ctx context.Context, out io.Writer) error {
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+
+	for _, item := range m.statuses(applied) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", item.Sequence, item.Status, item.AppliedAt)
+	}
+
+	return w.Flush()
+}
+
+func (m *migrator) plan(ctx context.Context, out io.Writer) error {
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending, err := m.pending(applied)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		_, err := fmt.Fprintln(out, "-- No pending migrations.")
+		return err
+	}
+
+	sections := make([]string, 0, len(pending))
+	for _, step := range pending {
+		sections = append(sections, renderDDLHistorySection(step.Sequence, step.SQL))
+	}
+
+	_, err = fmt.Fprintln(out, strings.Join(sections, "\n\n"))
+
+	return err
+}
+
+func (m *migrator) statuses(applied map[string]appliedMigration) []migrationStepStatus {
+	result := make([]migrationStepStatus, 0, len(m.steps)+len(applied))
+
+	for _, sequence := range m.squashedApplied(applied) {
+		result = append(result, migrationStepStatus{Sequence: sequence, Status: migrationStatusSquashed, AppliedAt: applied[sequence].AppliedAt})
+	}
+
+	for _, step := range m.steps {
+		record, ok := applied[step.Sequence]
+
+		switch {
+		case !ok:
+			result = append(result, migrationStepStatus{Sequence: step.Sequence, Status: migrationStatusPending})
+		case record.Checksum != step.Checksum:
+			result = append(result, migrationStepStatus{Sequence: step.Sequence, Status: migrationStatusModified, AppliedAt: record.AppliedAt})
+		default:
+			result = append(result, migrationStepStatus{Sequence: step.Sequence, Status: migrationStatusApplied, AppliedAt: record.AppliedAt})
+		}
+	}
+
+	for _, sequence := range m.unknownApplied(applied) {
+		result = append(result, migrationStepStatus{Sequence: sequence, Status: migrationStatusUnknown, AppliedAt: applied[sequence].AppliedAt})
+	}
+
+	return result
+}
+
+func (m *migrator) pending(applied map[string]appliedMigration) ([]migrationStep, error) {
+	// 执行过一部分被压缩步骤的数据库没法从压缩后的初始 schema 继续，否则会在已有的表上重新建表。
+	if _, ok := applied[m.steps[0].Sequence]; !ok && m.squash != nil {
+		if folded := m.squashedApplied(applied); len(folded) > 0 {
+			return nil, fmt.Errorf("database stopped at %s before squash point %s: run tsq migrate up with the tsq.json from before %s first", folded[len(folded)-1], m.squash.Through, m.squash.Sequence)
+		}
+	}
+
+	var pending []migrationStep
+
+	for _, step := range m.steps {
+		record, ok := applied[step.Sequence]
+		if !ok {
+			pending = append(pending, step)
+			continue
+		}
+
+		if record.Checksum != step.Checksum {
+			return nil, fmt.Errorf("applied migration %s no longer matches tsq.json (checksum %s, recorded %s)", step.Sequence, step.Checksum, record.Checksum)
+		}
+	}
+
+	return pending, nil
+}
+
+// squashedApplied 按历史顺序返回数据库里已经被压缩掉的步骤。
+func (m *migrator) squashedApplied(applied map[string]appliedMigration) []string {
+	if m.squash == nil {
+		return nil
+	}
+
+	var squashed []string
+
+	for _, sequence := range m.squash.Replaces {
+		if _, ok := applied[sequence]; ok {
+			squashed = append(squashed, sequence)
+		}
+	}
+
+	return squashed
+}
+
+func (m *migrator) unknownApplied(applied map[string]appliedMigration) []string {
+	known := make(map[string]struct{}, len(m.steps))
+	for _, step := range m.steps {
+		known[step.Sequence] = struct{}{}
+	}
+
+	if m.squash != nil {
+		for _, sequence := range m.squash.Replaces {
+			known[sequence] = struct{}{}
+		}
+	}
+
+	var unknown []string
+
+	for sequence := range applied {
+		if _, ok := known[sequence]; !ok {
+			unknown = append(unknown, sequence)
+		}
+	}
+
+	slices.Sort(unknown)
+
+	return unknown
+}
+
+func (m *migrator) apply(ctx context.Context, step migrationStep) error {
+	return m.exec(ctx, "migration "+step.Sequence, step.Statements, m.insertRecordSQL(), step.Sequence, step.Checksum, time.Now().UTC().Format(time.RFC3339))
+}
+
+// adopt 只补记压缩后的初始 schema，不执行它的语句。
+func (m *migrator) adopt(ctx context.Context, step migrationStep) error {
+	return m.exec(ctx, "adoption of "+step.Sequence, nil, m.insertRecordSQL(), step.Sequence, step.Checksum, time.Now().UTC().Format(time.RFC3339))
+}
+
+func (m *migrator) insertRecordSQL() string {
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES (%s, %s, %s)",
+		m.dialect.QuoteField(migrationsRegistryName),
+		m.dialect.QuoteField("sequence"),
+		m.dialect.QuoteField("checksum"),
+		m.dialect.QuoteField("applied_at"),
+		m.dialect.BindVar(0),
+		m.dialect.BindVar(1),
+		m.dialect.BindVar(2),
+	)
+}
+
+func (m *migrator) revert(ctx context.Context, step migrationStep) error {
+	record := fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = %s",
+		m.dialect.QuoteField(migrationsRegistryName),
+		m.dialect.QuoteField("sequence"),
+		m.dialect.BindVar(0),
+	)
+
+	return m.exec(ctx, "down migration "+step.Sequence, step.DownStatements, record, step.Sequence)
+}
+
+// exec 执行一步迁移的语句，并用 record 更新记录表。
+func (m *migrator) exec(ctx context.Context, label string, statements []string, record string, args ...any) error {
+	// mysql 的 DDL 会隐式提交，事务包不住，只能逐条执行。
+	if m.dialect.Name() == tsqdialect.MySQL {
+		for i, statement := range statements {
+			if _, err := m.conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("%s failed at statement %d; earlier statements stay applied on mysql"+": %w", label, i+1, err)
+			}
+		}
+
+		if _, err := m.conn.ExecContext(ctx, record, args...); err != nil {
+			return fmt.Errorf("failed to record %s"+": %w", label, err)
+		}
+
+		return nil
+	}
+
+	tx, err := m.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for i, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s failed at statement %d"+": %w", label, i+1, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record %s"+": %w", label, err)
+	}
+
+	return tx.Commit()
+}
+
+func (m *migrator) ensureRegistry(ctx context.Context) error {
+	registry := ddlSnapshotTable{
+		Name: migrationsRegistryName,
+		Columns: []ddlSnapshotColumn{
+			{Name: "sequence", Kind: ddlColumnString, Size: 64, PrimaryKey: true},
+			{Name: "checksum", Kind: ddlColumnString, Size: 64},
+			{Name: "applied_at", Kind: ddlColumnString, Size: 64},
+		},
+	}
+
+	statement := renderDDLSnapshotCreateTable(registry, ddlDialectSpec{dialect: m.dialect})
+	if _, err := m.conn.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to create migration registry %s"+": %w", migrationsRegistryName, err)
+	}
+
+	return nil
+}
+
+func (m *migrator) loadApplied(ctx context.Context) (map[string]appliedMigration, error) {
+	applied := make(map[string]appliedMigration)
+
+	tables, err := m.dialect.ListTables(ctx, m.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(tables, migrationsRegistryName) {
+		return applied, nil
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s, %s FROM %s",
+		m.dialect.QuoteField("sequence"),
+		m.dialect.QuoteField("checksum"),
+		m.dialect.QuoteField("applied_at"),
+		m.dialect.QuoteField(migrationsRegistryName),
+	)
+
+	rows, err := m.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var record appliedMigration
+		if err := rows.Scan(&record.Sequence, &record.Checksum, &record.AppliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[record.Sequence] = record
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 执行到压缩点的数据库视为已经执行过压缩后的初始 schema，由 up 补记。
+	if m.squash != nil {
+		through, ok := applied[m.squash.Through]
+		if _, done := applied[m.steps[0].Sequence]; ok && !done {
+			applied[m.steps[0].Sequence] = appliedMigration{
+				Sequence:  m.steps[0].Sequence,
+				Checksum:  m.steps[0].Checksum,
+				AppliedAt: through.AppliedAt,
+				adopted:   true,
+			}
+		}
+	}
+
+	return applied, nil
+}
+
+// lock 取得迁移锁并返回释放函数。mysql 和 postgres 使用会话级 advisory lock，
+// 进程退出即释放；sqlite 没有 advisory lock，用锁表中的一行代替，
+// 进程崩溃后需要手动删除该行。
+func (m *migrator) lock(ctx context.Context, timeout time.Duration) (func(), error) {
+	var (
+		try     func() (bool, error)
+		release func()
+	)
+
+	switch m.dialect.Name() {
+	case tsqdialect.MySQL:
+		try = func() (bool, error) {
+			var got sql.NullInt64
+			err := m.conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", migrationLockName).Scan(&got)
+
+			return got.Valid && got.Int64 == 1, err
+		}
+		release = func() {
+			_, _ = m.conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+		}
+	case tsqdialect.Postgres:
+		try = func() (bool, error) {
+			var got bool
+			err := m.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&got)
+
+			return got, err
+		}
+		release = func() {
+			_, _ = m.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		}
+	default:
+		var err error
+
+		try, release, err = m.lockRow(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ok, err := try()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock"+": %w", err)
+		}
+
+		if ok {
+			return release, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			if m.dialect.Name() == tsqdialect.SQLite {
+				return nil, fmt.Errorf("timed out after %s waiting for the migration lock; if no migration is running, delete the row in %s", timeout, migrationLockTableName)
+			}
+
+			return nil, fmt.Errorf("timed out after %s waiting for the migration lock", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+func (m *migrator) lockRow(ctx context.Context) (func() (bool, error), func(), error) {
+	lockTable := ddlSnapshotTable{
+		Name: migrationLockTableName,
+		Columns: []ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true},
+			{Name: "locked_at", Kind: ddlColumnString, Size: 64},
+		},
+	}
+
+	statement := renderDDLSnapshotCreateTable(lockTable, ddlDialectSpec{dialect: m.dialect})
+	if _, err := m.conn.ExecContext(ctx, statement); err != nil {
+		return nil, nil, fmt.Errorf("failed to create migration lock table %s"+": %w", migrationLockTableName, err)
+	}
+
+	table := m.dialect.QuoteField(migrationLockTableName)
+	id := m.dialect.QuoteField("id")
+
+	try := func() (bool, error) {
+		insert := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (1, %s)", table, id, m.dialect.QuoteField("locked_at"), m.dialect.BindVar(0))
+		_, insertErr := m.conn.ExecContext(ctx, insert, time.Now().UTC().Format(time.RFC3339))
+		if insertErr == nil {
+			return true, nil
+		}
+
+		// 插入失败且锁行存在说明锁被占用，否则是真正的错误。
+		var held int
+		if err := m.conn.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = 1", table, id)).Scan(&held); err != nil {
+			return false, err
+		}
+
+		if held == 0 {
+			return false, insertErr
+		}
+
+		return false, nil
+	}
+
+	release := func() {
+		_, _ = m.conn.ExecContext(context.Background(), fmt.Sprintf("DELETE FROM %s WHERE %s = 1", table, id))
+	}
+
+	return try, release, nil
+}
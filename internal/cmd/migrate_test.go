@@ -0,0 +1,631 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func migrateTestSnapshot(columns ...ddlSnapshotColumn) ddlSnapshot {
+	return ddlSnapshot{Tables: []ddlSnapshotTable{{
+		Name: "users",
+		Columns: append([]ddlSnapshotColumn{
+			{Name: "id", Kind: ddlColumnInt, Bits: 64, PrimaryKey: true, AutoIncrement: true},
+		}, columns...),
+		Indexes: []ddlSnapshotIndex{{Name: "idx_users_id", Fields: []string{"id"}}},
+	}}}
+}
+
+// writeMigrateTestHistory writes tsq.json the way successive tsq gen runs
+// would: the first snapshot is the initial schema and every later snapshot
+// appends one dated record.
+func writeMigrateTestHistory(t *testing.T, dir string, snapshots ...ddlSnapshot) *ddlStateFile {
+	t.Helper()
+
+	var state *ddlStateFile
+
+	for i, snapshot := range snapshots {
+		if state == nil {
+			initial := make(map[string]ddlStateDialectSQL, len(ddlDialects))
+			for _, dialect := range ddlDialects {
+				initial[ddlDialectName(dialect)] = ddlStateDialectSQL{SQL: string(renderDDLSnapshotAggregateFile("v4.0.0", snapshot, dialect))}
+			}
+
+			state = &ddlStateFile{InitialDialects: initial, Snapshot: snapshot}
+			state = appendMigrateTestRecord(t, state, snapshot, "")
+
+			continue
+		}
+
+		state = appendMigrateTestRecord(t, state, snapshot, time.Date(2026, 5, 29, 11, 20, i, 0, time.UTC).Format(time.DateTime))
+	}
+
+	saveMigrateTestHistory(t, dir, state)
+
+	return state
+}
+
+// appendMigrateTestRecord 像 tsq gen 一样把 previous 到 snapshot 的变更追加为一条记录。
+func appendMigrateTestRecord(t *testing.T, previous *ddlStateFile, snapshot ddlSnapshot, sequence string) *ddlStateFile {
+	t.Helper()
+
+	var record *ddlStateRecord
+
+	if sequence != "" {
+		var err error
+
+		record, err = buildDDLStateRecord(previous.Snapshot, snapshot, diffDDLSnapshots(&previous.Snapshot, snapshot), sequence)
+		if err != nil {
+			t.Fatalf("buildDDLStateRecord() error = %v", err)
+		}
+	}
+
+	content, err := marshalDDLStateFile("v4.0.0", previous, snapshot, previous.InitialDialects, previous.RenderedRecords, record, previous.Proto)
+	if err != nil {
+		t.Fatalf("marshalDDLStateFile() error = %v", err)
+	}
+
+	state := new(ddlStateFile)
+	if err := json.Unmarshal(content, state); err != nil {
+		t.Fatalf("unmarshal state: %v", err)
+	}
+
+	return state
+}
+
+func saveMigrateTestHistory(t *testing.T, dir string, state *ddlStateFile) {
+	t.Helper()
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, ddlStateFilename), string(content))
+}
+
+func runMigrate(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		migrateDriverFlag = ""
+		migrateDSNFlag = ""
+		migrateLockTimeoutFlag = time.Minute
+		migrateDownToFlag = ""
+		migrateAllowIrreversibleFlag = false
+		MigrateCmd.SetArgs(nil)
+	})
+
+	out := new(bytes.Buffer)
+	MigrateCmd.SetOut(out)
+	MigrateCmd.SetErr(out)
+	MigrateCmd.SetArgs(args)
+
+	err := MigrateCmd.Execute()
+
+	return out.String(), err
+}
+
+func openMigrateTestDB(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func migrateTestColumns(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+
+	rows, err := db.QueryContext(context.Background(), `SELECT name, type FROM pragma_table_info('users')`)
+	if err != nil {
+		t.Fatalf("inspect users: %v", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns := make(map[string]string)
+
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			t.Fatalf("scan column: %v", err)
+		}
+
+		columns[name] = typ
+	}
+
+	return columns
+}
+
+func TestMigrateCmdRequiresDriverAndDSN(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := runMigrate(t, "up", dir); err == nil || !strings.Contains(err.Error(), "requires --driver") {
+		t.Fatalf("expected missing driver error, got %v", err)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "oracle", "--dsn", "x", dir); err == nil || !strings.Contains(err.Error(), "unsupported migrate driver") {
+		t.Fatalf("expected unsupported driver error, got %v", err)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", filepath.Join(dir, "app.db"), dir); err == nil || !strings.Contains(err.Error(), "run tsq gen first") {
+		t.Fatalf("expected missing history error, got %v", err)
+	}
+}
+
+func TestMigrateCmdAppliesHistoryInOrder(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}),
+		// 修改列类型会让 sqlite 重建表，生成的 SQL 自带 BEGIN / COMMIT。
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 16}),
+	)
+
+	status, err := runMigrate(t, "status", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("status error = %v", err)
+	}
+
+	if strings.Count(status, migrationStatusPending) != 3 {
+		t.Fatalf("expected three pending steps, got:\n%s", status)
+	}
+
+	plan, err := runMigrate(t, "plan", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("plan error = %v", err)
+	}
+
+	for _, want := range []string{
+		"-- Migration: initial",
+		`CREATE TABLE IF NOT EXISTS "users"`,
+		"-- Migration: 2026-05-29 11:20:01",
+		`ALTER TABLE "users" ADD COLUMN "age" INTEGER NOT NULL;`,
+		"-- Migration: 2026-05-29 11:20:02",
+	} {
+		if !strings.Contains(plan, want) {
+			t.Fatalf("expected plan to contain %q, got:\n%s", want, plan)
+		}
+	}
+
+	out, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	for _, want := range []string{"applied initial", "applied 2026-05-29 11:20:01", "applied 2026-05-29 11:20:02"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected up output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if columns := migrateTestColumns(t, openMigrateTestDB(t, dsn)); columns["age"] != "VARCHAR(16)" {
+		t.Fatalf("expected the rebuilt age column, got %v", columns)
+	}
+
+	if out, err = runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || !strings.Contains(out, "database is up to date") {
+		t.Fatalf("expected a second up to be a no-op, got %q, %v", out, err)
+	}
+
+	if status, err = runMigrate(t, "status", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || strings.Count(status, migrationStatusApplied) != 3 {
+		t.Fatalf("expected three applied steps, got %v:\n%s", err, status)
+	}
+
+	if plan, err = runMigrate(t, "plan", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || !strings.Contains(plan, "-- No pending migrations.") {
+		t.Fatalf("expected an empty plan, got %v:\n%s", err, plan)
+	}
+}
+
+func TestMigrateCmdAppliesOnlyNewRecords(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	writeMigrateTestHistory(t, dir, migrateTestSnapshot())
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("initial up error = %v", err)
+	}
+
+	writeMigrateTestHistory(t, dir, migrateTestSnapshot(), migrateTestSnapshot(ddlSnapshotColumn{Name: "name", Kind: ddlColumnString, Size: 64}))
+
+	out, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("second up error = %v", err)
+	}
+
+	if strings.Contains(out, "applied initial") || !strings.Contains(out, "applied 2026-05-29 11:20:01") {
+		t.Fatalf("expected only the new record to apply, got:\n%s", out)
+	}
+
+	if columns := migrateTestColumns(t, openMigrateTestDB(t, dsn)); columns["name"] == "" {
+		t.Fatalf("expected the name column, got %v", columns)
+	}
+}
+
+func TestMigrateCmdRefusesModifiedAppliedStep(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	state := writeMigrateTestHistory(t, dir, migrateTestSnapshot(), migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}))
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	state.Records[0].Dialects["sqlite"] = ddlStateDialectDiff{AggregateSQL: `ALTER TABLE "users" ADD COLUMN "age" BIGINT NOT NULL;`}
+	saveMigrateTestHistory(t, dir, state)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "no longer matches tsq.json") {
+		t.Fatalf("expected modified step error, got %v", err)
+	}
+
+	status, err := runMigrate(t, "status", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("status error = %v", err)
+	}
+
+	if !strings.Contains(status, migrationStatusModified) {
+		t.Fatalf("expected status to flag the modified step, got:\n%s", status)
+	}
+
+	// 只改注释不影响校验和。
+	state.Records[0].Dialects["sqlite"] = ddlStateDialectDiff{AggregateSQL: "-- Table: users\n\n-- reviewed\nALTER TABLE \"users\" ADD COLUMN \"age\" INTEGER NOT NULL;"}
+	saveMigrateTestHistory(t, dir, state)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("expected comment-only edits to keep the checksum, got %v", err)
+	}
+}
+
+func TestMigrateCmdRollsBackFailedStep(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	state := writeMigrateTestHistory(t, dir, migrateTestSnapshot(), migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}))
+
+	state.Records[0].Dialects["sqlite"] = ddlStateDialectDiff{AggregateSQL: "ALTER TABLE \"users\" ADD COLUMN \"age\" INTEGER NOT NULL DEFAULT 0;\n\nALTER TABLE \"missing\" ADD COLUMN \"age\" INTEGER;"}
+	saveMigrateTestHistory(t, dir, state)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "failed at statement 2") {
+		t.Fatalf("expected the second statement to fail, got %v", err)
+	}
+
+	if columns := migrateTestColumns(t, openMigrateTestDB(t, dsn)); columns["id"] == "" || columns["age"] != "" {
+		t.Fatalf("expected the initial step applied and the failed step rolled back, got %v", columns)
+	}
+
+	status, err := runMigrate(t, "status", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("status error = %v", err)
+	}
+
+	if !strings.Contains(status, "2026-05-29 11:20:01  "+migrationStatusPending) {
+		t.Fatalf("expected the failed step to stay pending, got:\n%s", status)
+	}
+}
+
+func TestMigrateCmdWaitsForLock(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	writeMigrateTestHistory(t, dir, migrateTestSnapshot())
+
+	db := openMigrateTestDB(t, dsn)
+	if _, err := db.ExecContext(context.Background(), `CREATE TABLE "_tsq_migration_lock" ("id" INTEGER PRIMARY KEY, "locked_at" VARCHAR(64) NOT NULL)`); err != nil {
+		t.Fatalf("create lock table: %v", err)
+	}
+
+	if _, err := db.ExecContext(context.Background(), `INSERT INTO "_tsq_migration_lock" ("id", "locked_at") VALUES (1, 'now')`); err != nil {
+		t.Fatalf("hold lock: %v", err)
+	}
+
+	_, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, "--lock-timeout", "0s", dir)
+	if err == nil || !strings.Contains(err.Error(), "waiting for the migration lock") {
+		t.Fatalf("expected lock timeout, got %v", err)
+	}
+
+	if _, err := db.ExecContext(context.Background(), `DELETE FROM "_tsq_migration_lock"`); err != nil {
+		t.Fatalf("release lock: %v", err)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error after releasing the lock = %v", err)
+	}
+
+	var held int
+	if err := db.QueryRowContext(context.Background(), `SELECT COUNT(*) FROM "_tsq_migration_lock"`).Scan(&held); err != nil || held != 0 {
+		t.Fatalf("expected up to release the lock, got %d, %v", held, err)
+	}
+}
+
+func TestMigrateCmdResolvesPackageDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrateTestHistory(t, dir, migrateTestSnapshot())
+
+	t.Chdir(filepath.Dir(dir))
+
+	rel := "./" + filepath.Base(dir)
+	if _, err := runMigrate(t, "up", "--driver", "sqlite3", "--dsn", filepath.Join(dir, "app.db"), rel); err != nil {
+		t.Fatalf("up with a relative directory error = %v", err)
+	}
+}
+
+func TestMigrateCmdDownRevertsToSequence(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 16}),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 64}),
+	)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	out, err := runMigrate(t, "down", "--to", "2026-05-29 11:20:01", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("down error = %v", err)
+	}
+
+	if !strings.Contains(out, "reverted 2026-05-29 11:20:02") || strings.Contains(out, "reverted 2026-05-29 11:20:01") {
+		t.Fatalf("expected only the last step reverted, got:\n%s", out)
+	}
+
+	db := openMigrateTestDB(t, dsn)
+	if columns := migrateTestColumns(t, db); columns["age"] != "VARCHAR(16)" {
+		t.Fatalf("expected down to restore the previous column type, got %v", columns)
+	}
+
+	if out, err = runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || !strings.Contains(out, "reverted 2026-05-29 11:20:01") {
+		t.Fatalf("expected down to initial to revert the first record, got %q, %v", out, err)
+	}
+
+	if columns := migrateTestColumns(t, db); columns["age"] != "" || columns["id"] == "" {
+		t.Fatalf("expected only the initial schema, got %v", columns)
+	}
+
+	if out, err = runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || !strings.Contains(out, "nothing to revert") {
+		t.Fatalf("expected a second down to be a no-op, got %q, %v", out, err)
+	}
+
+	if out, err = runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil || strings.Count(out, "applied ") != 2 {
+		t.Fatalf("expected up to reapply both records, got %q, %v", out, err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "2026-01-01 00:00:00", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "unknown migration") {
+		t.Fatalf("expected unknown target error, got %v", err)
+	}
+}
+
+func TestMigrateCmdRenameHintKeepsData(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "full_name", Kind: ddlColumnString, Size: 64}),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "name", Kind: ddlColumnString, Size: 64, RenamedFrom: "full_name"}),
+	)
+
+	out, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir)
+	if err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	db := openMigrateTestDB(t, dsn)
+
+	if !strings.Contains(out, "applied 2026-05-29 11:20:01") {
+		t.Fatalf("expected the rename record to apply, got:\n%s", out)
+	}
+
+	if _, err := db.ExecContext(context.Background(), `INSERT INTO users (name) VALUES ('amy')`); err != nil {
+		t.Fatalf("insert renamed column: %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("down error = %v", err)
+	}
+
+	var name string
+	if err := db.QueryRowContext(context.Background(), `SELECT full_name FROM users`).Scan(&name); err != nil || name != "amy" {
+		t.Fatalf("expected down to rename the column back with its data, got %q, %v", name, err)
+	}
+}
+
+func TestMigrateCmdDownRefusesIrreversibleStep(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	state := writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}),
+		migrateTestSnapshot(),
+	)
+
+	if !slices.Equal(state.Records[0].Irreversible, []string{"users: drop column age loses its values; down re-adds it empty"}) {
+		t.Fatalf("expected the dropped column flagged, got %q", state.Records[0].Irreversible)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "--allow-irreversible") {
+		t.Fatalf("expected irreversible step error, got %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--allow-irreversible", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("down --allow-irreversible error = %v", err)
+	}
+
+	if columns := migrateTestColumns(t, openMigrateTestDB(t, dsn)); columns["age"] != "INTEGER" {
+		t.Fatalf("expected the dropped column restored, got %v", columns)
+	}
+}
+
+func TestMigrateCmdDownRefusesNarrowingStep(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	state := writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "name", Kind: ddlColumnString, Size: 64}),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "name", Kind: ddlColumnString, Size: 16}),
+	)
+
+	want := "users: alter column name truncates values longer than 16; down restores the old type, not the lost data"
+	if !slices.Equal(state.Records[0].Irreversible, []string{want}) {
+		t.Fatalf("expected the narrowed column flagged, got %q", state.Records[0].Irreversible)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "truncates values longer than 16") {
+		t.Fatalf("expected irreversible step error, got %v", err)
+	}
+}
+
+func TestMigrateCmdDownRequiresStoredInverse(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "app.db")
+	state := writeMigrateTestHistory(t, dir, migrateTestSnapshot(), migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}))
+
+	// 早于逆向 DDL 的历史记录只有 aggregate_sql。
+	diff := state.Records[0].Dialects["sqlite"]
+	diff.DownSQL = ""
+	state.Records[0].Dialects["sqlite"] = diff
+	saveMigrateTestHistory(t, dir, state)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", dsn, dir); err != nil {
+		t.Fatalf("up error = %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", dsn, dir); err == nil || !strings.Contains(err.Error(), "has no down SQL") {
+		t.Fatalf("expected missing down SQL error, got %v", err)
+	}
+}
+
+func TestMigrateCmdAdoptsSquashedHistory(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "current.db")
+	behind := filepath.Join(dir, "behind.db")
+	fresh := filepath.Join(dir, "fresh.db")
+
+	writeMigrateTestHistory(t, dir, migrateTestSnapshot())
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", behind, dir); err != nil {
+		t.Fatalf("up behind error = %v", err)
+	}
+
+	state := writeMigrateTestHistory(t, dir,
+		migrateTestSnapshot(),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnInt, Bits: 32}),
+		migrateTestSnapshot(ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 16}),
+	)
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", current, dir); err != nil {
+		t.Fatalf("up current error = %v", err)
+	}
+
+	squashed, err := squashDDLState(state, "v4.0.0", "squash 2026-06-01 00:00:00")
+	if err != nil {
+		t.Fatalf("squashDDLState() error = %v", err)
+	}
+
+	want := ddlStateSquash{
+		Sequence: "squash 2026-06-01 00:00:00",
+		Through:  "2026-05-29 11:20:02",
+		Replaces: []string{"initial", "2026-05-29 11:20:01", "2026-05-29 11:20:02"},
+	}
+	if squashed.Squash == nil || !reflect.DeepEqual(*squashed.Squash, want) || len(squashed.Records) != 0 {
+		t.Fatalf("unexpected squashed state %+v", squashed)
+	}
+
+	if _, err := squashDDLState(squashed, "v4.0.0", "squash 2026-06-02 00:00:00"); err == nil || !strings.Contains(err.Error(), "nothing to squash") {
+		t.Fatalf("expected an empty history to refuse squashing, got %v", err)
+	}
+
+	// 压缩之后的变更照常追加为新记录，Squash 标记跟着保留下来。
+	next := migrateTestSnapshot(
+		ddlSnapshotColumn{Name: "age", Kind: ddlColumnString, Size: 16},
+		ddlSnapshotColumn{Name: "email", Kind: ddlColumnString, Size: 64, Nullable: true},
+	)
+	saveMigrateTestHistory(t, dir, appendMigrateTestRecord(t, squashed, next, "2026-06-02 00:00:00"))
+
+	out, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", current, dir)
+	if err != nil {
+		t.Fatalf("up after squash error = %v", err)
+	}
+
+	for _, want := range []string{
+		"adopted squash 2026-06-01 00:00:00 (database already at 2026-05-29 11:20:02)",
+		"applied 2026-06-02 00:00:00",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected up output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "warning") {
+		t.Fatalf("expected squashed steps not to be reported as unknown, got:\n%s", out)
+	}
+
+	status, err := runMigrate(t, "status", "--driver", "sqlite", "--dsn", current, dir)
+	if err != nil || strings.Count(status, migrationStatusSquashed) != 3 || strings.Count(status, migrationStatusApplied) != 2 {
+		t.Fatalf("expected three squashed and two applied steps, got %v:\n%s", err, status)
+	}
+
+	out, err = runMigrate(t, "up", "--driver", "sqlite", "--dsn", fresh, dir)
+	if err != nil || !strings.Contains(out, "applied squash 2026-06-01 00:00:00") || !strings.Contains(out, "applied 2026-06-02 00:00:00") {
+		t.Fatalf("expected a fresh database to run the squashed schema, got %v:\n%s", err, out)
+	}
+
+	if columns := migrateTestColumns(t, openMigrateTestDB(t, fresh)); columns["age"] != "VARCHAR(16)" || columns["email"] != "VARCHAR(64)" {
+		t.Fatalf("expected the squashed schema plus the new record, got %v", columns)
+	}
+
+	if _, err := runMigrate(t, "up", "--driver", "sqlite", "--dsn", behind, dir); err == nil || !strings.Contains(err.Error(), "before squash point 2026-05-29 11:20:02") {
+		t.Fatalf("expected a database behind the squash point to be refused, got %v", err)
+	}
+
+	if _, err := runMigrate(t, "down", "--to", "initial", "--driver", "sqlite", "--dsn", current, dir); err == nil || !strings.Contains(err.Error(), "was squashed into squash 2026-06-01 00:00:00") {
+		t.Fatalf("expected down to a squashed step to be refused, got %v", err)
+	}
+}
+
+func TestSplitDDLStatements(t *testing.T) {
+	got := splitDDLStatements(`-- Code generated by tsq-v4.0.0. DO NOT EDIT.
+BEGIN TRANSACTION;
+CREATE TABLE "a;b" (
+    "note" VARCHAR(16) DEFAULT 'x;y' -- trailing; comment
+);
+/* block; comment */
+CREATE INDEX "idx" ON "a;b"("note");
+COMMIT;`)
+
+	var statements []string
+	for _, statement := range got {
+		if !isTransactionControlStatement(statement) {
+			statements = append(statements, strings.Join(strings.Fields(statement), " "))
+		}
+	}
+
+	want := []string{
+		`CREATE TABLE "a;b" ( "note" VARCHAR(16) DEFAULT 'x;y' )`,
+		`CREATE INDEX "idx" ON "a;b"("note")`,
+	}
+
+	if !slices.Equal(statements, want) {
+		t.Fatalf("splitDDLStatements() = %q, want %q", statements, want)
+	}
+}
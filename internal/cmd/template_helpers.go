@@ -40,6 +40,7 @@ func funcMap() template.FuncMap {
 		"IndexFieldsToCols":        indexFieldsToCols,
 		"HasImport":                hasImport,
 		"NeedsGeneratedTimeImport": needsGeneratedTimeImport,
+		"NeedsGeneratedSQLImport":  needsGeneratedSQLImport,
 		"GeneratedSQLRef":          generatedSQLRef,
 		"GeneratedTimeRef":         generatedTimeRef,
 		"TimestampNowValue":        timestampNowValue,
@@ -235,6 +236,11 @@ func needsGeneratedTimeImport(data *genmodel.StructInfo) bool {
 	return hasImport(data, importPathTime) || data.CreatedAtField != "" || data.UpdatedAtField != "" || data.DeletedAtField != ""
 }
 
+// needsGeneratedSQLImport 表示表文件要以 tsqsql 别名导入 database/sql：列定义会写出每个字段的类型。
+func needsGeneratedSQLImport(data *genmodel.StructInfo) bool {
+	return data != nil && hasImport(data, importPathDatabaseSQL)
+}
+
 func generatedSQLRef(name string) string {
 	return generatedSQLAlias + "." + name
 }
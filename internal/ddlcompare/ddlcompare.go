@@ -0,0 +1,30 @@
+// Package ddlcompare holds the column comparison shared by the runtime schema
+// policies and tsq diff, so both report the same drift without exporting the
+// rule from the public dialect package.
+package ddlcompare
+
+import (
+	"strings"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+)
+
+// ColumnSpecsEquivalent reports whether an inspected column satisfies a
+// declared one: equivalent types, the same key and nullability flags, and the
+// same default. Defaults of auto-increment primary keys are ignored because
+// databases report managed values (e.g. PostgreSQL nextval('..._seq')) that a
+// declared schema never states.
+func ColumnSpecsEquivalent(dialect tsqdialect.Dialect, left, right tsqdialect.DDLColumnSpec) bool {
+	if !tsqdialect.DDLColumnTypesEquivalent(dialect, left, right) ||
+		left.PrimaryKey != right.PrimaryKey ||
+		left.AutoIncrement != right.AutoIncrement ||
+		left.Type.Nullable != right.Type.Nullable {
+		return false
+	}
+
+	if left.PrimaryKey && left.AutoIncrement {
+		return true
+	}
+
+	return strings.EqualFold(strings.TrimSpace(left.Default), strings.TrimSpace(right.Default))
+}
@@ -85,18 +85,22 @@ func (s *StructInfo) SetTSQVersion(version string) {
 }
 
 type TableMeta struct {
-	IsResult       bool
-	Table          string
-	AI             bool
-	PK             string
-	VersionField   string
-	CreatedAtField string
-	UpdatedAtField string
-	DeletedAtField string
-	SearchColumns  []string
-	UxList         UxList
-	IdxList        IdxList
-	QueryList      IdxList
+	IsResult         bool
+	Table            string
+	AI               bool
+	PK               string
+	VersionField     string
+	CreatedAtField   string
+	UpdatedAtField   string
+	DeletedAtField   string
+	RenamedFrom      string
+	AllowDestructive bool
+	AllowDrop        []string
+	HTTP             bool
+	SearchColumns    []string
+	UxList           UxList
+	IdxList          IdxList
+	QueryList        IdxList
 }
 
 type UxList []IndexInfo
@@ -469,6 +469,7 @@ func genTableInfoFromAST(
 	ast DSLObject,
 	isTable bool,
 	structFields map[string]struct{},
+	naming Naming,
 ) (*genmodel.TableMeta, error) {
 	info := &genmodel.TableMeta{
 		IsResult: !isTable,
@@ -539,6 +540,41 @@ func genTableInfoFromAST(
 			} else if _, ok := v.(DSLBool); !ok {
 				return nil, NewDSLValueTypeError(k, "string or boolean", v)
 			}
+		case "allow_destructive":
+			b, ok := v.(DSLBool)
+			if !ok {
+				return nil, NewDSLValueTypeError(k, "boolean", v)
+			}
+
+			info.AllowDestructive = bool(b)
+		case "allow_drop":
+			arr, ok := v.(DSLArray)
+			if !ok {
+				return nil, NewDSLValueTypeError(k, "array of column names", v)
+			}
+
+			for _, node := range arr {
+				s, ok := node.(DSLString)
+				if !ok {
+					return nil, NewDSLArrayEntryTypeError(k, "string column name", node)
+				}
+
+				info.AllowDrop = append(info.AllowDrop, string(s))
+			}
+		case "http":
+			b, ok := v.(DSLBool)
+			if !ok {
+				return nil, NewDSLValueTypeError(k, "boolean", v)
+			}
+
+			info.HTTP = bool(b)
+		case "renamed_from":
+			s, ok := v.(DSLString)
+			if !ok {
+				return nil, NewDSLValueTypeError(k, "string", v)
+			}
+
+			info.RenamedFrom = string(s)
 		case "ux":
 			arr, ok := v.(DSLArray)
 			if !ok {
@@ -656,8 +692,8 @@ func genTableInfoFromAST(
 		}
 	}
 
-	normalizeIndexNames(info.UxList, "ux", info.Table)
-	normalizeIndexNames(info.IdxList, "idx", info.Table)
+	normalizeIndexNames(info.UxList, "ux", info.Table, naming)
+	normalizeIndexNames(info.IdxList, "idx", info.Table, naming)
 
 	// 新增：校验 DSL 字段和索引
 	err := validateTableInfoAgainstStruct(info, structFields, name)
@@ -679,11 +715,11 @@ func defaultIndexName(prefix, table string, fields []string) string {
 	return strings.Join(parts, "_")
 }
 
-func normalizeIndexNames(indexes []genmodel.IndexInfo, prefix, table string) {
+func normalizeIndexNames(indexes []genmodel.IndexInfo, prefix, table string, naming Naming) {
 	for i := range indexes {
 		switch {
 		case indexes[i].Name == "":
-			indexes[i].Name = defaultIndexName(prefix, table, indexes[i].Fields)
+			indexes[i].Name = naming.index(prefix, table, indexes[i].Fields)
 		case strings.HasPrefix(indexes[i].Name, "Ux") && !strings.Contains(indexes[i].Name, "_"):
 			indexes[i].Name = snaker.CamelToSnake(indexes[i].Name)
 		case strings.HasPrefix(indexes[i].Name, "Idx") && !strings.Contains(indexes[i].Name, "_"):
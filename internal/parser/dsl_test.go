@@ -415,12 +415,16 @@ func TestDSLErrors(t *testing.T) {
 
 func Test_genTableInfoFromAST(t *testing.T) {
 	ast := DSLObject{
-		"name":       DSLString("t1"),
-		"pk":         DSLString("id,false"),
-		"version":    DSLString("Version1"),
-		"created_at": DSLBool(true),
-		"updated_at": DSLString("mtime"),
-		"deleted_at": DSLBool(true),
+		"name":              DSLString("t1"),
+		"pk":                DSLString("id,false"),
+		"version":           DSLString("Version1"),
+		"created_at":        DSLBool(true),
+		"updated_at":        DSLString("mtime"),
+		"deleted_at":        DSLBool(true),
+		"renamed_from":      DSLString("t0"),
+		"allow_destructive": DSLBool(true),
+		"allow_drop":        DSLArray{DSLString("legacy")},
+		"http":              DSLBool(true),
 		"ux": DSLArray{
 			DSLObject{"name": DSLString("ux1"), "fields": DSLArray{DSLString("f1"), DSLString("f2")}},
 		},
@@ -433,7 +437,7 @@ func Test_genTableInfoFromAST(t *testing.T) {
 	}
 	structFields := map[string]struct{}{"id": {}, "Version1": {}, "CreatedAt": {}, "mtime": {}, "DeletedAt": {}, "f1": {}, "f2": {}, "f3": {}}
 
-	info, err := genTableInfoFromAST("MyTable", ast, true, structFields)
+	info, err := genTableInfoFromAST("MyTable", ast, true, structFields, Naming{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -447,6 +451,22 @@ func Test_genTableInfoFromAST(t *testing.T) {
 		t.Errorf("Primary key error: PK=%s, AI=%v", info.PK, info.AI)
 	}
 
+	if info.RenamedFrom != "t0" {
+		t.Errorf("RenamedFrom error: got %s, want t0", info.RenamedFrom)
+	}
+
+	if !info.AllowDestructive {
+		t.Error("AllowDestructive error: got false, want true")
+	}
+
+	if len(info.AllowDrop) != 1 || info.AllowDrop[0] != "legacy" {
+		t.Errorf("AllowDrop error: got %v, want [legacy]", info.AllowDrop)
+	}
+
+	if !info.HTTP {
+		t.Error("HTTP error: got false, want true")
+	}
+
 	if info.VersionField != "Version1" {
 		t.Errorf("Version field error: got %s, want V1", info.VersionField)
 	}
@@ -507,6 +527,7 @@ func Test_genTableInfoFromASTRejectsUnknownTopLevelKeys(t *testing.T) {
 		DSLObject{"unknown": DSLBool(true)},
 		true,
 		map[string]struct{}{"PK": {}},
+		Naming{},
 	)
 	if err == nil {
 		t.Fatal("expected unknown DSL key to return an error")
@@ -520,7 +541,7 @@ func Test_genTableInfoFromASTRejectsUnknownTopLevelKeys(t *testing.T) {
 	if !strings.Contains(got, `unknown table DSL key "unknown"`) {
 		t.Fatalf("expected clearer table DSL key error, got %q", got)
 	}
-	if !strings.Contains(got, "valid keys: name, pk, version, created_at, updated_at, deleted_at, ux, idx, search") {
+	if !strings.Contains(got, "valid keys: name, pk, version, created_at, updated_at, deleted_at, renamed_from, allow_destructive, allow_drop, http, ux, idx, search") {
 		t.Fatalf("expected valid table DSL keys in error, got %q", got)
 	}
 }
@@ -538,6 +559,7 @@ func Test_genTableInfoFromASTRejectsUnknownIndexKeys(t *testing.T) {
 		},
 		true,
 		map[string]struct{}{"PK": {}},
+		Naming{},
 	)
 	if err == nil {
 		t.Fatal("expected unknown index DSL key to return an error")
@@ -562,6 +584,7 @@ func Test_genTableInfoFromASTSuggestsClosestTopLevelKey(t *testing.T) {
 		DSLObject{"created_a": DSLBool(true)},
 		true,
 		map[string]struct{}{"PK": {}, "CreatedAt": {}},
+		Naming{},
 	)
 	if err == nil {
 		t.Fatal("expected mistyped DSL key to return an error")
@@ -630,6 +653,22 @@ func Test_genTableInfoFromASTRejectsWrongValueTypes(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "allow_destructive must be boolean",
+			ast:  DSLObject{"allow_destructive": DSLString("yes")},
+		},
+		{
+			name: "allow_drop must be an array of strings",
+			ast:  DSLObject{"allow_drop": DSLArray{DSLBool(true)}},
+		},
+		{
+			name: "http must be boolean",
+			ast:  DSLObject{"http": DSLString("yes")},
+		},
+		{
+			name: "renamed_from must be string",
+			ast:  DSLObject{"renamed_from": DSLBool(true)},
+		},
 		{
 			name: "ux must be array",
 			ast:  DSLObject{"ux": DSLObject{}},
@@ -648,7 +687,7 @@ func Test_genTableInfoFromASTRejectsWrongValueTypes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := genTableInfoFromAST("MyTable", tt.ast, true, structFields)
+			_, err := genTableInfoFromAST("MyTable", tt.ast, true, structFields, Naming{})
 			if err == nil {
 				t.Fatal("expected wrong DSL value type to return an error")
 			}
@@ -666,6 +705,7 @@ func Test_genTableInfoFromASTReportsExpectedValueTypes(t *testing.T) {
 		DSLObject{"created_at": DSLNumber(1)},
 		true,
 		map[string]struct{}{"PK": {}, "CreatedAt": {}},
+		Naming{},
 	)
 	if err == nil {
 		t.Fatal("expected invalid managed field type to return an error")
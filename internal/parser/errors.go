@@ -219,7 +219,7 @@ func NewDSLUnexpectedValueTokenError(actual string, position int) error {
 
 func NewDSLUnknownTableKeyError(actual string) error {
 	return newDSLUnknownKeyError("table DSL", actual, []string{
-		"name", "pk", "version", "created_at", "updated_at", "deleted_at", "ux", "idx", "search",
+		"name", "pk", "version", "created_at", "updated_at", "deleted_at", "renamed_from", "allow_destructive", "allow_drop", "http", "ux", "idx", "search",
 	})
 }
 
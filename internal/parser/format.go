@@ -564,6 +564,10 @@ func orderedRootKeys(obj DSLObject) []string {
 		"created_at",
 		"updated_at",
 		"deleted_at",
+		"renamed_from",
+		"allow_destructive",
+		"allow_drop",
+		"http",
 		"ux",
 		"idx",
 		"search",
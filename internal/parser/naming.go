@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/serenize/snaker"
+)
+
+// 列名推导规则
+const (
+	ColumnNamingSnake    = "snake_case"
+	ColumnNamingCamel    = "camelCase"
+	ColumnNamingLower    = "lowercase"
+	ColumnNamingVerbatim = "verbatim"
+)
+
+// DefaultIndexNaming 是没写 name 的索引的默认命名模板。
+const DefaultIndexNaming = "{kind}_{table}_{fields}"
+
+// Naming 是可以按包调整的默认命名规则，零值就是内置规则。
+type Naming struct {
+	// Columns 为 db 标签没写列名的字段（如 `db:",size:64"`）推导列名。
+	Columns string
+	// Indexes 是没写 name 的索引名模板，可用 {kind}（ux 或 idx）、{table} 和 {fields}。
+	Indexes string
+}
+
+// Validate 检查命名规则是否可用
+func (n Naming) Validate() error {
+	switch n.Columns {
+	case "", ColumnNamingSnake, ColumnNamingCamel, ColumnNamingLower, ColumnNamingVerbatim:
+	default:
+		return fmt.Errorf(
+			"unknown column naming %q: use %s, %s, %s or %s",
+			n.Columns, ColumnNamingSnake, ColumnNamingCamel, ColumnNamingLower, ColumnNamingVerbatim,
+		)
+	}
+
+	if n.Indexes != "" && !strings.Contains(n.Indexes, "{fields}") {
+		return fmt.Errorf("index naming %q must contain {fields} so that indexes on one table get distinct names", n.Indexes)
+	}
+
+	return nil
+}
+
+// column 按规则从字段名推导列名
+func (n Naming) column(fieldName string) string {
+	switch n.Columns {
+	case ColumnNamingCamel:
+		runes := []rune(fieldName)
+		// 开头连续的大写字母整体转小写，例如 ID -> id、URLPath -> urlPath。
+		i := 0
+		for i < len(runes) && unicode.IsUpper(runes[i]) {
+			if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				break
+			}
+
+			runes[i] = unicode.ToLower(runes[i])
+			i++
+		}
+
+		return string(runes)
+	case ColumnNamingLower:
+		return strings.ToLower(fieldName)
+	case ColumnNamingVerbatim:
+		return fieldName
+	default:
+		return snaker.CamelToSnake(fieldName)
+	}
+}
+
+// index 按模板生成默认索引名
+func (n Naming) index(kind, table string, fields []string) string {
+	if n.Indexes == "" {
+		return defaultIndexName(kind, table, fields)
+	}
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, snaker.CamelToSnake(field))
+	}
+
+	return strings.NewReplacer(
+		"{kind}", kind,
+		"{table}", snaker.CamelToSnake(table),
+		"{fields}", strings.Join(parts, "_"),
+	).Replace(n.Indexes)
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamingColumn(t *testing.T) {
+	for _, tc := range []struct {
+		columns string
+		field   string
+		want    string
+	}{
+		{"", "CreatedAt", "created_at"},
+		{ColumnNamingSnake, "UserID", "user_id"},
+		{ColumnNamingCamel, "CreatedAt", "createdAt"},
+		{ColumnNamingCamel, "ID", "id"},
+		{ColumnNamingCamel, "URLPath", "urlPath"},
+		{ColumnNamingLower, "CreatedAt", "createdat"},
+		{ColumnNamingVerbatim, "CreatedAt", "CreatedAt"},
+	} {
+		if got := (Naming{Columns: tc.columns}).column(tc.field); got != tc.want {
+			t.Fatalf("Naming{%q}.column(%q) = %q, want %q", tc.columns, tc.field, got, tc.want)
+		}
+	}
+}
+
+func TestNamingIndex(t *testing.T) {
+	fields := []string{"OrgID", "Name"}
+
+	if got, want := (Naming{}).index("ux", "User", fields), defaultIndexName("ux", "User", fields); got != want {
+		t.Fatalf("default index name = %q, want %q", got, want)
+	}
+
+	if got := (Naming{Indexes: "{table}_{fields}_{kind}"}).index("idx", "User", fields); got != "user_org_id_name_idx" {
+		t.Fatalf("templated index name = %q", got)
+	}
+}
+
+func TestNamingValidate(t *testing.T) {
+	if err := (Naming{Columns: "kebab-case"}).Validate(); err == nil || !strings.Contains(err.Error(), "unknown column naming") {
+		t.Fatalf("expected an unknown column naming error, got %v", err)
+	}
+
+	if err := (Naming{Indexes: "{kind}_{table}"}).Validate(); err == nil || !strings.Contains(err.Error(), "{fields}") {
+		t.Fatalf("expected a missing {fields} error, got %v", err)
+	}
+
+	if err := (Naming{Columns: ColumnNamingCamel, Indexes: "{table}_{fields}"}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 
@@ -28,7 +29,16 @@ type ParseResult struct {
 
 // Parse 解析指定路径的包，返回所有带有表注解的结构体和目录路径
 func Parse(packagePath string) ([]*genmodel.StructInfo, string, error) {
-	result, err := parsePackage(packagePath)
+	return ParseWithNaming(packagePath, Naming{})
+}
+
+// ParseWithNaming 与 Parse 相同，但没写列名的字段和没写 name 的索引按 naming 命名
+func ParseWithNaming(packagePath string, naming Naming) ([]*genmodel.StructInfo, string, error) {
+	if err := naming.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	result, err := parsePackage(packagePath, naming, newPackageLoader())
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to parse package %s"+": %w", packagePath, err)
 	}
@@ -41,13 +51,107 @@ func Parse(packagePath string) ([]*genmodel.StructInfo, string, error) {
 	return infos, result.Directory, nil
 }
 
+// PackageDir 返回包路径（导入路径、相对或绝对目录）对应的源码目录
+func PackageDir(packagePath string) (string, error) {
+	pkg, err := loadSinglePackage(packagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load package %s"+": %w", packagePath, err)
+	}
+
+	return pkg.Dir, nil
+}
+
+// PackageSet 是一次 packages.Load 得到的一组包。tsq gen ./... 用它解析每个目标包，
+// 嵌入的基础结构体（如 tsq.MutableTable）在所有目标包里都从同一份加载结果解析。
+type PackageSet struct {
+	loader *packageLoader
+	roots  []*packages.Package
+}
+
+// NewPackageSet 用 packages.Load 的结果（根包及其依赖）建立解析用的包缓存
+func NewPackageSet(roots []*packages.Package) *PackageSet {
+	loader := newPackageLoader()
+
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		if pkg.PkgPath != "" {
+			loader.cache[pkg.PkgPath] = newLoadedPackage(pkg)
+		}
+	})
+
+	return &PackageSet{loader: loader, roots: roots}
+}
+
+// Annotated 按导入路径排序返回带 @TABLE / @RESULT 注解的根包
+func (s *PackageSet) Annotated() []*packages.Package {
+	var annotated []*packages.Package
+
+	for _, pkg := range s.roots {
+		if hasTableAnnotations(pkg) {
+			annotated = append(annotated, pkg)
+		}
+	}
+
+	slices.SortFunc(annotated, func(a, b *packages.Package) int {
+		return strings.Compare(a.PkgPath, b.PkgPath)
+	})
+
+	return annotated
+}
+
+// Parse 与 ParseWithNaming 相同，但只从这组包里读取包信息，可以并发调用
+func (s *PackageSet) Parse(importPath string, naming Naming) ([]*genmodel.StructInfo, string, error) {
+	if err := naming.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	result, err := parsePackage(importPath, naming, s.loader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse package %s"+": %w", importPath, err)
+	}
+
+	infos := make([]*genmodel.StructInfo, len(result.Structs))
+	for i, internal := range result.Structs {
+		infos[i] = internal.StructInfo
+	}
+
+	return infos, result.Directory, nil
+}
+
+// hasTableAnnotations 报告包的源文件（不含生成文件和测试文件）里是否有 @TABLE / @RESULT 注释
+func hasTableAnnotations(pkg *packages.Package) bool {
+	for _, file := range pkg.Syntax {
+		if shouldSkipFile(pkg.Fset.Position(file.Package).Filename) {
+			continue
+		}
+
+		for _, group := range file.Comments {
+			lines := make([]string, 0, len(group.List))
+			for _, comment := range group.List {
+				lines = append(lines, CleanCommentPrefix(comment.Text))
+			}
+
+			text := strings.Join(lines, "\n")
+			if _, ok := findAnnotationKeyword(text, "@TABLE"); ok {
+				return true
+			}
+
+			if _, ok := findAnnotationKeyword(text, "@RESULT"); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // parsePackage 解析包的完整流程
-func parsePackage(packagePath string) (*ParseResult, error) {
+func parsePackage(packagePath string, naming Naming, loader *packageLoader) (*ParseResult, error) {
 	parseState := &ParseState{
+		naming:          naming,
 		structMap:       make(map[genmodel.TypeInfo]*StructInfo),
 		parsedPackages:  make(map[genmodel.PackageInfo]bool),
 		pendingPackages: list.New(),
-		loader:          newPackageLoader(),
+		loader:          loader,
 	}
 
 	pipeline := parsePipeline{
@@ -86,6 +190,7 @@ type ParseState struct {
 	parsedPackages  map[genmodel.PackageInfo]bool     // 已解析的包集合
 	pendingPackages *list.List                        // 待解析的包队列
 	loader          *packageLoader
+	naming          Naming // 默认列名和索引名的命名规则
 }
 
 type parsePipeline struct {
@@ -293,7 +398,7 @@ func (ps *ParseState) processStructTypeSpec(
 		fields[name] = struct{}{}
 	}
 
-	tableMeta, err := ParseTableInfo(structName, comments, fields, fileSet)
+	tableMeta, err := parseTableInfo(structName, comments, fields, fileSet, ps.naming)
 	if err != nil {
 		return err
 	}
@@ -418,6 +523,15 @@ func (ps *ParseState) parseStructDeclarations(
 			if err != nil {
 				return err
 			}
+
+			// db 标签没写列名的字段按命名规则补上列名
+			fieldMap := ps.structMap[genmodel.TypeInfo{Package: pkg, TypeName: typeSpec.Name.Name}].FieldMap
+			for name, field := range fieldMap {
+				if field.Column == "" {
+					field.Column = ps.naming.column(name)
+					fieldMap[name] = field
+				}
+			}
 		}
 	}
 
@@ -657,7 +771,10 @@ func (l *packageLoader) loadWithConfig(
 		return nil, fmt.Errorf("package %s not found", packagePath)
 	}
 
-	pkg := pkgs[0]
+	return newLoadedPackage(pkgs[0]), nil
+}
+
+func newLoadedPackage(pkg *packages.Package) *loadedPackage {
 	goFiles := pkg.GoFiles
 
 	if len(goFiles) == 0 {
@@ -690,7 +807,7 @@ func (l *packageLoader) loadWithConfig(
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 func cloneLoadedPackage(pkg *loadedPackage) *loadedPackage {
@@ -20,6 +20,16 @@ func ParseTableInfo(
 	commentGroup []*ast.CommentGroup,
 	structFields map[string]struct{},
 	fileSet *token.FileSet,
+) (*genmodel.TableMeta, error) {
+	return parseTableInfo(structName, commentGroup, structFields, fileSet, Naming{})
+}
+
+func parseTableInfo(
+	structName string,
+	commentGroup []*ast.CommentGroup,
+	structFields map[string]struct{},
+	fileSet *token.FileSet,
+	naming Naming,
 ) (*genmodel.TableMeta, error) {
 	if commentGroup == nil {
 		return nil, nil
@@ -28,7 +38,7 @@ func ParseTableInfo(
 	locator := newCommentLocator(commentGroup, fileSet)
 
 	// 解析注解，填充 meta
-	info, err := parseDSL(structName, commentGroup, structFields)
+	info, err := parseDSL(structName, commentGroup, structFields, naming)
 	if err != nil {
 		return nil, locator.attach(err)
 	}
@@ -508,6 +518,7 @@ func parseDSL(
 	structName string,
 	commentGroup []*ast.CommentGroup,
 	structFields map[string]struct{},
+	naming Naming,
 ) (*genmodel.TableMeta, error) {
 	for _, comments := range commentGroup {
 		// 合并整个注释组，并健壮去除每行注释前缀
@@ -520,9 +531,9 @@ func parseDSL(
 		text = strings.TrimSpace(text)
 
 		if _, ok := findAnnotationKeyword(text, "@TABLE"); ok {
-			return parseTableDSL(structName, text, structFields)
+			return parseTableDSL(structName, text, structFields, naming)
 		} else if _, ok := findAnnotationKeyword(text, "@RESULT"); ok {
-			return parseResultDSL(structName, text, structFields)
+			return parseResultDSL(structName, text, structFields, naming)
 		}
 	}
 
@@ -534,6 +545,7 @@ func parseTableDSL(
 	structName string,
 	text string,
 	structFields map[string]struct{},
+	naming Naming,
 ) (*genmodel.TableMeta, error) {
 	// 去除注释前缀
 	text = CleanBlockComment(text)
@@ -544,7 +556,7 @@ func parseTableDSL(
 	}
 
 	if content == "" {
-		return genTableInfoFromAST(structName, DSLObject{}, true, structFields)
+		return genTableInfoFromAST(structName, DSLObject{}, true, structFields, naming)
 	}
 
 	content = strings.ReplaceAll(content, "\n", " ")
@@ -561,7 +573,7 @@ func parseTableDSL(
 		return nil, err
 	}
 
-	return genTableInfoFromAST(structName, dsl, true, structFields)
+	return genTableInfoFromAST(structName, dsl, true, structFields, naming)
 }
 
 // parseResultDSL 解析 @RESULT DSL 并填充 meta
@@ -569,6 +581,7 @@ func parseResultDSL(
 	structName string,
 	text string,
 	structFields map[string]struct{},
+	naming Naming,
 ) (*genmodel.TableMeta, error) {
 	// 去除注释前缀
 	text = CleanBlockComment(text)
@@ -596,7 +609,7 @@ func parseResultDSL(
 		return nil, err
 	}
 
-	return genTableInfoFromAST(structName, dsl, false, structFields)
+	return genTableInfoFromAST(structName, dsl, false, structFields, naming)
 }
 
 // generateQueryList 生成查询索引列表，支持普通、集合、前缀等多种组合
@@ -106,7 +106,7 @@ func TestParseAnnotations_DSL(t *testing.T) {
 		t.Run(tt.desc, func(t *testing.T) {
 			cg := []*ast.CommentGroup{{List: []*ast.Comment{{Text: tt.comment}}}}
 
-			info, err := parseDSL("User", cg, structFields)
+			info, err := parseDSL("User", cg, structFields, Naming{})
 			if err != nil {
 				t.Fatalf("parseDSL error: %v", err)
 			}
@@ -186,7 +186,7 @@ func TestExtractDSLContent_ReturnsErrorForArgumentsWithoutBrackets(t *testing.T)
 func TestParseDSL_IgnoresAnnotationPrefixes(t *testing.T) {
 	cg := []*ast.CommentGroup{{List: []*ast.Comment{{Text: `// @TABLEX(name="user")`}}}}
 
-	info, err := parseDSL("User", cg, map[string]struct{}{"PK": {}})
+	info, err := parseDSL("User", cg, map[string]struct{}{"PK": {}}, Naming{})
 	if err != nil {
 		t.Fatalf("parseDSL returned error for non-annotation prefix: %v", err)
 	}
@@ -199,7 +199,7 @@ func TestParseDSL_IgnoresAnnotationPrefixes(t *testing.T) {
 func TestParseDSL_IgnoresAnnotationMentionsInProse(t *testing.T) {
 	cg := []*ast.CommentGroup{{List: []*ast.Comment{{Text: `// This struct can be generated with @TABLE(name="user") later.`}}}}
 
-	info, err := parseDSL("User", cg, map[string]struct{}{"PK": {}})
+	info, err := parseDSL("User", cg, map[string]struct{}{"PK": {}}, Naming{})
 	if err != nil {
 		t.Fatalf("parseDSL returned error for prose annotation mention: %v", err)
 	}
@@ -212,7 +212,7 @@ func TestParseDSL_IgnoresAnnotationMentionsInProse(t *testing.T) {
 func TestParseTableDSL_ReturnsErrorForMalformedAnnotation(t *testing.T) {
 	_, err := parseTableDSL("User", `// @TABLE(name="user"`, map[string]struct{}{
 		"PK": {},
-	})
+	}, Naming{})
 	if err == nil {
 		t.Fatal("expected malformed TABLE annotation to return an error")
 	}
@@ -225,7 +225,7 @@ func TestParseTableDSL_ReturnsErrorForMalformedAnnotation(t *testing.T) {
 func TestParseResultDSL_ReturnsErrorForMalformedAnnotation(t *testing.T) {
 	_, err := parseResultDSL("UserResult", `// @RESULT(name="user"`, map[string]struct{}{
 		"PK": {},
-	})
+	}, Naming{})
 	if err == nil {
 		t.Fatal("expected malformed Result annotation to return an error")
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"slices"
+	"time"
 )
 
 // ErrUnknownSortField reports that a requested sort field is unknown.
@@ -125,7 +126,16 @@ type Query[O Owner] struct {
 	selectTables map[string]Table // 查询涉及的所有表。
 	kwCols       []SearchColumn   // 关键词搜索涉及的列。
 	kwTables     map[string]Table
-	hasSetOps    bool // 是否包含集合操作（UNION 等），影响别名处理。
+	hasSetOps    bool     // 是否包含集合操作（UNION 等），影响别名处理。
+	scanCols     []string // 交给 ScanBinder.ScanDest 的列名；nil 表示走字段指针。
+	locking      bool     // 是否带 FOR UPDATE / FOR SHARE，带锁读取必须走主库。
+
+	// 结果缓存；cacheTTL 为 0 表示不缓存，cacheTables 是 SQL 中出现的全部标识符。
+	cacheTTL    time.Duration
+	cacheTables []string
+
+	// 按方言缓存渲染后的 SQL；手写的 Query 字面量为 nil，此时每次现渲染。
+	renders *queryRenderCache
 }
 
 type (
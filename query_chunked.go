@@ -393,6 +393,8 @@ func chunkedDeleteByPKsChunk(
 		return fmt.Errorf("chunked delete by primary keys failed: %s: %w", sqlText, err)
 	}
 
+	invalidateResultCache(tx, tableName)
+
 	return nil
 }
 
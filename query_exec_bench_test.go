@@ -1,6 +1,7 @@
 package tsq
 
 import (
+	"context"
 	"testing"
 )
 
@@ -93,3 +94,48 @@ func BenchmarkQueryBuilder_WithAliases(b *testing.B) {
 			Build()
 	}
 }
+
+// BenchmarkQueryExec_ListUncachedRender measures List with SQL rendered on every call
+func BenchmarkQueryExec_ListUncachedRender(b *testing.B) {
+	benchmarkQueryExecList(b, false)
+}
+
+// BenchmarkQueryExec_ListCachedRender measures List with SQL served from the query render cache
+func BenchmarkQueryExec_ListCachedRender(b *testing.B) {
+	benchmarkQueryExecList(b, true)
+}
+
+// BenchmarkQueryExec_PageCachedRender measures Page, which renders both the count and list SQL
+func BenchmarkQueryExec_PageCachedRender(b *testing.B) {
+	db := newInVarEngine(b)
+	query := newRenderCacheTestQuery(b)
+	page := &PageRequest{Page: 1, Size: 10}
+	ctx := context.Background()
+	ids := []int64{1, 2, 3}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := query.Page(ctx, db, page, ids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkQueryExecList(b *testing.B, cached bool) {
+	db := newInVarEngine(b)
+	query := newRenderCacheTestQuery(b)
+	if !cached {
+		query.renders = nil
+	}
+	ctx := context.Background()
+	ids := []int64{1, 2, 3}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := query.List(ctx, db, ids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
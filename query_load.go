@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 )
 
@@ -65,8 +66,8 @@ func pageFn[O Owner](
 		return nil, err
 	}
 
-	renderedCntSQL := renderSQLForExecutor(tx, resolvedCntSQL)
-	renderedListSQL := renderSQLForExecutor(tx, resolvedListSQL)
+	renderedCntSQL := q.renderSQL(tx, resolvedCntSQL)
+	renderedListSQL := q.renderSQL(tx, resolvedListSQL)
 
 	if err := validateScanDestForType(q.selectCols, renderedListSQL, finalArgs); err != nil {
 		return nil, err
@@ -81,12 +82,28 @@ func pageFn[O Owner](
 		slog.Info("list", "sql", renderedListSQL, "args", compactJSON(argsWithLimit))
 	}
 
-	count, err := queryInt64(ctx, tx, renderedCntSQL, countArgs...)
+	cacheArgs := append(slices.Clone(countArgs), argsWithLimit...)
+
+	return q.cachedResult(tx, "page", renderedCntSQL+"\n;\n"+renderedListSQL, cacheArgs, cloneResultValue[*PageResponse[O]], func() (*PageResponse[O], error) {
+		return q.loadPage(q.readContext(ctx), tx, page, renderedCntSQL, countArgs, renderedListSQL, argsWithLimit)
+	})
+}
+
+func (q *Query[O]) loadPage(
+	ctx context.Context,
+	tx SQLExecutor,
+	page *PageRequest,
+	cntSQL string,
+	countArgs []any,
+	listSQL string,
+	listArgs []any,
+) (*PageResponse[O], error) {
+	count, err := queryInt64(ctx, tx, cntSQL, countArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "failed to execute count query", err)
 	}
 
-	rows, err := tx.QueryContext(ctx, renderedListSQL, argsWithLimit...)
+	rows, err := tx.QueryContext(ctx, listSQL, listArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "failed to execute paginated query", err)
 	}
@@ -102,7 +119,7 @@ func pageFn[O Owner](
 	for rows.Next() {
 		r := new(O)
 
-		dest, err := buildScanDest(q.selectCols, r)
+		dest, err := q.scanDest(r)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", "failed to execute paginated query", err)
 		}
@@ -151,7 +168,7 @@ func listFn[O Owner](
 		return nil, err
 	}
 
-	sqlText := renderSQLForExecutor(tx, resolvedSQL)
+	sqlText := q.renderSQL(tx, resolvedSQL)
 
 	if err := validateScanDestForType(q.selectCols, sqlText, finalArgs); err != nil {
 		return nil, err
@@ -161,7 +178,13 @@ func listFn[O Owner](
 		slog.Info("list", "sql", sqlText, "args", compactJSON(finalArgs))
 	}
 
-	rows, err := tx.QueryContext(ctx, sqlText, finalArgs...)
+	return q.cachedResult(tx, "list", sqlText, finalArgs, cloneResultValue[[]*O], func() ([]*O, error) {
+		return q.loadList(q.readContext(ctx), tx, sqlText, finalArgs)
+	})
+}
+
+func (q *Query[O]) loadList(ctx context.Context, tx SQLExecutor, sqlText string, args []any) ([]*O, error) {
+	rows, err := tx.QueryContext(ctx, sqlText, args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "failed to execute list query", err)
 	}
@@ -177,7 +200,7 @@ func listFn[O Owner](
 	for rows.Next() {
 		r := new(O)
 
-		dest, err := buildScanDest(q.selectCols, r)
+		dest, err := q.scanDest(r)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", "failed to execute list query", err)
 		}
@@ -226,20 +249,26 @@ func getOrErrFn[O Owner](
 		return nil, err
 	}
 
-	sqlText := renderSQLForExecutor(tx, resolvedSQL)
+	sqlText := qb.renderSQL(tx, resolvedSQL)
 
 	if ctx.Value(printSQL) != nil {
 		slog.Info("getOrErr", "sql", sqlText, "args", compactJSON(finalArgs))
 	}
 
+	return qb.cachedResult(tx, "get", sqlText, finalArgs, cloneResultValue[*O], func() (*O, error) {
+		return qb.loadRow(qb.readContext(ctx), tx, sqlText, finalArgs)
+	})
+}
+
+func (q *Query[O]) loadRow(ctx context.Context, tx SQLExecutor, sqlText string, args []any) (*O, error) {
 	r := new(O)
 
-	dest, err := buildScanDest(qb.selectCols, r)
+	dest, err := q.scanDest(r)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "failed to execute select query", err)
 	}
 
-	row := tx.QueryRowContext(ctx, sqlText, finalArgs...)
+	row := tx.QueryRowContext(ctx, sqlText, args...)
 
 	if err := row.Scan(dest...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -291,18 +320,18 @@ func (q *Query[O]) Load(
 			return err
 		}
 
-		sqlText := renderSQLForExecutor(tx, resolvedSQL)
+		sqlText := q.renderSQL(tx, resolvedSQL)
 
 		if ctx.Value(printSQL) != nil {
 			slog.Info("load", "sql", sqlText, "args", compactJSON(finalArgs))
 		}
 
-		dest, err := buildScanDest(q.selectCols, holder)
+		dest, err := q.scanDest(holder)
 		if err != nil {
 			return fmt.Errorf("%s: %w", "failed to execute select query", err)
 		}
 
-		row := tx.QueryRowContext(ctx, sqlText, finalArgs...)
+		row := tx.QueryRowContext(q.readContext(ctx), sqlText, finalArgs...)
 		if err := row.Scan(dest...); err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return sql.ErrNoRows
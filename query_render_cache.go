@@ -0,0 +1,84 @@
+package tsq
+
+import (
+	"sync"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+)
+
+// renderCacheMaxEntries bounds how many rendered variants one Query keeps.
+// Variants multiply with dialects, page sort orders, and slice-expansion
+// arities; past the bound TSQ simply renders on every call.
+const renderCacheMaxEntries = 128
+
+type renderCacheKey struct {
+	dialect tsqdialect.Name
+	raw     string
+}
+
+// queryRenderCache memoizes renderSQLForDialect output for one built Query.
+// The key is the resolved raw SQL, which already encodes the SQL variant
+// (list / count / keyword / page order) and the slice-expansion arity, so the
+// cache stays correct without tracking those dimensions separately.
+type queryRenderCache struct {
+	mu      sync.RWMutex
+	entries map[renderCacheKey]string
+}
+
+func newQueryRenderCache() *queryRenderCache {
+	return &queryRenderCache{entries: make(map[renderCacheKey]string)}
+}
+
+func (c *queryRenderCache) render(exec SQLExecutor, raw string) string {
+	sqlDialect := dialectForExecutor(exec)
+	if c == nil {
+		return renderSQLForDialect(raw, sqlDialect)
+	}
+
+	name, ok := renderCacheDialectName(sqlDialect)
+	if !ok {
+		return renderSQLForDialect(raw, sqlDialect)
+	}
+
+	key := renderCacheKey{dialect: name, raw: raw}
+
+	c.mu.RLock()
+	rendered, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return rendered
+	}
+
+	rendered = renderSQLForDialect(raw, sqlDialect)
+
+	c.mu.Lock()
+	if len(c.entries) < renderCacheMaxEntries {
+		c.entries[key] = rendered
+	}
+	c.mu.Unlock()
+
+	return rendered
+}
+
+// renderCacheDialectName reports the cache key for sqlDialect. Only the
+// built-in dialects are cached: a custom Dialect may reuse a built-in name
+// while quoting differently, so its output is never memoized.
+func renderCacheDialectName(sqlDialect tsqdialect.Dialect) (tsqdialect.Name, bool) {
+	switch sqlDialect.(type) {
+	case nil:
+		return "", true
+	case tsqdialect.MySQLDialect, *tsqdialect.MySQLDialect:
+		return tsqdialect.MySQL, true
+	case tsqdialect.PostgresDialect, *tsqdialect.PostgresDialect:
+		return tsqdialect.Postgres, true
+	case tsqdialect.SQLiteDialect, *tsqdialect.SQLiteDialect:
+		return tsqdialect.SQLite, true
+	default:
+		return "", false
+	}
+}
+
+func (q *Query[O]) renderSQL(exec SQLExecutor, raw string) string {
+	return q.renders.render(exec, raw)
+}
@@ -0,0 +1,141 @@
+package tsq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type renderCacheDialect struct {
+	SQLiteDialect
+}
+
+func (renderCacheDialect) QuoteField(name string) string {
+	return "[" + name + "]"
+}
+
+func newRenderCacheTestQuery(t testing.TB) *Query[inVarUser] {
+	t.Helper()
+
+	users := newMockTable("users")
+	idCol := newColForTable[inVarUser, int64](users, "id", "id", toScanPointer(func(holder *inVarUser) *int64 {
+		return &holder.ID
+	}))
+	nameCol := newColForTable[inVarUser, string](users, "name", "name", toScanPointer(func(holder *inVarUser) *string {
+		return &holder.Name
+	}))
+
+	query, err := Select(idCol, nameCol).From(idCol.Table()).Where(idCol.InVar()).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return query
+}
+
+func renderCacheSize[O Owner](q *Query[O]) int {
+	q.renders.mu.RLock()
+	defer q.renders.mu.RUnlock()
+
+	return len(q.renders.entries)
+}
+
+func TestQueryRenderCacheKeysByDialectAndSliceArity(t *testing.T) {
+	query := newRenderCacheTestQuery(t)
+
+	oneArg, _, err := resolveQueryWithState(query.listSQL, query.listArgs, []any{[]int64{1}}, "", query.listArgState)
+	if err != nil {
+		t.Fatalf("resolveQueryWithState() error = %v", err)
+	}
+	twoArgs, _, err := resolveQueryWithState(query.listSQL, query.listArgs, []any{[]int64{1, 2}}, "", query.listArgState)
+	if err != nil {
+		t.Fatalf("resolveQueryWithState() error = %v", err)
+	}
+
+	sqlite := newRuntimeWithDB(nil, SQLiteDialect{})
+	postgres := newRuntimeWithDB(nil, PostgresDialect{})
+
+	for range 2 {
+		if got, want := query.renderSQL(sqlite, oneArg), renderSQLForDialect(oneArg, SQLiteDialect{}); got != want {
+			t.Fatalf("sqlite render = %q, want %q", got, want)
+		}
+		if got, want := query.renderSQL(sqlite, twoArgs), renderSQLForDialect(twoArgs, SQLiteDialect{}); got != want {
+			t.Fatalf("sqlite render = %q, want %q", got, want)
+		}
+		if got, want := query.renderSQL(postgres, twoArgs), renderSQLForDialect(twoArgs, PostgresDialect{}); got != want {
+			t.Fatalf("postgres render = %q, want %q", got, want)
+		}
+	}
+
+	if size := renderCacheSize(query); size != 3 {
+		t.Fatalf("expected one entry per dialect and arity, got %d", size)
+	}
+}
+
+func TestQueryRenderCacheSkipsCustomDialects(t *testing.T) {
+	query := newRenderCacheTestQuery(t)
+
+	rendered := query.renderSQL(newRuntimeWithDB(nil, renderCacheDialect{}), query.listSQL)
+	if !strings.Contains(rendered, "[users]") {
+		t.Fatalf("expected the custom dialect to quote identifiers, got %q", rendered)
+	}
+
+	if size := renderCacheSize(query); size != 0 {
+		t.Fatalf("expected custom dialect output to stay uncached, got %d entries", size)
+	}
+}
+
+func TestQueryRenderCacheIsBounded(t *testing.T) {
+	query := newRenderCacheTestQuery(t)
+	runtime := newRuntimeWithDB(nil, SQLiteDialect{})
+
+	for i := range renderCacheMaxEntries + 10 {
+		query.renderSQL(runtime, fmt.Sprintf("%s AND %d = %d", query.listSQL, i, i))
+	}
+
+	if size := renderCacheSize(query); size != renderCacheMaxEntries {
+		t.Fatalf("expected the render cache to stop at %d entries, got %d", renderCacheMaxEntries, size)
+	}
+}
+
+func TestQueryRenderCacheConcurrentExecution(t *testing.T) {
+	db := newInVarEngine(t)
+	// Every :memory: connection is a separate database; keep the seeded one.
+	db.DB().SetMaxOpenConns(1)
+
+	query := newRenderCacheTestQuery(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	for worker := range 8 {
+		wg.Go(func() {
+			ids := []int64{1, 2, 3}[:worker%3+1]
+
+			for range 20 {
+				rows, err := query.List(context.Background(), db, ids)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(rows) != len(ids) {
+					errs <- fmt.Errorf("expected %d rows, got %d", len(ids), len(rows))
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent List() error = %v", err)
+	}
+
+	if size := renderCacheSize(query); size != 3 {
+		t.Fatalf("expected one cached render per arity, got %d", size)
+	}
+}
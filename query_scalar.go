@@ -30,7 +30,7 @@ func (q *Query[O]) prepareQueryExecution(
 		return "", nil, err
 	}
 
-	sqlText := renderSQLForExecutor(tx, resolvedSQL)
+	sqlText := q.renderSQL(tx, resolvedSQL)
 
 	if ctx.Value(printSQL) != nil {
 		slog.Info(methodName, "sql", sqlText, "args", compactJSON(finalArgs))
@@ -90,7 +90,9 @@ func (q *Query[O]) scalarValue[T any](ctx context.Context, tx SQLExecutor, args
 		return zero, err
 	}
 
-	result, err := queryScalar[T](ctx, tx, sqlText, finalArgs...)
+	result, err := q.cachedResult(tx, "scalar", sqlText, finalArgs, cloneResultValue[T], func() (T, error) {
+		return queryScalar[T](q.readContext(ctx), tx, sqlText, finalArgs...)
+	})
 	if err != nil {
 		return zero, fmt.Errorf("failed to execute scalar query: %w", err)
 	}
@@ -202,13 +204,15 @@ func (q *Query[O]) count64(
 		return 0, err
 	}
 
-	sqlText := renderSQLForExecutor(tx, resolvedSQL)
+	sqlText := q.renderSQL(tx, resolvedSQL)
 
 	if ctx.Value(printSQL) != nil {
 		slog.Info("count", "sql", sqlText, "args", compactJSON(finalArgs))
 	}
 
-	count, err := queryInt64(ctx, tx, sqlText, finalArgs...)
+	count, err := q.cachedResult(tx, "count", sqlText, finalArgs, cloneResultValue[int64], func() (int64, error) {
+		return queryInt64(q.readContext(ctx), tx, sqlText, finalArgs...)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", "failed to execute count query", err)
 	}
@@ -245,13 +249,15 @@ func (q *Query[O]) exist(
 		return false, err
 	}
 
-	sqlText := renderSQLForExecutor(tx, resolvedSQL)
+	sqlText := q.renderSQL(tx, resolvedSQL)
 
 	if ctx.Value(printSQL) != nil {
 		slog.Info("exist", "sql", sqlText, "args", compactJSON(finalArgs))
 	}
 
-	count, err := queryInt64(ctx, tx, sqlText, finalArgs...)
+	count, err := q.cachedResult(tx, "count", sqlText, finalArgs, cloneResultValue[int64], func() (int64, error) {
+		return queryInt64(q.readContext(ctx), tx, sqlText, finalArgs...)
+	})
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", "failed to check record existence", err)
 	}
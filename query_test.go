@@ -434,7 +434,7 @@ func newScanValidationEngine(t *testing.T) *Runtime {
 	return newRuntimeWithDB(db, SQLiteDialect{})
 }
 
-func newInVarEngine(t *testing.T) *Runtime {
+func newInVarEngine(t testing.TB) *Runtime {
 	t.Helper()
 	db, err := sql.Open("sqlite", ":memory:")
 	if err != nil {
@@ -3,6 +3,7 @@ package tsq
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 type joinType string
@@ -78,6 +79,17 @@ type queryBuilder[O Owner] struct {
 	*queryBuilderCore[O]
 }
 
+// BuildOptions configures optional behavior of a built Query. Pass it to
+// BuildWith or MustBuildWith; Build and nil options keep the defaults.
+type BuildOptions struct {
+	// CacheTTL opts the query into the Runtime result cache: List, Get, GetOrErr,
+	// Page, Count, Count64, Exists, and Scalar results are reused for up to this
+	// long, or until a TSQ mutation touches a table the query reads. It has no
+	// effect unless the Runtime enables RuntimeOptions.ResultCacheSize or
+	// RuntimeOptions.ResultCache. Zero disables caching.
+	CacheTTL time.Duration
+}
+
 // QueryStage is a buildable query state that can participate in CTEs and set operations.
 type QueryStage[O Owner] interface {
 	Build() (*Query[O], error)
@@ -104,6 +116,8 @@ type FromStage[O Owner] interface {
 // WhereStage is the query state after Where(...).
 type WhereStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	Search(cols ...SearchColumn) FilteredStage[O]
 	GroupBy(cols ...SQLColumn) GroupedStage[O]
 	ForUpdate() LockedStage[O]
@@ -119,6 +133,8 @@ type WhereStage[O Owner] interface {
 // SearchStage is the query state after Search(...).
 type SearchStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	Where(conds ...Condition) FilteredStage[O]
 	GroupBy(cols ...SQLColumn) GroupedStage[O]
 	ForUpdate() LockedStage[O]
@@ -134,6 +150,8 @@ type SearchStage[O Owner] interface {
 // FilteredStage is the query state after both Where(...) and Search(...).
 type FilteredStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	GroupBy(cols ...SQLColumn) GroupedStage[O]
 	ForUpdate() LockedStage[O]
 	ForShare() LockedStage[O]
@@ -148,6 +166,8 @@ type FilteredStage[O Owner] interface {
 // GroupedStage is the query state after GroupBy(...).
 type GroupedStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	Having(conds ...Condition) HavingStage[O]
 	ForUpdate() LockedStage[O]
 	ForShare() LockedStage[O]
@@ -162,6 +182,8 @@ type GroupedStage[O Owner] interface {
 // HavingStage is the query state after Having(...).
 type HavingStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	ForUpdate() LockedStage[O]
 	ForShare() LockedStage[O]
 	Union(other QueryStage[O]) CompoundStage[O]
@@ -175,6 +197,8 @@ type HavingStage[O Owner] interface {
 // CompoundStage is the query state after one or more set operations.
 type CompoundStage[O Owner] interface {
 	QueryStage[O]
+	BuildWith(options *BuildOptions) (*Query[O], error)
+	MustBuildWith(options *BuildOptions) *Query[O]
 	ForUpdate() LockedStage[O]
 	ForShare() LockedStage[O]
 	Union(other QueryStage[O]) CompoundStage[O]
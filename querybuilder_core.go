@@ -477,22 +477,36 @@ func (core *queryBuilderCore[O]) setLockWaitMode(mode queryLockWaitMode) {
 	core.phase = builderPhaseLocked
 }
 
-func buildQuery[O Owner](core *queryBuilderCore[O]) (*Query[O], error) {
+func buildQuery[O Owner](core *queryBuilderCore[O], options ...*BuildOptions) (*Query[O], error) {
 	if core == nil {
 		return nil, errors.New("query builder cannot be nil")
 	}
 
+	var opts BuildOptions
+	if len(options) > 0 && options[0] != nil {
+		opts = *options[0]
+	}
+
+	if opts.CacheTTL < 0 {
+		return nil, fmt.Errorf("invalid cache ttl: %s", opts.CacheTTL)
+	}
+
 	core = ensureQueryBuilderCore(core, core.phase)
 	if core.buildErr != nil {
 		return nil, core.buildErr
 	}
 
+	// 行锁读取必须每次都到数据库加锁，从缓存返回就等于没锁。
+	if opts.CacheTTL > 0 && core.spec.Lock.strength != "" {
+		return nil, errors.New("cache ttl cannot be combined with ForUpdate or ForShare")
+	}
+
 	plan, err := buildQueryPlan(core.spec)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Query[O]{
+	query := &Query[O]{
 		cntSQL:     plan.cntSQL,
 		listSQL:    plan.listSQL,
 		kwCntSQL:   plan.kwCntSQL,
@@ -512,7 +526,18 @@ func buildQuery[O Owner](core *queryBuilderCore[O]) (*Query[O], error) {
 		kwCols:       cloneSearchColumns(core.spec.KeywordSearch),
 		kwTables:     core.spec.keywordTables(),
 		hasSetOps:    len(core.spec.SetOps) > 0,
-	}, nil
+		scanCols:     scanBinderColumns(core.spec.Selects),
+		locking:      core.spec.Lock.strength != "",
+
+		renders: newQueryRenderCache(),
+	}
+
+	if opts.CacheTTL > 0 {
+		query.cacheTTL = opts.CacheTTL
+		query.cacheTables = resultCacheTables(plan.listSQL, plan.kwListSQL, plan.cntSQL, plan.kwCntSQL)
+	}
+
+	return query, nil
 }
 
 func cloneBoundColumns[O Owner](cols []BoundColumn[O]) []BoundColumn[O] {
@@ -20,6 +20,23 @@ func (core *queryBuilderCore[O]) MustBuild() *Query[O] {
 	return query
 }
 
+// BuildWith compiles and validates the query shape like Build, then applies
+// options to the built Query. Nil options behave like Build.
+func (core *queryBuilderCore[O]) BuildWith(options *BuildOptions) (*Query[O], error) {
+	return buildQuery(core, options)
+}
+
+// MustBuildWith is BuildWith that panics if validation fails. Like MustBuild,
+// it is intended for code where TSQ controls the query shape.
+func (core *queryBuilderCore[O]) MustBuildWith(options *BuildOptions) *Query[O] {
+	query, err := buildQuery(core, options)
+	if err != nil {
+		panic(err)
+	}
+
+	return query
+}
+
 // Get builds and executes the query, returning one row or nil when no row matches.
 func (core *queryBuilderCore[O]) Get(ctx context.Context, tx SQLExecutor, args ...any) (*O, error) {
 	query, err := core.build()
@@ -21,6 +21,9 @@ type Runtime struct {
 	tablePolicy SchemaPolicy
 	indexPolicy SchemaPolicy
 	logger      Logger
+	stmts       *stmtCache
+	replicas    *replicaSet
+	results     *resultCache
 }
 
 // NewRuntime opens a database connection, resolves the SQL dialect from driverName,
@@ -30,12 +33,41 @@ func NewRuntime(
 	dsn string,
 	tables []TableRegistration,
 	options ...*RuntimeOptions,
+) (*Runtime, error) {
+	return newRuntime(driverName, ClusterConfig{PrimaryDSN: dsn}, tables, options...)
+}
+
+// NewClusterRuntime is NewRuntime for a primary database with read replicas.
+// Read-only Query methods (List, Get, Page, Count, Exists, Scalar, Load) go to a
+// healthy replica chosen by cluster.Balancer. Mutations, ForUpdate/ForShare
+// queries, WithTx, schema policies, and reads under UsePrimary go to the
+// primary. A replica read that fails to connect is retried on the primary.
+func NewClusterRuntime(
+	driverName string,
+	cluster ClusterConfig,
+	tables []TableRegistration,
+	options ...*RuntimeOptions,
+) (*Runtime, error) {
+	for i, dsn := range cluster.ReplicaDSNs {
+		if dsn == "" {
+			return nil, fmt.Errorf("replica dsn %d cannot be empty", i)
+		}
+	}
+
+	return newRuntime(driverName, cluster, tables, options...)
+}
+
+func newRuntime(
+	driverName string,
+	cluster ClusterConfig,
+	tables []TableRegistration,
+	options ...*RuntimeOptions,
 ) (*Runtime, error) {
 	if driverName == "" {
 		return nil, errors.New("driver name cannot be empty")
 	}
 
-	if dsn == "" {
+	if cluster.PrimaryDSN == "" {
 		return nil, errors.New("dsn cannot be empty")
 	}
 
@@ -64,19 +96,46 @@ func NewRuntime(
 		return nil, err
 	}
 
-	db, sqlDialect, err := openRuntimeDB(driverName, dsn)
+	if opts.StatementCacheSize < 0 {
+		return nil, fmt.Errorf("invalid statement cache size: %d", opts.StatementCacheSize)
+	}
+
+	if opts.ResultCacheSize < 0 {
+		return nil, fmt.Errorf("invalid result cache size: %d", opts.ResultCacheSize)
+	}
+
+	db, sqlDialect, err := openRuntimeDB(driverName, cluster.PrimaryDSN)
 	if err != nil {
 		return nil, err
 	}
 
 	cleanup := true
+	opened := []*sql.DB{db}
 
 	defer func() {
 		if cleanup {
-			_ = db.Close()
+			for _, db := range opened {
+				_ = db.Close()
+			}
 		}
 	}()
 
+	replicaDBs := make([]*sql.DB, 0, len(cluster.ReplicaDSNs))
+	replicaStmts := make([]*stmtCache, 0, len(cluster.ReplicaDSNs))
+
+	for _, dsn := range cluster.ReplicaDSNs {
+		// Replicas are not pinged here: an unreachable replica must not block
+		// startup, so CheckReplicas below ejects it instead.
+		replicaDB, err := sql.Open(driverName, dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		opened = append(opened, replicaDB)
+		replicaDBs = append(replicaDBs, replicaDB)
+		replicaStmts = append(replicaStmts, newStmtCache(replicaDB, sqlDialect, opts.StatementCacheSize))
+	}
+
 	runtime := &Runtime{
 		tables:      registeredTables,
 		tracers:     appendTracers(nil, opts.Tracers...),
@@ -85,6 +144,9 @@ func NewRuntime(
 		tablePolicy: tablePolicy,
 		indexPolicy: indexPolicy,
 		logger:      resolveRuntimeLogger(opts),
+		stmts:       newStmtCache(db, sqlDialect, opts.StatementCacheSize),
+		replicas:    newReplicaSet(replicaDBs, replicaStmts, cluster),
+		results:     newResultCache(opts.ResultCache, opts.ResultCacheSize),
 	}
 
 	if opts.IdentifierValidationMode != "skip" {
@@ -101,6 +163,10 @@ func NewRuntime(
 		return nil, err
 	}
 
+	if err := runtime.CheckReplicas(context.Background()); err != nil {
+		runtime.warn("replica unreachable during runtime bootstrap; ejected until the next check", "error", err)
+	}
+
 	cleanup = false
 
 	return runtime, nil
@@ -134,6 +200,26 @@ func (r *Runtime) SQLDialect() tsqdialect.Dialect {
 	return r.dialect
 }
 
+// Tables returns copies of the table registrations the runtime was created
+// with, ordered by their schema-qualified table names.
+func (r *Runtime) Tables() []TableRegistration {
+	if r == nil {
+		return nil
+	}
+
+	tables := make([]TableRegistration, 0, len(r.tables))
+	for _, table := range r.tables {
+		tables = append(tables, TableRegistration{
+			Table:       table.Table,
+			Columns:     cloneDDLColumnSpecs(table.Columns),
+			Indexes:     cloneTableIndexes(table.Indexes),
+			RenamedFrom: table.RenamedFrom,
+		})
+	}
+
+	return tables
+}
+
 // QueryContext executes a query against the runtime database.
 func (r *Runtime) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	db, err := r.sqlDB()
@@ -141,7 +227,14 @@ func (r *Runtime) QueryContext(ctx context.Context, query string, args ...any) (
 		return nil, err
 	}
 
-	return db.QueryContext(ctx, query, args...)
+	if replica := r.readReplica(ctx); replica != nil {
+		rows, err := queryDB(ctx, replica.db, replica.stmts, query, args...)
+		if r.replicas.observe(replica, err) {
+			return rows, err
+		}
+	}
+
+	return queryDB(ctx, db, r.stmts, query, args...)
 }
 
 // QueryRowContext executes a query expected to return at most one row.
@@ -151,7 +244,13 @@ func (r *Runtime) QueryRowContext(ctx context.Context, query string, args ...any
 		return sql.OpenDB(runtimeErrorConnector{err: err}).QueryRowContext(ctx, query, args...)
 	}
 
-	return db.QueryRowContext(ctx, query, args...)
+	// *sql.Row defers its error to Scan, so replica failures here cannot be
+	// observed or retried; CheckReplicas covers them.
+	if replica := r.readReplica(ctx); replica != nil {
+		return queryRowDB(ctx, replica.db, replica.stmts, query, args...)
+	}
+
+	return queryRowDB(ctx, db, r.stmts, query, args...)
 }
 
 // ExecContext executes a statement against the runtime database.
@@ -161,9 +260,58 @@ func (r *Runtime) ExecContext(ctx context.Context, query string, args ...any) (s
 		return nil, err
 	}
 
+	if r.stmts != nil {
+		result, prepared, err := r.stmts.run(ctx, nil, query, func(stmt *sql.Stmt) (sql.Result, error) {
+			return stmt.ExecContext(ctx, args...)
+		})
+		if prepared {
+			return result, err
+		}
+	}
+
 	return db.ExecContext(ctx, query, args...)
 }
 
+// Close closes the primary database and every replica.
+func (r *Runtime) Close() error {
+	if r == nil || r.db == nil {
+		return nil
+	}
+
+	errs := []error{r.db.Close()}
+	for _, db := range r.ReplicaDBs() {
+		errs = append(errs, db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func queryDB(ctx context.Context, db *sql.DB, stmts *stmtCache, query string, args ...any) (*sql.Rows, error) {
+	if stmts != nil {
+		rows, prepared, err := stmts.run(ctx, nil, query, func(stmt *sql.Stmt) (*sql.Rows, error) {
+			return stmt.QueryContext(ctx, args...)
+		})
+		if prepared {
+			return rows, err
+		}
+	}
+
+	return db.QueryContext(ctx, query, args...)
+}
+
+func queryRowDB(ctx context.Context, db *sql.DB, stmts *stmtCache, query string, args ...any) *sql.Row {
+	if stmts != nil {
+		row, prepared, _ := stmts.run(ctx, nil, query, func(stmt *sql.Stmt) (*sql.Row, error) {
+			return stmt.QueryRowContext(ctx, args...), nil
+		})
+		if prepared {
+			return row
+		}
+	}
+
+	return db.QueryRowContext(ctx, query, args...)
+}
+
 // WithTx starts a transaction on the runtime database and passes a dialect-aware executor to fn.
 // It manages BeginTx, Commit, and Rollback automatically.
 func (r *Runtime) WithTx(
@@ -0,0 +1,313 @@
+package tsq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultReplicaEjectAfter = 3
+	defaultReplicaEjectFor   = 30 * time.Second
+)
+
+// ClusterConfig describes one primary database plus its read replicas.
+type ClusterConfig struct {
+	PrimaryDSN  string          // PrimaryDSN receives writes, locking reads, transactions, and schema DDL.
+	ReplicaDSNs []string        // ReplicaDSNs serve read-only Query methods; order defines replica indexes.
+	Balancer    ReplicaBalancer // Balancer picks a healthy replica per read; nil means round robin.
+	// EjectAfter is the number of consecutive connection failures that eject a
+	// replica. Zero means 3.
+	EjectAfter int
+	// EjectFor is how long an ejected replica stays out of rotation before TSQ
+	// tries it again. Zero means 30 seconds.
+	EjectFor time.Duration
+	// IsUnhealthy reports whether a replica query error counts toward ejection
+	// and is retried on the primary. Nil means connection-level failures such
+	// as driver.ErrBadConn and network timeouts.
+	IsUnhealthy func(error) bool
+}
+
+// ReplicaBalancer chooses which healthy replica serves the next read.
+type ReplicaBalancer interface {
+	// Pick returns one element of healthy, which lists replica indexes in
+	// ClusterConfig.ReplicaDSNs order and is never empty.
+	Pick(ctx context.Context, healthy []int) int
+}
+
+// ReplicaBalancerFunc adapts a function to ReplicaBalancer.
+type ReplicaBalancerFunc func(ctx context.Context, healthy []int) int
+
+// Pick implements ReplicaBalancer.
+func (f ReplicaBalancerFunc) Pick(ctx context.Context, healthy []int) int {
+	return f(ctx, healthy)
+}
+
+type roundRobinBalancer struct {
+	next atomic.Uint64
+}
+
+// RoundRobinBalancer returns a ReplicaBalancer that cycles through healthy replicas.
+func RoundRobinBalancer() ReplicaBalancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(_ context.Context, healthy []int) int {
+	return healthy[int((b.next.Add(1)-1)%uint64(len(healthy)))]
+}
+
+// RandomBalancer returns a ReplicaBalancer that picks a healthy replica uniformly at random.
+func RandomBalancer() ReplicaBalancer {
+	return ReplicaBalancerFunc(func(_ context.Context, healthy []int) int {
+		return healthy[rand.IntN(len(healthy))]
+	})
+}
+
+// ReplicaStatus reports the routing state of one replica.
+type ReplicaStatus struct {
+	Index        int       // Index is the replica position in ClusterConfig.ReplicaDSNs.
+	Healthy      bool      // Healthy reports whether the replica currently receives reads.
+	Failures     int       // Failures counts consecutive connection failures since the last success.
+	EjectedUntil time.Time // EjectedUntil is when an ejected replica rejoins rotation.
+}
+
+type usePrimaryKey struct{}
+
+type readQueryKey struct{}
+
+// readRoute pins the replica chosen for one Query call, so Page runs its count
+// and list statements against the same replica.
+type readRoute struct {
+	replica *replica
+}
+
+// UsePrimary returns a context that routes reads issued with it to the primary
+// database, for example to read your own writes right after a mutation.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+// readContext marks ctx as carrying a read-only query so a cluster Runtime may
+// route it to a replica. Locking reads stay on the primary.
+func (q *Query[O]) readContext(ctx context.Context) context.Context {
+	if q.locking || ctx.Value(readQueryKey{}) != nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, readQueryKey{}, &readRoute{})
+}
+
+type replica struct {
+	index int
+	db    *sql.DB
+	stmts *stmtCache
+
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+type replicaSet struct {
+	replicas    []*replica
+	balancer    ReplicaBalancer
+	ejectAfter  int
+	ejectFor    time.Duration
+	isUnhealthy func(error) bool
+	now         func() time.Time
+}
+
+func newReplicaSet(dbs []*sql.DB, stmts []*stmtCache, config ClusterConfig) *replicaSet {
+	if len(dbs) == 0 {
+		return nil
+	}
+
+	set := &replicaSet{
+		balancer:    config.Balancer,
+		ejectAfter:  config.EjectAfter,
+		ejectFor:    config.EjectFor,
+		isUnhealthy: config.IsUnhealthy,
+		now:         time.Now,
+	}
+	if set.balancer == nil {
+		set.balancer = RoundRobinBalancer()
+	}
+
+	if set.isUnhealthy == nil {
+		set.isUnhealthy = isReplicaConnectionError
+	}
+
+	if set.ejectAfter <= 0 {
+		set.ejectAfter = defaultReplicaEjectAfter
+	}
+
+	if set.ejectFor <= 0 {
+		set.ejectFor = defaultReplicaEjectFor
+	}
+
+	for i, db := range dbs {
+		set.replicas = append(set.replicas, &replica{index: i, db: db, stmts: stmts[i]})
+	}
+
+	return set
+}
+
+// pick returns the replica for the next read, or nil when every replica is
+// ejected and the read must fall back to the primary.
+func (s *replicaSet) pick(ctx context.Context) *replica {
+	now := s.now()
+
+	healthy := make([]int, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy(now) {
+			healthy = append(healthy, r.index)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	index := s.balancer.Pick(ctx, healthy)
+	if index < 0 || index >= len(s.replicas) {
+		index = healthy[0]
+	}
+
+	return s.replicas[index]
+}
+
+// observe records the outcome of a replica call. It reports false when err is
+// a connection failure, in which case the caller retries on the primary.
+func (s *replicaSet) observe(r *replica, err error) bool {
+	if err == nil || !s.isUnhealthy(err) {
+		r.mu.Lock()
+		r.failures = 0
+		r.mu.Unlock()
+
+		return true
+	}
+
+	r.mu.Lock()
+	r.failures++
+	if r.failures >= s.ejectAfter {
+		r.ejectedUntil = s.now().Add(s.ejectFor)
+		r.failures = 0
+	}
+	r.mu.Unlock()
+
+	return false
+}
+
+func (s *replicaSet) check(ctx context.Context) error {
+	var errs []error
+
+	for _, r := range s.replicas {
+		err := r.db.PingContext(ctx)
+
+		r.mu.Lock()
+		if err != nil {
+			r.failures = 0
+			r.ejectedUntil = s.now().Add(s.ejectFor)
+			errs = append(errs, err)
+		} else {
+			r.failures = 0
+			r.ejectedUntil = time.Time{}
+		}
+		r.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *replicaSet) status() []ReplicaStatus {
+	if s == nil {
+		return nil
+	}
+
+	now := s.now()
+
+	result := make([]ReplicaStatus, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		r.mu.Lock()
+		result = append(result, ReplicaStatus{
+			Index:        r.index,
+			Healthy:      !now.Before(r.ejectedUntil),
+			Failures:     r.failures,
+			EjectedUntil: r.ejectedUntil,
+		})
+		r.mu.Unlock()
+	}
+
+	return result
+}
+
+func (r *replica) healthy(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return !now.Before(r.ejectedUntil)
+}
+
+func isReplicaConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return errors.Is(err, sql.ErrConnDone) || IsRetryableNetworkError(err)
+}
+
+// readReplica returns the replica that should serve a read issued with ctx.
+func (r *Runtime) readReplica(ctx context.Context) *replica {
+	if r.replicas == nil || ctx.Value(usePrimaryKey{}) != nil {
+		return nil
+	}
+
+	route, ok := ctx.Value(readQueryKey{}).(*readRoute)
+	if !ok {
+		return nil
+	}
+
+	if route.replica == nil || !route.replica.healthy(r.replicas.now()) {
+		route.replica = r.replicas.pick(ctx)
+	}
+
+	return route.replica
+}
+
+// ReplicaStatus reports the routing state of every replica configured through
+// NewClusterRuntime. It returns nil for a single-database runtime.
+func (r *Runtime) ReplicaStatus() []ReplicaStatus {
+	if r == nil {
+		return nil
+	}
+
+	return r.replicas.status()
+}
+
+// CheckReplicas pings every replica, ejecting unreachable ones for
+// ClusterConfig.EjectFor and returning healthy ones to rotation immediately.
+// Call it periodically to detect failures that reads alone would not surface.
+func (r *Runtime) CheckReplicas(ctx context.Context) error {
+	if r == nil || r.replicas == nil {
+		return nil
+	}
+
+	return r.replicas.check(ctx)
+}
+
+// ReplicaDBs returns the replica connection pools in ClusterConfig.ReplicaDSNs order.
+func (r *Runtime) ReplicaDBs() []*sql.DB {
+	if r == nil || r.replicas == nil {
+		return nil
+	}
+
+	dbs := make([]*sql.DB, 0, len(r.replicas.replicas))
+	for _, replica := range r.replicas.replicas {
+		dbs = append(dbs, replica.db)
+	}
+
+	return dbs
+}
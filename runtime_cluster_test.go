@@ -0,0 +1,298 @@
+package tsq
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newClusterTestRuntime opens a primary and two replica SQLite files. Each
+// database holds users named after itself, so reads reveal where they ran.
+// replica-1 has two rows to tell Page count/list pinning apart.
+func newClusterTestRuntime(t *testing.T, cluster ClusterConfig) *Runtime {
+	t.Helper()
+
+	dir := t.TempDir()
+	seed := map[string][]string{
+		"primary.db":   {"primary"},
+		"replica-0.db": {"replica-0"},
+		"replica-1.db": {"replica-1", "replica-1"},
+	}
+
+	for file, names := range seed {
+		db, err := sql.Open("sqlite", filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("open %s: %v", file, err)
+		}
+
+		if _, err := db.ExecContext(context.Background(), `CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			email TEXT NOT NULL UNIQUE
+		)`); err != nil {
+			t.Fatalf("create users table in %s: %v", file, err)
+		}
+
+		for i, name := range names {
+			if _, err := db.ExecContext(context.Background(), `INSERT INTO users (name, email) VALUES (?, ?)`, name, name+string(rune('a'+i))); err != nil {
+				t.Fatalf("seed %s: %v", file, err)
+			}
+		}
+
+		_ = db.Close()
+	}
+
+	cluster.PrimaryDSN = filepath.Join(dir, "primary.db")
+	if cluster.ReplicaDSNs == nil {
+		cluster.ReplicaDSNs = []string{filepath.Join(dir, "replica-0.db"), filepath.Join(dir, "replica-1.db")}
+	}
+
+	runtime, err := NewClusterRuntime("sqlite", cluster, nil)
+	if err != nil {
+		t.Fatalf("NewClusterRuntime() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = runtime.Close()
+	})
+
+	return runtime
+}
+
+func clusterUserQuery(t *testing.T) *Query[batchMutationUser] {
+	t.Helper()
+
+	cols := batchMutationUserColumns()
+
+	return mustBuild(Select(cols...).From(cols[0].Table()))
+}
+
+func listClusterUserNames(t *testing.T, ctx context.Context, exec SQLExecutor) string {
+	t.Helper()
+
+	users, err := clusterUserQuery(t).List(ctx, exec)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if len(users) == 0 {
+		t.Fatal("expected at least one user")
+	}
+
+	return users[0].Name
+}
+
+func TestNewClusterRuntimeRejectsEmptyReplicaDSN(t *testing.T) {
+	_, dsn := newSQLiteIndexTestEngine(t)
+
+	_, err := NewClusterRuntime("sqlite", ClusterConfig{PrimaryDSN: dsn, ReplicaDSNs: []string{""}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "replica dsn 0 cannot be empty") {
+		t.Fatalf("expected empty replica dsn error, got %v", err)
+	}
+}
+
+func TestClusterRuntimeRoutesReadsRoundRobin(t *testing.T) {
+	runtime := newClusterTestRuntime(t, ClusterConfig{})
+	ctx := context.Background()
+
+	var got []string
+	for range 4 {
+		got = append(got, listClusterUserNames(t, ctx, runtime))
+	}
+
+	want := "replica-0,replica-1,replica-0,replica-1"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("expected reads %s, got %v", want, got)
+	}
+
+	count, err := clusterUserQuery(t).Count(ctx, runtime)
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected Count to run on replica-0, got %d", count)
+	}
+}
+
+func TestClusterRuntimePinsPageToOneReplica(t *testing.T) {
+	runtime := newClusterTestRuntime(t, ClusterConfig{})
+	ctx := context.Background()
+
+	for range 4 {
+		page, err := clusterUserQuery(t).Page(ctx, runtime, &PageRequest{Page: 1, Size: 10})
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+
+		if page.Total != int64(len(page.Data)) {
+			t.Fatalf("expected count and list from the same replica, got total %d with %d rows", page.Total, len(page.Data))
+		}
+	}
+}
+
+func TestClusterRuntimeKeepsWritesAndPinnedReadsOnPrimary(t *testing.T) {
+	runtime := newClusterTestRuntime(t, ClusterConfig{})
+	ctx := context.Background()
+
+	if err := Insert(ctx, runtime, &batchMutationUser{Name: "written", Email: "written@example.com"}); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+
+	var count int
+	if err := runtime.DB().QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE name = 'written'`).Scan(&count); err != nil {
+		t.Fatalf("count primary rows: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected the insert on the primary, got %d rows", count)
+	}
+
+	for _, replicaDB := range runtime.ReplicaDBs() {
+		if err := replicaDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE name = 'written'`).Scan(&count); err != nil {
+			t.Fatalf("count replica rows: %v", err)
+		}
+
+		if count != 0 {
+			t.Fatalf("expected no insert on replicas, got %d rows", count)
+		}
+	}
+
+	if name := listClusterUserNames(t, UsePrimary(ctx), runtime); name != "primary" {
+		t.Fatalf("expected UsePrimary read on the primary, got %q", name)
+	}
+
+	err := runtime.WithTx(ctx, nil, func(ctx context.Context, tx SQLExecutor) error {
+		if name := listClusterUserNames(t, ctx, tx); name != "primary" {
+			t.Fatalf("expected transaction read on the primary, got %q", name)
+		}
+
+		if name := listClusterUserNames(t, ctx, runtime); name != "primary" {
+			t.Fatalf("expected runtime read inside WithTx on the primary, got %q", name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+}
+
+func TestClusterRuntimeKeepsLockingReadsOnPrimary(t *testing.T) {
+	cols := batchMutationUserColumns()
+	query := mustBuild(Select(cols...).From(cols[0].Table()).ForUpdate())
+
+	if !query.locking {
+		t.Fatal("expected ForUpdate query to be marked as locking")
+	}
+
+	if ctx := query.readContext(context.Background()); ctx.Value(readQueryKey{}) != nil {
+		t.Fatal("expected locking query to skip replica routing")
+	}
+}
+
+func TestClusterRuntimeUsesCustomBalancer(t *testing.T) {
+	var offered [][]int
+
+	runtime := newClusterTestRuntime(t, ClusterConfig{
+		Balancer: ReplicaBalancerFunc(func(_ context.Context, healthy []int) int {
+			offered = append(offered, append([]int(nil), healthy...))
+			return healthy[len(healthy)-1]
+		}),
+	})
+
+	for range 2 {
+		if name := listClusterUserNames(t, context.Background(), runtime); name != "replica-1" {
+			t.Fatalf("expected the balancer's replica, got %q", name)
+		}
+	}
+
+	if len(offered) != 2 || len(offered[0]) != 2 {
+		t.Fatalf("expected both replicas offered on each read, got %v", offered)
+	}
+}
+
+func TestClusterRuntimeEjectsFailingReplicaAndFallsBack(t *testing.T) {
+	runtime := newClusterTestRuntime(t, ClusterConfig{
+		EjectAfter: 1,
+		EjectFor:   time.Minute,
+		IsUnhealthy: func(err error) bool {
+			return err != nil && strings.Contains(err.Error(), "database is closed")
+		},
+	})
+	ctx := context.Background()
+
+	now := time.Now()
+	runtime.replicas.now = func() time.Time { return now }
+
+	_ = runtime.ReplicaDBs()[0].Close()
+
+	if name := listClusterUserNames(t, ctx, runtime); name != "primary" {
+		t.Fatalf("expected failed replica read to fall back to the primary, got %q", name)
+	}
+
+	status := runtime.ReplicaStatus()
+	if len(status) != 2 || status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("expected replica 0 ejected, got %+v", status)
+	}
+
+	if !status[0].EjectedUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected replica 0 ejected for EjectFor, got %v", status[0].EjectedUntil)
+	}
+
+	for range 2 {
+		if name := listClusterUserNames(t, ctx, runtime); name != "replica-1" {
+			t.Fatalf("expected reads on the healthy replica, got %q", name)
+		}
+	}
+
+	if err := runtime.CheckReplicas(ctx); err == nil {
+		t.Fatal("expected CheckReplicas to report the closed replica")
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if status := runtime.ReplicaStatus(); !status[0].Healthy {
+		t.Fatalf("expected replica 0 back in rotation after EjectFor, got %+v", status)
+	}
+}
+
+func TestClusterRuntimeStartsWithUnreachableReplicaEjected(t *testing.T) {
+	unreachable := filepath.Join(t.TempDir(), "missing", "replica.db")
+	runtime := newClusterTestRuntime(t, ClusterConfig{ReplicaDSNs: []string{unreachable}})
+
+	if status := runtime.ReplicaStatus(); len(status) != 1 || status[0].Healthy {
+		t.Fatalf("expected unreachable replica ejected at startup, got %+v", status)
+	}
+
+	if name := listClusterUserNames(t, context.Background(), runtime); name != "primary" {
+		t.Fatalf("expected reads on the primary while every replica is ejected, got %q", name)
+	}
+}
+
+func TestRuntimeWithoutReplicasReportsNoStatus(t *testing.T) {
+	_, dsn := newSQLiteIndexTestEngine(t)
+
+	runtime, err := NewRuntime("sqlite", dsn, nil)
+	if err != nil {
+		t.Fatalf("NewRuntime() error = %v", err)
+	}
+
+	if runtime.ReplicaStatus() != nil || runtime.ReplicaDBs() != nil {
+		t.Fatal("expected no replica state without replicas")
+	}
+
+	if err := runtime.CheckReplicas(context.Background()); err != nil {
+		t.Fatalf("CheckReplicas() error = %v", err)
+	}
+
+	if err := runtime.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := runtime.DB().PingContext(context.Background()); err == nil {
+		t.Fatal("expected Close to close the primary database")
+	}
+}
@@ -0,0 +1,462 @@
+package tsq
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache stores query results for a Runtime. Keys already encode the
+// rendered SQL, its arguments, and the invalidation generation of every table
+// the query reads, so a backend only needs expiring key/value storage: entries
+// made stale by a mutation are simply never looked up again.
+//
+// Values are Go values shared within the process; TSQ deep-copies results on
+// the way in and out, so a backend must not serialize or mutate them.
+type ResultCache interface {
+	Get(key string) (value any, ok bool)
+	Set(key string, value any, ttl time.Duration)
+}
+
+// ResultCacheStats reports result cache activity for a Runtime.
+type ResultCacheStats struct {
+	Hits          uint64 // Hits counts reads served from the cache.
+	Misses        uint64 // Misses counts cacheable reads that went to the database.
+	Invalidations uint64 // Invalidations counts table invalidations applied by mutations, commits, or InvalidateResultCache.
+}
+
+type lruResultCacheEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+type lruResultCache struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewLRUResultCache returns the in-memory ResultCache used for
+// RuntimeOptions.ResultCacheSize. It keeps at most capacity entries and drops
+// the least recently used one first.
+func NewLRUResultCache(capacity int) ResultCache {
+	return newLRUResultCache(capacity)
+}
+
+func newLRUResultCache(capacity int) *lruResultCache {
+	return &lruResultCache{
+		capacity: max(capacity, 1),
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *lruResultCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruResultCacheEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+func (c *lruResultCache) Set(key string, value any, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruResultCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruResultCacheEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruResultCacheEntry).key)
+	}
+}
+
+// resultCache holds the per-table invalidation generations for one Runtime.
+// Bumping a generation changes the key of every query that reads the table,
+// which both invalidates existing entries and discards results of reads that
+// were in flight when the mutation landed.
+type resultCache struct {
+	backend ResultCache
+
+	mu     sync.RWMutex
+	global uint64
+	tables map[string]uint64
+
+	hits          uint64
+	misses        uint64
+	invalidations uint64
+}
+
+func newResultCache(backend ResultCache, size int) *resultCache {
+	if backend == nil {
+		if size <= 0 {
+			return nil
+		}
+
+		backend = NewLRUResultCache(size)
+	}
+
+	return &resultCache{backend: backend, tables: make(map[string]uint64)}
+}
+
+func (c *resultCache) key(kind, sqlText string, args []any, tables []string) string {
+	var b strings.Builder
+
+	b.WriteString(kind)
+	b.WriteByte(0)
+	b.WriteString(sqlText)
+	b.WriteByte(0)
+
+	for _, arg := range args {
+		writeResultCacheArg(&b, arg)
+		b.WriteByte(0x1f)
+	}
+
+	c.mu.RLock()
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatUint(c.global, 10))
+
+	for _, table := range tables {
+		if gen := c.tables[table]; gen > 0 {
+			b.WriteByte(0x1f)
+			b.WriteString(table)
+			b.WriteByte('=')
+			b.WriteString(strconv.FormatUint(gen, 10))
+		}
+	}
+	c.mu.RUnlock()
+
+	return b.String()
+}
+
+func writeResultCacheArg(b *strings.Builder, arg any) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			arg = value
+		}
+	}
+
+	value := reflect.ValueOf(arg)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if !value.IsValid() {
+		b.WriteString("<nil>")
+		return
+	}
+
+	fmt.Fprintf(b, "%T:%#v", value.Interface(), value.Interface())
+}
+
+func (c *resultCache) get(key string) (any, bool) {
+	value, ok := c.backend.Get(key)
+
+	c.mu.Lock()
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+
+	return value, ok
+}
+
+func (c *resultCache) invalidate(tables ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(tables) == 0 {
+		c.global++
+		c.invalidations++
+
+		return
+	}
+
+	for _, table := range tables {
+		c.tables[table]++
+		c.invalidations++
+	}
+}
+
+func (c *resultCache) stats() ResultCacheStats {
+	if c == nil {
+		return ResultCacheStats{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return ResultCacheStats{Hits: c.hits, Misses: c.misses, Invalidations: c.invalidations}
+}
+
+// resultCacheTx collects the tables a transaction mutated; they are only
+// invalidated once the transaction commits, so other callers never cache or
+// evict based on uncommitted writes.
+type resultCacheTx struct {
+	mu     sync.Mutex
+	tables map[string]struct{}
+}
+
+func (c *resultCache) begin() *resultCacheTx {
+	if c == nil {
+		return nil
+	}
+
+	return &resultCacheTx{tables: make(map[string]struct{})}
+}
+
+func (c *resultCache) commit(tx *resultCacheTx) {
+	if c == nil || tx == nil {
+		return
+	}
+
+	tx.mu.Lock()
+	tables := make([]string, 0, len(tx.tables))
+	for table := range tx.tables {
+		tables = append(tables, table)
+	}
+	tx.mu.Unlock()
+
+	if len(tables) > 0 {
+		slices.Sort(tables)
+		c.invalidate(tables...)
+	}
+}
+
+func (tx *resultCacheTx) add(tables ...string) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	for _, table := range tables {
+		tx.tables[table] = struct{}{}
+	}
+}
+
+// resultCacheForRead returns the cache serving reads through exec. Only the
+// Runtime itself qualifies: transaction executors read their own uncommitted
+// writes, which must neither be served from nor stored in the shared cache.
+func resultCacheForRead(exec SQLExecutor) *resultCache {
+	switch exec := exec.(type) {
+	case *Runtime:
+		if exec == nil {
+			return nil
+		}
+
+		return exec.results
+	case wrappedExecutor:
+		return resultCacheForRead(exec.SQLExecutor)
+	default:
+		return nil
+	}
+}
+
+// invalidateResultCache records that a TSQ mutation through exec touched
+// tables: immediately outside a transaction, or on commit inside WithTx.
+func invalidateResultCache(exec SQLExecutor, tables ...string) {
+	provider, ok := exec.(traceProvider)
+	if !ok || provider.tsqRuntime() == nil || provider.tsqRuntime().results == nil {
+		return
+	}
+
+	if wrapped, ok := exec.(wrappedExecutor); ok && wrapped.resultTx != nil {
+		wrapped.resultTx.add(tables...)
+		return
+	}
+
+	provider.tsqRuntime().results.invalidate(tables...)
+}
+
+// cachedResult serves load from the runtime result cache when q was built
+// with BuildOptions.CacheTTL and exec is a Runtime with a result cache.
+// clone copies a result so callers never share rows with the cache.
+func (q *Query[O]) cachedResult[T any](
+	exec SQLExecutor,
+	kind string,
+	sqlText string,
+	args []any,
+	clone func(T) T,
+	load func() (T, error),
+) (T, error) {
+	cache := resultCacheForRead(exec)
+	if cache == nil || q.cacheTTL <= 0 {
+		return load()
+	}
+
+	key := cache.key(kind, sqlText, args, q.cacheTables)
+
+	if value, ok := cache.get(key); ok {
+		if result, ok := value.(T); ok {
+			return clone(result), nil
+		}
+	}
+
+	result, err := load()
+	if err != nil {
+		return result, err
+	}
+
+	cache.backend.Set(key, clone(result), q.cacheTTL)
+
+	return result, nil
+}
+
+// resultCacheTables lists every identifier in the query's SQL templates. That
+// is a superset of the tables it reads, including those behind subqueries,
+// CTEs, and set operations, so over-invalidation is the only possible error.
+func resultCacheTables(sqlTexts ...string) []string {
+	seen := make(map[string]struct{})
+
+	for _, sqlText := range sqlTexts {
+		renderSQLWithIdentifierQuoter(sqlText, func(name string) string {
+			seen[name] = struct{}{}
+			return name
+		})
+	}
+
+	tables := make([]string, 0, len(seen))
+	for name := range seen {
+		tables = append(tables, name)
+	}
+
+	slices.Sort(tables)
+
+	return tables
+}
+
+// cloneResultValue deep-copies a cached result: pointers, slices (including
+// []byte and json.RawMessage), maps, arrays, interfaces, and exported struct
+// fields get their own storage, so a caller editing a returned row never
+// changes what later cache hits return. Unexported struct fields are copied
+// by value, which is enough for value types such as time.Time. Scanned rows
+// are trees, so the copy does not track cycles.
+func cloneResultValue[T any](value T) T {
+	src := reflect.ValueOf(&value).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	copyResultValue(dst, src)
+
+	return dst.Interface().(T)
+}
+
+func copyResultValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyResultValue(dst.Elem(), src.Elem())
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := range src.Len() {
+			copyResultValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Array:
+		for i := range src.Len() {
+			copyResultValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			copyResultValue(value, iter.Value())
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+
+		value := reflect.New(src.Elem().Type()).Elem()
+		copyResultValue(value, src.Elem())
+		dst.Set(value)
+	case reflect.Struct:
+		dst.Set(src)
+
+		for i := range src.NumField() {
+			if dst.Field(i).CanSet() {
+				copyResultValue(dst.Field(i), src.Field(i))
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// InvalidateResultCache invalidates cached results of queries that read any of
+// tables, or every cached result when tables is empty. TSQ mutations do this
+// automatically; call it after writing through raw SQL or another client.
+func (r *Runtime) InvalidateResultCache(tables ...string) {
+	if r == nil {
+		return
+	}
+
+	r.results.invalidate(tables...)
+}
+
+// ResultCacheStats reports hits, misses, and invalidations of the result cache
+// enabled by RuntimeOptions.ResultCacheSize or RuntimeOptions.ResultCache. It
+// returns zero values when the cache is disabled.
+func (r *Runtime) ResultCacheStats() ResultCacheStats {
+	if r == nil {
+		return ResultCacheStats{}
+	}
+
+	return r.results.stats()
+}
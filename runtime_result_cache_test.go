@@ -0,0 +1,448 @@
+package tsq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newResultCacheTestRuntime(t *testing.T, options *RuntimeOptions) *Runtime {
+	t.Helper()
+
+	_, dsn := newSQLiteIndexTestEngine(t)
+
+	runtime, err := NewRuntime("sqlite", dsn, nil, options)
+	if err != nil {
+		t.Fatalf("NewRuntime() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = runtime.Close()
+	})
+
+	if _, err := runtime.DB().ExecContext(context.Background(), `CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		email TEXT NOT NULL UNIQUE
+	)`); err != nil {
+		t.Fatalf("create users table: %v", err)
+	}
+
+	if _, err := runtime.DB().ExecContext(context.Background(), `INSERT INTO users (name, email) VALUES ('alice', 'alice@example.com')`); err != nil {
+		t.Fatalf("seed users: %v", err)
+	}
+
+	return runtime
+}
+
+func cachedUserQuery(t *testing.T, ttl time.Duration) *Query[batchMutationUser] {
+	t.Helper()
+
+	cols := batchMutationUserColumns()
+
+	query, err := Select(cols...).From(cols[0].Table()).BuildWith(&BuildOptions{CacheTTL: ttl})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	return query
+}
+
+func countCachedUsers(t *testing.T, ctx context.Context, exec SQLExecutor, query *Query[batchMutationUser]) int {
+	t.Helper()
+
+	users, err := query.List(ctx, exec)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	return len(users)
+}
+
+func TestNewRuntimeRejectsNegativeResultCacheSize(t *testing.T) {
+	_, dsn := newSQLiteIndexTestEngine(t)
+
+	_, err := NewRuntime("sqlite", dsn, nil, &RuntimeOptions{ResultCacheSize: -1})
+	if err == nil || !strings.Contains(err.Error(), "invalid result cache size") {
+		t.Fatalf("expected invalid result cache size error, got %v", err)
+	}
+}
+
+func TestBuildRejectsNegativeCacheTTL(t *testing.T) {
+	cols := batchMutationUserColumns()
+
+	_, err := Select(cols...).From(cols[0].Table()).BuildWith(&BuildOptions{CacheTTL: -time.Second})
+	if err == nil || !strings.Contains(err.Error(), "invalid cache ttl") {
+		t.Fatalf("expected invalid cache ttl error, got %v", err)
+	}
+}
+
+func TestBuildRejectsCacheTTLOnLockingReads(t *testing.T) {
+	cols := batchMutationUserColumns()
+
+	for name, stage := range map[string]LockedStage[batchMutationUser]{
+		"for update": Select(cols...).From(cols[0].Table()).ForUpdate(),
+		"for share":  Select(cols...).From(cols[0].Table()).ForShare(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// LockedStage 上没有 BuildWith，这里直接用具体 builder 验证运行期的拒绝。
+			locked, ok := stage.(*lockedQueryBuilder[batchMutationUser])
+			if !ok {
+				t.Fatalf("expected *lockedQueryBuilder, got %T", stage)
+			}
+
+			_, err := locked.BuildWith(&BuildOptions{CacheTTL: time.Minute})
+			if err == nil || !strings.Contains(err.Error(), "cannot be combined with ForUpdate or ForShare") {
+				t.Fatalf("expected locking reads to reject a cache ttl, got %v", err)
+			}
+
+			if _, err := locked.BuildWith(nil); err != nil {
+				t.Fatalf("BuildWith(nil) error = %v", err)
+			}
+		})
+	}
+}
+
+func TestResultCacheServesRepeatedReadsUntilInvalidated(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+	query := cachedUserQuery(t, time.Minute)
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 1 {
+		t.Fatalf("expected one user, got %d", got)
+	}
+
+	if _, err := runtime.DB().ExecContext(ctx, `INSERT INTO users (name, email) VALUES ('bob', 'bob@example.com')`); err != nil {
+		t.Fatalf("raw insert: %v", err)
+	}
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 1 {
+		t.Fatalf("expected the cached result to hide a raw write, got %d users", got)
+	}
+
+	runtime.InvalidateResultCache("users")
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 2 {
+		t.Fatalf("expected InvalidateResultCache to expose the raw write, got %d users", got)
+	}
+
+	stats := runtime.ResultCacheStats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Invalidations != 1 {
+		t.Fatalf("expected 1 hit, 2 misses and 1 invalidation, got %+v", stats)
+	}
+}
+
+func TestResultCacheInvalidatedByMutations(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+	query := cachedUserQuery(t, time.Minute)
+
+	count, err := query.Count(ctx, runtime)
+	if err != nil || count != 1 {
+		t.Fatalf("Count() = %d, %v", count, err)
+	}
+
+	user := &batchMutationUser{Name: "bob", Email: "bob@example.com"}
+	if err := Insert(ctx, runtime, user); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+
+	if count, err = query.Count(ctx, runtime); err != nil || count != 2 {
+		t.Fatalf("expected Insert to invalidate Count, got %d, %v", count, err)
+	}
+
+	if err := Delete(ctx, runtime, user); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if count, err = query.Count(ctx, runtime); err != nil || count != 1 {
+		t.Fatalf("expected Delete to invalidate Count, got %d, %v", count, err)
+	}
+
+	cols := batchMutationUserColumns()
+	if err := ChunkedDeleteByPKs(ctx, runtime, cols[0].(TypedColumn[batchMutationUser, int64]), []int64{1}); err != nil {
+		t.Fatalf("ChunkedDeleteByPKs() error = %v", err)
+	}
+
+	if count, err = query.Count(ctx, runtime); err != nil || count != 0 {
+		t.Fatalf("expected ChunkedDeleteByPKs to invalidate Count, got %d, %v", count, err)
+	}
+}
+
+func TestResultCacheInvalidatesOnCommitOnly(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+	query := cachedUserQuery(t, time.Minute)
+
+	countCachedUsers(t, ctx, runtime, query)
+
+	err := runtime.WithTx(ctx, nil, func(ctx context.Context, tx SQLExecutor) error {
+		if err := Insert(ctx, tx, &batchMutationUser{Name: "bob", Email: "bob@example.com"}); err != nil {
+			return err
+		}
+
+		if got := countCachedUsers(t, ctx, tx, query); got != 2 {
+			t.Fatalf("expected the transaction to bypass the cache, got %d users", got)
+		}
+
+		if stats := runtime.ResultCacheStats(); stats.Invalidations != 0 {
+			t.Fatalf("expected no invalidation before commit, got %+v", stats)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 2 {
+		t.Fatalf("expected commit to invalidate the cached list, got %d users", got)
+	}
+
+	wantErr := errors.New("rollback")
+
+	err = runtime.WithTx(ctx, nil, func(ctx context.Context, tx SQLExecutor) error {
+		if err := Insert(ctx, tx, &batchMutationUser{Name: "carol", Email: "carol@example.com"}); err != nil {
+			return err
+		}
+
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+
+	if stats := runtime.ResultCacheStats(); stats.Invalidations != 1 {
+		t.Fatalf("expected rollback to skip invalidation, got %+v", stats)
+	}
+}
+
+func TestResultCacheReturnsCopies(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+	query := cachedUserQuery(t, time.Minute)
+
+	users, err := query.List(ctx, runtime)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	users[0].Name = "mutated"
+
+	page, err := query.Page(ctx, runtime, &PageRequest{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	page.Data[0].Name = "mutated"
+
+	for range 2 {
+		users, err = query.List(ctx, runtime)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+
+		if users[0].Name != "alice" {
+			t.Fatalf("expected cached rows to be copied, got %q", users[0].Name)
+		}
+
+		page, err = query.Page(ctx, runtime, &PageRequest{Page: 1, Size: 10})
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+
+		if page.Total != 1 || page.Data[0].Name != "alice" {
+			t.Fatalf("expected cached page rows to be copied, got %+v", page)
+		}
+	}
+
+	if stats := runtime.ResultCacheStats(); stats.Hits != 4 {
+		t.Fatalf("expected repeated List and Page to hit the cache, got %+v", stats)
+	}
+}
+
+func TestResultCacheRequiresQueryOptIn(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+	query := cachedUserQuery(t, 0)
+
+	for range 2 {
+		countCachedUsers(t, ctx, runtime, query)
+	}
+
+	if stats := runtime.ResultCacheStats(); stats != (ResultCacheStats{}) {
+		t.Fatalf("expected queries without CacheTTL to skip the cache, got %+v", stats)
+	}
+}
+
+func TestResultCacheExpiresAfterTTL(t *testing.T) {
+	backend := newLRUResultCache(16)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCache: backend})
+	ctx := context.Background()
+	query := cachedUserQuery(t, time.Minute)
+
+	countCachedUsers(t, ctx, runtime, query)
+
+	if _, err := runtime.DB().ExecContext(ctx, `INSERT INTO users (name, email) VALUES ('bob', 'bob@example.com')`); err != nil {
+		t.Fatalf("raw insert: %v", err)
+	}
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 1 {
+		t.Fatalf("expected the entry to live until its TTL, got %d users", got)
+	}
+
+	now = now.Add(time.Minute)
+
+	if got := countCachedUsers(t, ctx, runtime, query); got != 2 {
+		t.Fatalf("expected the entry to expire after its TTL, got %d users", got)
+	}
+}
+
+func TestLRUResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUResultCache(2)
+
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	cache.Get("a")
+	cache.Set("c", 3, 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Fatalf("expected %q to stay cached", key)
+		}
+	}
+}
+
+func TestResultCacheTablesIncludeSubqueryTables(t *testing.T) {
+	orders := newMockTable("orders")
+	orderUserID := newColForTable[Table, int64](orders, "user_id", "user_id", nil)
+	users := newMockTable("users")
+	userID := newColForTable[Table, int64](users, "id", "id", nil)
+
+	sub, err := BuildSubquery(Select(orderUserID).From(orders), orderUserID)
+	if err != nil {
+		t.Fatalf("BuildSubquery() error = %v", err)
+	}
+
+	query, err := Select(userID).From(users).Where(userID.In(sub)).BuildWith(&BuildOptions{CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	for _, table := range []string{"users", "orders"} {
+		found := false
+		for _, name := range query.cacheTables {
+			found = found || name == table
+		}
+
+		if !found {
+			t.Fatalf("expected %s among cache tables, got %v", table, query.cacheTables)
+		}
+	}
+}
+
+type cachedDocument struct {
+	ID      int64
+	Body    json.RawMessage
+	Payload []byte
+}
+
+func (cachedDocument) TSQOwner() {}
+
+func (cachedDocument) Table() string { return "documents" }
+
+func (cachedDocument) Cols() []SQLColumn { return SQLColumns(cachedDocumentColumns()...) }
+
+func (cachedDocument) SearchColumns() []SearchColumn { return nil }
+
+func (cachedDocument) PrimaryKeys() []string { return []string{"id"} }
+
+func (cachedDocument) AutoIncrement() bool { return true }
+
+func (cachedDocument) VersionColumn() string { return "" }
+
+func cachedDocumentColumns() []BoundColumn[cachedDocument] {
+	return []BoundColumn[cachedDocument]{
+		NewCol[cachedDocument, int64]("id", "id", func(d *cachedDocument) *int64 { return &d.ID }),
+		NewCol[cachedDocument, json.RawMessage]("body", "body", func(d *cachedDocument) *json.RawMessage { return &d.Body }),
+		NewCol[cachedDocument, []byte]("payload", "payload", func(d *cachedDocument) *[]byte { return &d.Payload }),
+	}
+}
+
+func TestResultCacheHitsDoNotShareByteSlices(t *testing.T) {
+	runtime := newResultCacheTestRuntime(t, &RuntimeOptions{ResultCacheSize: 16})
+	ctx := context.Background()
+
+	if _, err := runtime.DB().ExecContext(ctx, `CREATE TABLE documents (id INTEGER PRIMARY KEY, body BLOB NOT NULL, payload BLOB NOT NULL);
+		INSERT INTO documents (id, body, payload) VALUES (1, CAST('{"title":"cached"}' AS BLOB), x'0102')`); err != nil {
+		t.Fatalf("seed documents: %v", err)
+	}
+
+	cols := cachedDocumentColumns()
+
+	query, err := Select(cols...).From(cols[0].Table()).BuildWith(&BuildOptions{CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("BuildWith() error = %v", err)
+	}
+
+	for range 2 {
+		docs, err := query.List(ctx, runtime)
+		if err != nil || len(docs) != 1 {
+			t.Fatalf("List() = %+v, %v", docs, err)
+		}
+
+		if string(docs[0].Body) != `{"title":"cached"}` || !bytes.Equal(docs[0].Payload, []byte{1, 2}) {
+			t.Fatalf("expected the stored document, got body %s payload %v", docs[0].Body, docs[0].Payload)
+		}
+
+		// 原地改写和追加都不能影响下一次命中。
+		copy(docs[0].Body, `{"title":"edited"}`)
+		docs[0].Payload[0] = 9
+		_ = append(docs[0].Payload[:1], 7)
+	}
+
+	if stats := runtime.ResultCacheStats(); stats.Hits != 1 {
+		t.Fatalf("expected the second List to hit the cache, got %+v", stats)
+	}
+}
+
+func TestCloneResultValueCopiesReferences(t *testing.T) {
+	type row struct {
+		Tags  []string
+		Attrs map[string][]byte
+		Note  *string
+		Extra any
+		At    time.Time
+	}
+
+	note := "a"
+	original := &row{
+		Tags:  []string{"x"},
+		Attrs: map[string][]byte{"k": {1}},
+		Note:  &note,
+		Extra: []int{1},
+		At:    time.Unix(1, 0),
+	}
+
+	cloned := cloneResultValue(original)
+	cloned.Tags[0] = "y"
+	cloned.Attrs["k"][0] = 2
+	*cloned.Note = "b"
+	cloned.Extra.([]int)[0] = 2
+
+	if original.Tags[0] != "x" || original.Attrs["k"][0] != 1 || *original.Note != "a" || original.Extra.([]int)[0] != 1 {
+		t.Fatalf("expected the original to stay unchanged, got %+v", original)
+	}
+
+	if !cloned.At.Equal(original.At) {
+		t.Fatalf("expected time values to be copied, got %v", cloned.At)
+	}
+}
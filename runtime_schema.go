@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+	"github.com/tmoeish/tsq/v4/internal/ddlcompare"
 )
 
 const managedTablesRegistryName = "_tsq_managed_tables"
@@ -22,9 +23,10 @@ type tableColumnChange struct {
 }
 
 const (
-	tableColumnAdd   = "add"
-	tableColumnDrop  = "drop"
-	tableColumnAlter = "alter"
+	tableColumnAdd    = "add"
+	tableColumnDrop   = "drop"
+	tableColumnAlter  = "alter"
+	tableColumnRename = "rename"
 )
 
 func openRuntimeDB(driverName, dsn string) (*sql.DB, tsqdialect.Dialect, error) {
@@ -176,6 +178,13 @@ func (r *Runtime) applyTablePolicyForTable(ctx context.Context, table *registere
 		return fmt.Errorf("inspect table %s: %w", tableName, err)
 	}
 
+	if !found && table.RenamedFrom != "" && table.RenamedFrom != tableName {
+		current, found, err = r.renameTable(ctx, tableName, table.RenamedFrom)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !found {
 		if r.tablePolicy == SchemaPolicyValidate {
 			return &ErrTableMissing{Name: tableName}
@@ -205,6 +214,8 @@ func (r *Runtime) applyTablePolicyForTable(ctx context.Context, table *registere
 			}
 		}
 
+		// Renames are plain RENAME COLUMN statements unless a rebuild is needed
+		// anyway; the rebuild then copies data from the old column names.
 		if r.dialect.DDLAlterColumnMode() == tsqdialect.DDLAlterColumnRebuild && hasAlterColumnChange(changes) {
 			if err := r.rebuildTable(ctx, tableName, current, table.Columns); err != nil {
 				return fmt.Errorf("reconcile table %s: %w", tableName, err)
@@ -228,6 +239,41 @@ func (r *Runtime) applyTablePolicyForTable(ctx context.Context, table *registere
 	return nil
 }
 
+// renameTable applies a declared table rename when the old table still
+// exists. Policies that may not change the schema report the pending rename
+// instead of silently creating an empty table under the new name.
+func (r *Runtime) renameTable(
+	ctx context.Context,
+	tableName string,
+	oldName string,
+) ([]tsqdialect.DDLColumnSpec, bool, error) {
+	current, found, err := r.dialect.InspectTableColumns(ctx, r.db, oldName)
+	if err != nil {
+		return nil, false, fmt.Errorf("inspect table %s: %w", oldName, err)
+	}
+
+	if !found {
+		return nil, false, nil
+	}
+
+	switch r.tablePolicy {
+	case SchemaPolicyReconcile, SchemaPolicyManaged:
+	default:
+		return nil, false, fmt.Errorf("table %s schema mismatch: rename table from %s", tableName, oldName)
+	}
+
+	statement := fmt.Sprintf(
+		"ALTER TABLE %s RENAME TO %s;",
+		r.dialect.QuoteField(oldName),
+		r.dialect.QuoteField(tableName),
+	)
+	if err := r.execDDL(ctx, statement); err != nil {
+		return nil, false, fmt.Errorf("rename table %s to %s: %w", oldName, tableName, err)
+	}
+
+	return current, true, nil
+}
+
 // rebuildTable rewrites a table in place (rename, recreate, copy, drop) for
 // dialects that cannot ALTER columns directly. All statements run on a single
 // transaction: executing BEGIN/COMMIT as separate pooled Exec calls could land
@@ -253,6 +299,8 @@ func (r *Runtime) rebuildTable(
 		return err
 	}
 
+	defer r.purgeStatements()
+
 	for _, statement := range statements {
 		r.info("applied ddl", "table", tableName, "kind", "table_rebuild", "ddl", statement)
 
@@ -319,6 +367,7 @@ func (r *Runtime) applyIndexPolicyForTable(ctx context.Context, table *registere
 
 			if statement != "" {
 				r.info("applied ddl", "table", tableName, "kind", "index_create", "ddl", statement)
+				r.purgeStatements()
 			}
 
 			continue
@@ -350,6 +399,7 @@ func (r *Runtime) applyIndexPolicyForTable(ctx context.Context, table *registere
 
 			if createStatement != "" {
 				r.info("applied ddl", "table", tableName, "kind", "index_create", "ddl", createStatement)
+				r.purgeStatements()
 			}
 		}
 	}
@@ -378,6 +428,8 @@ func (r *Runtime) execDDL(ctx context.Context, statement string) error {
 
 	r.info("applied ddl", "ddl", statement)
 
+	defer r.purgeStatements()
+
 	if _, err := r.db.ExecContext(ctx, statement); err != nil {
 		return err
 	}
@@ -488,9 +540,20 @@ func diffTableColumns(
 		desiredByName[column.Name] = column
 	}
 
+	renamedColumns := resolveColumnRenames(current, desired)
+
+	renamedSources := make(map[string]struct{}, len(renamedColumns))
+	for _, oldName := range renamedColumns {
+		renamedSources[oldName] = struct{}{}
+	}
+
 	changes := make([]tableColumnChange, 0)
 
 	for _, column := range current {
+		if _, ok := renamedSources[column.Name]; ok {
+			continue
+		}
+
 		if _, ok := desiredByName[column.Name]; !ok {
 			columnCopy := column
 			changes = append(changes, tableColumnChange{kind: tableColumnDrop, before: &columnCopy})
@@ -499,6 +562,19 @@ func diffTableColumns(
 
 	for _, column := range desired {
 		currentColumn, ok := currentByName[column.Name]
+		if oldName, renamed := renamedColumns[column.Name]; renamed {
+			beforeCopy := currentByName[oldName]
+			afterCopy := column
+			changes = append(changes, tableColumnChange{
+				kind:   tableColumnRename,
+				before: &beforeCopy,
+				after:  &afterCopy,
+			})
+
+			currentColumn, ok = currentByName[oldName], true
+			currentColumn.Name = column.Name
+		}
+
 		if !ok {
 			columnCopy := column
 			changes = append(changes, tableColumnChange{kind: tableColumnAdd, after: &columnCopy})
@@ -506,7 +582,7 @@ func diffTableColumns(
 			continue
 		}
 
-		if columnsEqual(dialect, currentColumn, column) {
+		if ddlcompare.ColumnSpecsEquivalent(dialect, currentColumn, column) {
 			continue
 		}
 
@@ -520,6 +596,12 @@ func diffTableColumns(
 	}
 
 	sort.SliceStable(changes, func(i, j int) bool {
+		// Renames run first so later ALTER statements see the new names.
+		leftRename := changes[i].kind == tableColumnRename
+		if rightRename := changes[j].kind == tableColumnRename; leftRename != rightRename {
+			return leftRename
+		}
+
 		leftName := ddlColumnChangeName(changes[i])
 
 		rightName := ddlColumnChangeName(changes[j])
@@ -533,22 +615,51 @@ func diffTableColumns(
 	return changes
 }
 
-func columnsEqual(dialect tsqdialect.Dialect, left, right tsqdialect.DDLColumnSpec) bool {
-	if !tsqdialect.DDLColumnTypesEquivalent(dialect, left, right) ||
-		left.PrimaryKey != right.PrimaryKey ||
-		left.AutoIncrement != right.AutoIncrement ||
-		left.Type.Nullable != right.Type.Nullable {
-		return false
+// resolveColumnRenames maps desired column names to the existing columns they
+// were renamed from. A was:<old_name> hint only applies while the old column
+// still exists and the new one does not, so hints left in place after the
+// rename has been applied are ignored.
+func resolveColumnRenames(current, desired []tsqdialect.DDLColumnSpec) map[string]string {
+	currentNames := make(map[string]struct{}, len(current))
+	for _, column := range current {
+		currentNames[column.Name] = struct{}{}
 	}
-	// Auto-increment columns carry dialect-managed defaults (e.g. PostgreSQL
-	// SERIAL reports nextval('..._seq')) that the declared schema never
-	// states. Treating them as drift would make reconcile DROP the sequence
-	// default and break inserts, so ignore defaults for these columns.
-	if left.PrimaryKey && left.AutoIncrement {
-		return true
+
+	desiredNames := make(map[string]struct{}, len(desired))
+	for _, column := range desired {
+		desiredNames[column.Name] = struct{}{}
+	}
+
+	renames := make(map[string]string)
+	claimed := make(map[string]struct{})
+
+	for _, column := range desired {
+		oldName := column.RenamedFrom
+		if oldName == "" || oldName == column.Name {
+			continue
+		}
+
+		if _, ok := currentNames[column.Name]; ok {
+			continue
+		}
+
+		if _, ok := currentNames[oldName]; !ok {
+			continue
+		}
+
+		if _, ok := desiredNames[oldName]; ok {
+			continue
+		}
+
+		if _, ok := claimed[oldName]; ok {
+			continue
+		}
+
+		claimed[oldName] = struct{}{}
+		renames[column.Name] = oldName
 	}
 
-	return strings.EqualFold(strings.TrimSpace(left.Default), strings.TrimSpace(right.Default))
+	return renames
 }
 
 func ddlColumnChangeName(change tableColumnChange) string {
@@ -572,6 +683,8 @@ func summarizeTableColumnChanges(changes []tableColumnChange) string {
 			lines = append(lines, "drop column "+change.before.Name)
 		case tableColumnAlter:
 			lines = append(lines, "alter column "+change.after.Name)
+		case tableColumnRename:
+			lines = append(lines, "rename column "+change.before.Name+" to "+change.after.Name)
 		}
 	}
 
@@ -655,6 +768,13 @@ func renderTableColumnChanges(
 				dialect.QuoteField(tableName),
 				dialect.QuoteField(change.before.Name),
 			))
+		case tableColumnRename:
+			statements = append(statements, fmt.Sprintf(
+				"ALTER TABLE %s RENAME COLUMN %s TO %s;",
+				dialect.QuoteField(tableName),
+				dialect.QuoteField(change.before.Name),
+				dialect.QuoteField(change.after.Name),
+			))
 		case tableColumnAlter:
 			rendered := dialect.DDLAlterColumnStatements(tableName, *change.before, *change.after)
 			if len(rendered) == 0 {
@@ -691,7 +811,8 @@ func renderRebuildTableStatements(
 		return nil, err
 	}
 
-	shared := sharedColumnNames(current, desired)
+	renamedColumns := resolveColumnRenames(current, desired)
+	targets, sources := sharedColumnNames(current, desired, renamedColumns)
 	statements := []string{
 		fmt.Sprintf(
 			"ALTER TABLE %s RENAME TO %s;",
@@ -701,17 +822,20 @@ func renderRebuildTableStatements(
 		createStatement,
 	}
 
-	if len(shared) > 0 {
-		quotedColumns := make([]string, 0, len(shared))
-		for _, name := range shared {
-			quotedColumns = append(quotedColumns, dialect.QuoteField(name))
+	if len(targets) > 0 {
+		quotedTargets := make([]string, 0, len(targets))
+		quotedSources := make([]string, 0, len(sources))
+
+		for i := range targets {
+			quotedTargets = append(quotedTargets, dialect.QuoteField(targets[i]))
+			quotedSources = append(quotedSources, dialect.QuoteField(sources[i]))
 		}
 
 		statements = append(statements, fmt.Sprintf(
 			"INSERT INTO %s (%s) SELECT %s FROM %s;",
 			dialect.QuoteField(tableName),
-			strings.Join(quotedColumns, ", "),
-			strings.Join(quotedColumns, ", "),
+			strings.Join(quotedTargets, ", "),
+			strings.Join(quotedSources, ", "),
 			dialect.QuoteField(tempTable),
 		))
 	}
@@ -720,7 +844,7 @@ func renderRebuildTableStatements(
 
 	// Dropping the old table also drops its indexes; restore every secondary
 	// index that still applies, regardless of the index policy in effect.
-	statements = append(statements, renderRebuildIndexStatements(dialect, tableName, desired, existingIndexes)...)
+	statements = append(statements, renderRebuildIndexStatements(dialect, tableName, desired, existingIndexes, renamedColumns)...)
 
 	return statements, nil
 }
@@ -730,12 +854,18 @@ func renderRebuildIndexStatements(
 	tableName string,
 	desired []tsqdialect.DDLColumnSpec,
 	existingIndexes []tsqdialect.NamedIndexDefinition,
+	renamedColumns map[string]string,
 ) []string {
 	desiredNames := make(map[string]struct{}, len(desired))
 	for _, column := range desired {
 		desiredNames[column.Name] = struct{}{}
 	}
 
+	newNames := make(map[string]string, len(renamedColumns))
+	for newName, oldName := range renamedColumns {
+		newNames[oldName] = newName
+	}
+
 	statements := make([]string, 0, len(existingIndexes))
 
 	for _, idx := range existingIndexes {
@@ -749,6 +879,10 @@ func renderRebuildIndexStatements(
 		applicable := true
 
 		for _, field := range idx.Fields {
+			if newName, ok := newNames[field]; ok {
+				field = newName
+			}
+
 			if _, ok := desiredNames[field]; !ok {
 				applicable = false
 				break
@@ -767,20 +901,33 @@ func renderRebuildIndexStatements(
 	return statements
 }
 
-func sharedColumnNames(current, desired []tsqdialect.DDLColumnSpec) []string {
+// sharedColumnNames returns the desired columns that keep data across a
+// rebuild, paired with the existing columns they are copied from.
+func sharedColumnNames(
+	current, desired []tsqdialect.DDLColumnSpec,
+	renamedColumns map[string]string,
+) ([]string, []string) {
 	currentByName := make(map[string]struct{}, len(current))
 	for _, column := range current {
 		currentByName[column.Name] = struct{}{}
 	}
 
-	shared := make([]string, 0, len(desired))
+	targets := make([]string, 0, len(desired))
+	sources := make([]string, 0, len(desired))
+
 	for _, column := range desired {
-		if _, ok := currentByName[column.Name]; ok {
-			shared = append(shared, column.Name)
+		source := column.Name
+		if oldName, ok := renamedColumns[column.Name]; ok {
+			source = oldName
+		}
+
+		if _, ok := currentByName[source]; ok {
+			targets = append(targets, column.Name)
+			sources = append(sources, source)
 		}
 	}
 
-	return shared
+	return targets, sources
 }
 
 func containsString(items []string, target string) bool {
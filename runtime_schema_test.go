@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+	"github.com/tmoeish/tsq/v4/internal/ddlcompare"
 )
 
 type recordingLogger struct {
@@ -225,7 +226,7 @@ func TestColumnsEqualIgnoresAutoIncrementSequenceDefault(t *testing.T) {
 		AutoIncrement: true,
 	}
 
-	if !columnsEqual(dialect, inspected, declared) {
+	if !ddlcompare.ColumnSpecsEquivalent(dialect, inspected, declared) {
 		t.Fatal("auto-increment sequence default must not be treated as schema drift")
 	}
 }
@@ -359,6 +360,119 @@ func TestNewRuntimeReconcileRebuildPreservesDataAndIndexes(t *testing.T) {
 	}
 }
 
+func TestNewRuntimeReconcileAppliesRenameHints(t *testing.T) {
+	db, dsn := newSQLiteIndexTestEngine(t)
+	statements := []string{
+		`CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, age INTEGER, full_name VARCHAR(120))`,
+		`CREATE INDEX idx_users_name ON accounts(full_name)`,
+		`INSERT INTO accounts (age, full_name) VALUES (30, 'amy')`,
+	}
+	for _, statement := range statements {
+		if _, err := db.DB().ExecContext(context.Background(), statement); err != nil {
+			t.Fatalf("failed to execute setup statement %q: %v", statement, err)
+		}
+	}
+
+	table, _ := newStrictMockTable("users", "id", "years", "name")
+	registration := TableRegistration{
+		Table:       table,
+		RenamedFrom: "accounts",
+		Columns: []tsqdialect.DDLColumnSpec{
+			{
+				Name:          "id",
+				Type:          tsqdialect.DDLColumnType{Kind: tsqdialect.DDLColumnKindInt, Bits: 64},
+				PrimaryKey:    true,
+				AutoIncrement: true,
+			},
+			{
+				// Renamed and retyped: SQLite has to rebuild and copy age into years.
+				Name:        "years",
+				Type:        tsqdialect.DDLColumnType{Kind: tsqdialect.DDLColumnKindString, Size: 60, Nullable: true},
+				RenamedFrom: "age",
+			},
+			{
+				Name:        "name",
+				Type:        tsqdialect.DDLColumnType{Kind: tsqdialect.DDLColumnKindString, Size: 120, Nullable: true},
+				RenamedFrom: "full_name",
+			},
+		},
+	}
+
+	validateErr := func() error {
+		_, err := NewRuntime("sqlite", dsn, []TableRegistration{registration}, &RuntimeOptions{TablePolicy: SchemaPolicyValidate})
+		return err
+	}()
+	if validateErr == nil || !strings.Contains(validateErr.Error(), "rename table from accounts") {
+		t.Fatalf("expected validate to report the pending table rename, got %v", validateErr)
+	}
+
+	runtime, err := NewRuntime(
+		"sqlite",
+		dsn,
+		[]TableRegistration{registration},
+		&RuntimeOptions{TablePolicy: SchemaPolicyReconcile, IndexPolicy: SchemaPolicyManual},
+	)
+	if err != nil {
+		t.Fatalf("NewRuntime() error = %v", err)
+	}
+
+	if _, found, err := runtime.SQLDialect().InspectTableColumns(context.Background(), runtime, "accounts"); err != nil || found {
+		t.Fatalf("expected accounts to be renamed away, found=%v error = %v", found, err)
+	}
+
+	var name, years string
+	if err := runtime.QueryRowContext(context.Background(), `SELECT name, years FROM users`).Scan(&name, &years); err != nil {
+		t.Fatalf("expected renames to preserve row data: %v", err)
+	}
+	if name != "amy" || years != "30" {
+		t.Fatalf("unexpected row data after renames: name=%q years=%q", name, years)
+	}
+
+	definition, found := inspectRegisteredIndex(t, runtime, "users", "idx_users_name")
+	if !found || len(definition.Fields) != 1 || definition.Fields[0] != "name" {
+		t.Fatalf("expected rebuild to restore the index on the renamed column, got %+v found=%v", definition, found)
+	}
+
+	// Hints left in place after the rename must not produce more DDL.
+	logger := &recordingLogger{}
+	if _, err := NewRuntime(
+		"sqlite",
+		dsn,
+		[]TableRegistration{registration},
+		&RuntimeOptions{TablePolicy: SchemaPolicyReconcile, IndexPolicy: SchemaPolicyManual, Logger: logger},
+	); err != nil {
+		t.Fatalf("second NewRuntime() error = %v", err)
+	}
+	if ddl := logger.count("applied ddl"); ddl != 0 {
+		t.Fatalf("expected reconcile to converge after renames, got %d DDL statements", ddl)
+	}
+}
+
+func TestDiffTableColumnsRendersRenameBeforeAlter(t *testing.T) {
+	dialect := tsqdialect.PostgresDialect{}
+	current := []tsqdialect.DDLColumnSpec{
+		{Name: "age", Type: tsqdialect.DDLColumnType{Kind: tsqdialect.DDLColumnKindInt, Bits: 32}},
+	}
+	desired := []tsqdialect.DDLColumnSpec{
+		{Name: "years", Type: tsqdialect.DDLColumnType{Kind: tsqdialect.DDLColumnKindInt, Bits: 64}, RenamedFrom: "age"},
+	}
+
+	statements, err := renderTableColumnChanges(dialect, "users", diffTableColumns(dialect, current, desired))
+	if err != nil {
+		t.Fatalf("renderTableColumnChanges() error = %v", err)
+	}
+
+	if len(statements) < 2 || statements[0] != `ALTER TABLE "users" RENAME COLUMN "age" TO "years";` {
+		t.Fatalf("expected the rename to run first, got %q", statements)
+	}
+
+	for _, statement := range statements[1:] {
+		if !strings.Contains(statement, `"years"`) || strings.Contains(statement, "DROP COLUMN") {
+			t.Fatalf("expected follow-up statements to alter the renamed column, got %q", statements)
+		}
+	}
+}
+
 func TestResolveRuntimeDialectRejectsLegacySQLite3DriverName(t *testing.T) {
 	_, err := resolveRuntimeDialect("sqlite3")
 	if err == nil {
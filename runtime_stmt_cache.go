@@ -0,0 +1,318 @@
+package tsq
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+)
+
+// StatementCacheStats reports prepared-statement cache activity for a Runtime.
+type StatementCacheStats struct {
+	Hits      uint64 // Hits counts executions served by an already prepared statement.
+	Misses    uint64 // Misses counts executions that had to prepare a new statement.
+	Evictions uint64 // Evictions counts statements dropped by the LRU bound or by invalidation.
+	Size      int    // Size is the number of prepared statements currently cached.
+}
+
+type stmtCacheKey struct {
+	dialect tsqdialect.Name
+	query   string
+}
+
+type stmtCacheEntry struct {
+	key     stmtCacheKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is a per-Runtime LRU of server-side prepared statements keyed by
+// rendered SQL. Entries are reference counted: an evicted statement is only
+// closed once no in-flight call still holds it, so eviction under concurrency
+// never surfaces "statement is closed" to callers.
+type stmtCache struct {
+	db       *sql.DB
+	dialect  tsqdialect.Name
+	capacity int
+
+	mu        sync.Mutex
+	entries   map[stmtCacheKey]*list.Element
+	order     *list.List
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func newStmtCache(db *sql.DB, dialect tsqdialect.Dialect, capacity int) *stmtCache {
+	if db == nil || capacity <= 0 {
+		return nil
+	}
+
+	name := tsqdialect.Unknown
+	if dialect != nil {
+		name = dialect.Name()
+	}
+
+	return &stmtCache{
+		db:       db,
+		dialect:  name,
+		capacity: capacity,
+		entries:  make(map[stmtCacheKey]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *stmtCache) acquire(ctx context.Context, query string) (*stmtCacheEntry, error) {
+	key := stmtCacheKey{dialect: c.dialect, query: query}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*stmtCacheEntry)
+		entry.refs++
+		c.hits++
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+
+		return entry, nil
+	}
+	c.mu.Unlock()
+
+	// Prepare outside the lock: a slow round trip must not serialize every
+	// other statement lookup on this runtime.
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.misses++
+
+	if elem, ok := c.entries[key]; ok {
+		// Another caller prepared the same SQL concurrently; keep theirs.
+		entry := elem.Value.(*stmtCacheEntry)
+		entry.refs++
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+
+		_ = stmt.Close()
+
+		return entry, nil
+	}
+
+	entry := &stmtCacheEntry{key: key, stmt: stmt, refs: 1}
+	c.entries[key] = c.order.PushFront(entry)
+
+	var closable []*sql.Stmt
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if stmt := c.evictLocked(oldest); stmt != nil {
+			closable = append(closable, stmt)
+		}
+	}
+	c.mu.Unlock()
+
+	closeStmts(closable)
+
+	return entry, nil
+}
+
+func (c *stmtCache) release(entry *stmtCacheEntry) {
+	c.mu.Lock()
+	entry.refs--
+
+	var closable *sql.Stmt
+	if entry.evicted && entry.refs == 0 {
+		closable = entry.stmt
+	}
+	c.mu.Unlock()
+
+	if closable != nil {
+		_ = closable.Close()
+	}
+}
+
+// evictLocked removes elem from the cache and returns its statement when it can
+// be closed immediately. Callers must hold c.mu.
+func (c *stmtCache) evictLocked(elem *list.Element) *sql.Stmt {
+	entry := c.order.Remove(elem).(*stmtCacheEntry)
+	delete(c.entries, entry.key)
+
+	entry.evicted = true
+	c.evictions++
+
+	if entry.refs > 0 {
+		return nil
+	}
+
+	return entry.stmt
+}
+
+// purge drops every cached statement. Schema changes can invalidate the
+// server-side plan or result shape of a prepared statement, so DDL issued by
+// the runtime always purges the cache.
+func (c *stmtCache) purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+
+	var closable []*sql.Stmt
+	for c.order.Len() > 0 {
+		if stmt := c.evictLocked(c.order.Back()); stmt != nil {
+			closable = append(closable, stmt)
+		}
+	}
+	c.mu.Unlock()
+
+	closeStmts(closable)
+}
+
+func (c *stmtCache) stats() StatementCacheStats {
+	if c == nil {
+		return StatementCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return StatementCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+	}
+}
+
+// run executes fn with the cached statement for query, rebound to tx when tx is
+// non-nil. prepared is false when the statement could not be prepared; callers
+// then fall back to unprepared execution, which reports the real error.
+func (c *stmtCache) run[T any](
+	ctx context.Context,
+	tx *sql.Tx,
+	query string,
+	fn func(*sql.Stmt) (T, error),
+) (_ T, prepared bool, _ error) {
+	var zero T
+
+	entry, err := c.acquire(ctx, query)
+	if err != nil {
+		return zero, false, nil
+	}
+	defer c.release(entry)
+
+	stmt := entry.stmt
+	if tx != nil {
+		// A Tx-bound copy reuses the connection-level preparation when one
+		// exists and is closed automatically when the transaction ends.
+		stmt = tx.StmtContext(ctx, stmt)
+	}
+
+	result, err := fn(stmt)
+
+	return result, true, err
+}
+
+func closeStmts(stmts []*sql.Stmt) {
+	for _, stmt := range stmts {
+		_ = stmt.Close()
+	}
+}
+
+// stmtCacheTx routes transaction statements through the runtime statement cache.
+type stmtCacheTx struct {
+	tx    *sql.Tx
+	cache *stmtCache
+}
+
+var _ SQLExecutor = stmtCacheTx{}
+
+func (t stmtCacheTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, prepared, err := t.cache.run(ctx, t.tx, query, func(stmt *sql.Stmt) (*sql.Rows, error) {
+		return stmt.QueryContext(ctx, args...)
+	})
+	if prepared {
+		return rows, err
+	}
+
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t stmtCacheTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	row, prepared, _ := t.cache.run(ctx, t.tx, query, func(stmt *sql.Stmt) (*sql.Row, error) {
+		return stmt.QueryRowContext(ctx, args...), nil
+	})
+	if prepared {
+		return row
+	}
+
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (t stmtCacheTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, prepared, err := t.cache.run(ctx, t.tx, query, func(stmt *sql.Stmt) (sql.Result, error) {
+		return stmt.ExecContext(ctx, args...)
+	})
+	if prepared {
+		return result, err
+	}
+
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (r *Runtime) txExecutor(tx *sql.Tx) SQLExecutor {
+	if r.stmts == nil {
+		return tx
+	}
+
+	return stmtCacheTx{tx: tx, cache: r.stmts}
+}
+
+// StatementCacheStats reports hits, misses, and evictions of the prepared-statement
+// cache enabled by RuntimeOptions.StatementCacheSize, summed across the primary
+// and any replicas. It returns zero values when the cache is disabled.
+func (r *Runtime) StatementCacheStats() StatementCacheStats {
+	if r == nil {
+		return StatementCacheStats{}
+	}
+
+	total := r.stmts.stats()
+
+	if r.replicas != nil {
+		for _, replica := range r.replicas.replicas {
+			stats := replica.stmts.stats()
+			total.Hits += stats.Hits
+			total.Misses += stats.Misses
+			total.Evictions += stats.Evictions
+			total.Size += stats.Size
+		}
+	}
+
+	return total
+}
+
+// ResetStatementCache closes every cached prepared statement. Call it after
+// running DDL outside the runtime, such as external migrations, so later
+// executions re-prepare against the new schema.
+func (r *Runtime) ResetStatementCache() {
+	if r == nil {
+		return
+	}
+
+	r.purgeStatements()
+}
+
+// purgeStatements drops cached statements on the primary and every replica:
+// schema changes replicate, so replica plans go stale along with the primary's.
+func (r *Runtime) purgeStatements() {
+	r.stmts.purge()
+
+	if r.replicas != nil {
+		for _, replica := range r.replicas.replicas {
+			replica.stmts.purge()
+		}
+	}
+}
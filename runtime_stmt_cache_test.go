@@ -0,0 +1,231 @@
+package tsq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newStmtCacheTestRuntime(t *testing.T, size int) *Runtime {
+	t.Helper()
+
+	_, dsn := newSQLiteIndexTestEngine(t)
+	runtime, err := NewRuntime("sqlite", dsn, nil, &RuntimeOptions{StatementCacheSize: size})
+	if err != nil {
+		t.Fatalf("NewRuntime() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = runtime.DB().Close()
+	})
+
+	if _, err := runtime.DB().ExecContext(context.Background(), `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatalf("create users table: %v", err)
+	}
+
+	return runtime
+}
+
+func TestNewRuntimeRejectsNegativeStatementCacheSize(t *testing.T) {
+	_, dsn := newSQLiteIndexTestEngine(t)
+
+	_, err := NewRuntime("sqlite", dsn, nil, &RuntimeOptions{StatementCacheSize: -1})
+	if err == nil || !strings.Contains(err.Error(), "invalid statement cache size") {
+		t.Fatalf("expected invalid statement cache size error, got %v", err)
+	}
+}
+
+func TestRuntimeStatementCacheDisabledByDefault(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 0)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := runtime.ExecContext(ctx, `INSERT INTO users (name) VALUES (?)`, "alice"); err != nil {
+			t.Fatalf("ExecContext() error = %v", err)
+		}
+	}
+
+	if stats := runtime.StatementCacheStats(); stats != (StatementCacheStats{}) {
+		t.Fatalf("expected zero stats with the cache disabled, got %+v", stats)
+	}
+}
+
+func TestRuntimeStatementCacheCountsHitsAndMisses(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 4)
+	ctx := context.Background()
+
+	if _, err := runtime.ExecContext(ctx, `INSERT INTO users (id, name) VALUES (?, ?)`, 1, "alice"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	for range 3 {
+		var name string
+		if err := runtime.QueryRowContext(ctx, `SELECT name FROM users WHERE id = ?`, 1).Scan(&name); err != nil {
+			t.Fatalf("QueryRowContext() error = %v", err)
+		}
+		if name != "alice" {
+			t.Fatalf("expected alice, got %q", name)
+		}
+	}
+
+	stats := runtime.StatementCacheStats()
+	if stats.Misses != 2 || stats.Hits != 2 || stats.Size != 2 {
+		t.Fatalf("expected 2 misses, 2 hits and 2 cached statements, got %+v", stats)
+	}
+}
+
+func TestRuntimeStatementCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 2)
+	ctx := context.Background()
+
+	queries := []string{
+		`SELECT COUNT(*) FROM users`,
+		`SELECT COUNT(*) FROM users WHERE id > 0`,
+		`SELECT COUNT(*) FROM users`,
+		`SELECT COUNT(*) FROM users WHERE id > 1`,
+		`SELECT COUNT(*) FROM users`,
+	}
+	for _, query := range queries {
+		var count int64
+		if err := runtime.QueryRowContext(ctx, query).Scan(&count); err != nil {
+			t.Fatalf("QueryRowContext(%q) error = %v", query, err)
+		}
+	}
+
+	stats := runtime.StatementCacheStats()
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Evictions != 1 || stats.Size != 2 {
+		t.Fatalf("expected the recently used statement to survive eviction, got %+v", stats)
+	}
+}
+
+func TestRuntimeStatementCacheRebindsInsideTransactions(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 4)
+	ctx := context.Background()
+
+	err := runtime.WithTx(ctx, nil, func(ctx context.Context, tx SQLExecutor) error {
+		for i := range 3 {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO users (name) VALUES (?)`, fmt.Sprintf("user-%d", i)); err != nil {
+				return err
+			}
+		}
+
+		var count int64
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+			return err
+		}
+		if count != 3 {
+			return fmt.Errorf("expected uncommitted rows to be visible inside the transaction, got %d", count)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	rows, err := runtime.QueryContext(ctx, `SELECT name FROM users ORDER BY id`)
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		names = append(names, name)
+	}
+	if len(names) != 3 {
+		t.Fatalf("expected committed rows outside the transaction, got %v", names)
+	}
+
+	stats := runtime.StatementCacheStats()
+	if stats.Hits != 2 || stats.Misses != 3 {
+		t.Fatalf("expected transaction statements to share the runtime cache, got %+v", stats)
+	}
+}
+
+func TestRuntimeStatementCacheInvalidatedByDDL(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 4)
+	ctx := context.Background()
+
+	var count int64
+	if err := runtime.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+
+	if err := runtime.execDDL(ctx, `ALTER TABLE users ADD COLUMN email TEXT`); err != nil {
+		t.Fatalf("execDDL() error = %v", err)
+	}
+
+	if stats := runtime.StatementCacheStats(); stats.Size != 0 || stats.Evictions != 1 {
+		t.Fatalf("expected runtime DDL to purge the cache, got %+v", stats)
+	}
+
+	if err := runtime.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+
+	runtime.ResetStatementCache()
+
+	if stats := runtime.StatementCacheStats(); stats.Size != 0 || stats.Evictions != 2 {
+		t.Fatalf("expected ResetStatementCache to purge the cache, got %+v", stats)
+	}
+}
+
+func TestRuntimeStatementCacheConcurrentEviction(t *testing.T) {
+	runtime := newStmtCacheTestRuntime(t, 1)
+	ctx := context.Background()
+
+	if _, err := runtime.ExecContext(ctx, `INSERT INTO users (id, name) VALUES (1, 'alice')`); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+
+	for worker := range 8 {
+		wg.Go(func() {
+			for i := range 20 {
+				query := fmt.Sprintf(`SELECT name FROM users WHERE id = ? AND %d = %d`, (worker+i)%3, (worker+i)%3)
+
+				rows, err := runtime.QueryContext(ctx, query, 1)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				for rows.Next() {
+					var name string
+					if err := rows.Scan(&name); err != nil {
+						_ = rows.Close()
+						errs <- err
+
+						return
+					}
+				}
+
+				if err := rows.Close(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent cached query error = %v", err)
+	}
+
+	if stats := runtime.StatementCacheStats(); stats.Size != 1 {
+		t.Fatalf("expected the cache to respect its capacity, got %+v", stats)
+	}
+}
@@ -39,7 +39,7 @@ GENERATED_PATHS: Final = frozenset(
 )
 
 # 用户可见的公开契约。改到这里就意味着使用者读的东西变了。
-PUBLIC_API_ROOTS: Final = ("dialect",)
+PUBLIC_API_ROOTS: Final = ("dialect", "tsqtest")
 
 # 面向 TSQ 使用者的技能（仓库根 skills/），与面向本仓开发者的技能（.agents/skills/）。
 USER_SKILL_DIR: Final = Path("skills/tsq")
@@ -79,3 +79,28 @@ func BenchmarkComplexSQLRender(b *testing.B) {
 		_ = renderSQLForDialect(raw, PostgresDialect{})
 	}
 }
+
+// BenchmarkQueryRenderSQL_Uncached measures rendering a built query's SQL without the render cache
+func BenchmarkQueryRenderSQL_Uncached(b *testing.B) {
+	query := newRenderCacheTestQuery(b)
+	query.renders = nil
+	runtime := newRuntimeWithDB(nil, PostgresDialect{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = query.renderSQL(runtime, query.listSQL)
+	}
+}
+
+// BenchmarkQueryRenderSQL_Cached measures rendering a built query's SQL through the render cache
+func BenchmarkQueryRenderSQL_Cached(b *testing.B) {
+	query := newRenderCacheTestQuery(b)
+	runtime := newRuntimeWithDB(nil, PostgresDialect{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = query.renderSQL(runtime, query.listSQL)
+	}
+}
@@ -57,9 +57,10 @@ type TableIndex struct {
 
 // TableRegistration describes one table plus its declared indexes for runtime bootstrap.
 type TableRegistration struct {
-	Table   Table                      // Table is the physical table metadata.
-	Columns []tsqdialect.DDLColumnSpec // Columns declares the physical column schema owned by Table.
-	Indexes []TableIndex               // Indexes declares the indexes owned by Table.
+	Table       Table                      // Table is the physical table metadata.
+	Columns     []tsqdialect.DDLColumnSpec // Columns declares the physical column schema owned by Table.
+	Indexes     []TableIndex               // Indexes declares the indexes owned by Table.
+	RenamedFrom string                     // RenamedFrom is the previous physical table name; reconcile renames it instead of creating an empty table.
 }
 
 // ErrIndexMissing reports that an expected index was not found.
@@ -119,6 +120,16 @@ type RuntimeOptions struct {
 	IndexPolicy SchemaPolicy // IndexPolicy chooses how TSQ manages declared indexes during NewRuntime.
 	Tracers     []Tracer     // Tracers configures the runtime's tracer chain during NewRuntime.
 	Logger      Logger       // Logger receives schema bootstrap decisions and executed DDL.
+	// StatementCacheSize enables a per-runtime LRU of server-side prepared statements
+	// keyed by rendered SQL. Zero disables the cache; see Runtime.StatementCacheStats.
+	StatementCacheSize int
+	// ResultCacheSize enables an in-memory LRU of query results holding at most
+	// this many entries. Only queries built with BuildOptions.CacheTTL use it.
+	// Zero disables the cache unless ResultCache is set.
+	ResultCacheSize int
+	// ResultCache replaces the in-memory LRU with a custom backend and enables the
+	// result cache regardless of ResultCacheSize.
+	ResultCache ResultCache
 	// IdentifierValidationMode controls how to handle identifier length violations:
 	// "strict" = fail if any identifier exceeds dialect limits (default for most dialects)
 	// "warn"   = log warnings but allow (for permissive databases)
@@ -9,8 +9,9 @@ import (
 
 type registeredTable struct {
 	Table
-	Columns []tsqdialect.DDLColumnSpec
-	Indexes []TableIndex
+	Columns     []tsqdialect.DDLColumnSpec
+	Indexes     []TableIndex
+	RenamedFrom string
 }
 
 func buildRegisteredTables(registrations []TableRegistration) ([]*registeredTable, error) {
@@ -47,9 +48,10 @@ func buildRegisteredTables(registrations []TableRegistration) ([]*registeredTabl
 		}
 
 		tables[key] = &registeredTable{
-			Table:   table,
-			Columns: cloneDDLColumnSpecs(registration.Columns),
-			Indexes: cloneTableIndexes(registration.Indexes),
+			Table:       table,
+			Columns:     cloneDDLColumnSpecs(registration.Columns),
+			Indexes:     cloneTableIndexes(registration.Indexes),
+			RenamedFrom: registration.RenamedFrom,
 		}
 	}
 
@@ -89,6 +89,23 @@ func TestBuildRegisteredTablesReturnsDeterministicOrder(t *testing.T) {
 	}
 }
 
+func TestRuntimeTablesReturnsRegistrationCopies(t *testing.T) {
+	runtime := &Runtime{tables: []*registeredTable{
+		{Table: newMockTable("accounts")},
+		{Table: newMockTable("users"), Indexes: []TableIndex{{Name: "ux_users_name", Fields: []string{"name"}, Unique: true}}},
+	}}
+
+	tables := runtime.Tables()
+	if len(tables) != 2 || tables[0].Table.Table() != "accounts" || tables[1].Table.Table() != "users" {
+		t.Fatalf("expected registrations in table order, got %+v", tables)
+	}
+
+	tables[1].Indexes[0].Fields[0] = "changed"
+	if got := runtime.Tables()[1].Indexes[0].Fields[0]; got != "name" {
+		t.Fatalf("expected Tables() to return copies, got index field %q", got)
+	}
+}
+
 func newSQLiteIndexTestEngine(t *testing.T) (*Runtime, string) {
 	t.Helper()
 
@@ -0,0 +1,649 @@
+// Package tsqtest seeds and resets databases behind a tsq.Runtime for
+// integration tests.
+package tsqtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tmoeish/tsq/v4"
+	tsqdialect "github.com/tmoeish/tsq/v4/dialect"
+)
+
+// fixtureTemplatePattern 匹配字符串值里的 {{ now }}、{{ now -24h }} 和 {{ sequence }}。
+var fixtureTemplatePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_]\w*)\s*([^{}]*?)\s*\}\}`)
+
+// fixtureRow 是一行待插入的数据，记下来源以便报错时指明文件和行号。
+type fixtureRow struct {
+	file   string
+	index  int
+	values map[string]any
+}
+
+// Option configures LoadFixtures.
+type Option func(*options)
+
+type options struct {
+	order []string
+}
+
+// WithOrder gives the order in which LoadFixtures inserts tables instead of
+// inferring it from <name>_id columns. Tables are named as in fixture files.
+// Every table loaded must be listed; names of other tables are ignored.
+func WithOrder(tables ...string) Option {
+	return func(o *options) {
+		o.order = tables
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// LoadFixtures inserts the rows in every .yaml, .yml and .json file of fsys
+// into the tables registered with runtime, in one transaction.
+//
+// Each file maps table names to lists of rows, and each row maps JSON field
+// names (or column names, for fields hidden from JSON) to values:
+//
+//	learner:
+//	  - name: Alice
+//	    email: "alice{{ sequence }}@example.test"
+//	    created_at: "{{ now -24h }}"
+//
+// Columns a row leaves out get their database defaults. Lists and objects are
+// stored as JSON: as bytes in columns of the bytes kind (such as
+// json.RawMessage fields), otherwise as text. A string value may use two templates: {{ now }} is the
+// time LoadFixtures started, in UTC and truncated to the second, optionally
+// shifted by a time.ParseDuration offset such as {{ now -1h30m }}; {{ sequence }}
+// is the 1-based position of the row among the table's fixture rows. A value
+// that is exactly one template keeps its type (time.Time or int64); inside
+// other text now renders as RFC 3339.
+//
+// Files are read in lexical path order and rows keep their file order. TSQ
+// does not record foreign keys, so unless an order is given the insert order
+// is inferred from column names: a table with a <name>_id column comes after
+// the table named <name>, <name>s, <name>es or, for a name ending in y, the
+// plural ending in ies. A <name>_id column that names no registered table is
+// an error; give the order with WithOrder, or with a top-level _order list of
+// table names in a fixture file:
+//
+//	_order: [instructor, track, course, learner, enrollment]
+//
+// WithOrder takes precedence over _order. On PostgreSQL, the sequence of an
+// auto-increment primary key given explicitly in fixtures is moved past the
+// largest inserted value.
+func LoadFixtures(ctx context.Context, runtime *tsq.Runtime, fsys fs.FS, opts ...Option) error {
+	if runtime == nil {
+		return errors.New("tsqtest: runtime cannot be nil")
+	}
+
+	o := newOptions(opts)
+
+	rows, order, err := readFixtureFiles(fsys)
+	if err != nil {
+		return err
+	}
+
+	if o.order == nil {
+		o.order = order
+	}
+
+	registered := make(map[string]tsq.TableRegistration)
+	all := make([]tsq.Table, 0, len(runtime.Tables()))
+
+	for _, registration := range runtime.Tables() {
+		registered[tableKey(registration.Table)] = registration
+		all = append(all, registration.Table)
+	}
+
+	tables := make([]tsq.Table, 0, len(rows))
+	for key := range rows {
+		registration, ok := registered[key]
+		if !ok {
+			return fmt.Errorf("tsqtest: %s: table %s is not registered with the runtime", rows[key][0].file, key)
+		}
+
+		tables = append(tables, registration.Table)
+	}
+
+	tables, err = orderTables(tables, all, o.order)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	dialect := runtime.SQLDialect()
+
+	err = runtime.WithTx(ctx, nil, func(ctx context.Context, tx tsq.SQLExecutor) error {
+		for _, table := range tables {
+			registration := registered[tableKey(table)]
+			if err := insertFixtureRows(ctx, tx, dialect, registration, rows[tableKey(table)], now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateTables(runtime, tables)
+
+	return nil
+}
+
+// Truncate empties tables, or every table registered with runtime when none
+// are given, using the dialect's TruncateClause. Tables are emptied in the
+// reverse of the order inferred from <name>_id columns. TSQ records no foreign
+// keys, so a <name>_id column that names no registered table does not affect
+// the order here; tables without a dependency between them are emptied in
+// name order. Auto-increment counters are left as that clause leaves them.
+func Truncate(ctx context.Context, runtime *tsq.Runtime, tables ...tsq.Table) error {
+	if runtime == nil {
+		return errors.New("tsqtest: runtime cannot be nil")
+	}
+
+	if len(tables) == 0 {
+		for _, registration := range runtime.Tables() {
+			tables = append(tables, registration.Table)
+		}
+	}
+
+	ordered, err := dependencyOrder(tables)
+	if err != nil {
+		return err
+	}
+
+	dialect := runtime.SQLDialect()
+
+	for _, table := range slices.Backward(ordered) {
+		query := dialect.TruncateClause() + " " + quoteTable(dialect, table)
+		if _, err := runtime.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("tsqtest: truncate %s: %w", tableKey(table), err)
+		}
+	}
+
+	invalidateTables(runtime, ordered)
+
+	return nil
+}
+
+// readFixtureFiles 按路径字典序读取 fsys 里的全部 fixture 文件，按表名合并各文件的行，
+// 并返回文件里的 _order；多个文件都写 _order 时必须一致。
+func readFixtureFiles(fsys fs.FS) (map[string][]fixtureRow, []string, error) {
+	if fsys == nil {
+		return nil, nil, errors.New("tsqtest: fixture file system cannot be nil")
+	}
+
+	var files []string
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch path.Ext(name) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, name)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("tsqtest: read fixtures: %w", err)
+	}
+
+	slices.Sort(files)
+
+	rows := make(map[string][]fixtureRow)
+
+	var (
+		order     []string
+		orderFile string
+	)
+
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tsqtest: %w", err)
+		}
+
+		var tables map[string]any
+		if path.Ext(file) == ".json" {
+			dec := json.NewDecoder(bytes.NewReader(content))
+			dec.UseNumber()
+			err = dec.Decode(&tables)
+		} else {
+			err = yaml.Unmarshal(content, &tables)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("tsqtest: %s: %w", file, err)
+		}
+
+		if value, ok := tables["_order"]; ok {
+			delete(tables, "_order")
+
+			fileOrder, err := fixtureOrder(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("tsqtest: %s: %w", file, err)
+			}
+
+			if orderFile != "" && !slices.Equal(order, fileOrder) {
+				return nil, nil, fmt.Errorf("tsqtest: %s: _order differs from the one in %s", file, orderFile)
+			}
+
+			order, orderFile = fileOrder, file
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(tables)) {
+			list, ok := tables[name].([]any)
+			if !ok {
+				return nil, nil, fmt.Errorf("tsqtest: %s: %s must be a list of rows", file, name)
+			}
+
+			for i, item := range list {
+				values, ok := item.(map[string]any)
+				if !ok {
+					return nil, nil, fmt.Errorf("tsqtest: %s: %s row %d must map field names to values", file, name, i+1)
+				}
+
+				rows[name] = append(rows[name], fixtureRow{file: file, index: i + 1, values: values})
+			}
+		}
+	}
+
+	return rows, order, nil
+}
+
+// fixtureOrder 解析 fixture 文件顶层的 _order：一列表名。
+func fixtureOrder(value any) ([]string, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("_order must be a list of table names")
+	}
+
+	order := make([]string, 0, len(list))
+
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok {
+			return nil, errors.New("_order must be a list of table names")
+		}
+
+		order = append(order, name)
+	}
+
+	return order, nil
+}
+
+// insertFixtureRows 把一张表的 fixture 行逐条插入。JSON 字段名和列名都通过 Cols() 映射到列。
+func insertFixtureRows(
+	ctx context.Context,
+	tx tsq.SQLExecutor,
+	dialect tsqdialect.Dialect,
+	registration tsq.TableRegistration,
+	rows []fixtureRow,
+	now time.Time,
+) error {
+	table := registration.Table
+
+	kinds := make(map[string]tsqdialect.DDLColumnKind, len(registration.Columns))
+	for _, spec := range registration.Columns {
+		kinds[spec.Name] = spec.Type.Kind
+	}
+
+	columns := make(map[string]string)
+	for _, col := range table.Cols() {
+		columns[col.Name()] = col.Name()
+	}
+
+	// JSON 字段名优先：同一个名字既是某列的 JSON 名又是另一列的列名时按 JSON 名解释。
+	for _, col := range table.Cols() {
+		if name := col.JSONFieldName(); name != "" && name != "-" {
+			columns[name] = col.Name()
+		}
+	}
+
+	pkGiven := false
+
+	for sequence, row := range rows {
+		fields := make([]string, 0, len(row.values))
+		for field := range row.values {
+			fields = append(fields, field)
+		}
+
+		slices.Sort(fields)
+
+		cols := make([]string, 0, len(fields))
+		binds := make([]string, 0, len(fields))
+		args := make([]any, 0, len(fields))
+
+		for _, field := range fields {
+			column, ok := columns[field]
+			if !ok {
+				return fmt.Errorf("tsqtest: %s: %s row %d: unknown field %s", row.file, tableKey(table), row.index, field)
+			}
+
+			value, err := fixtureValue(row.values[field], kinds[column], now, int64(sequence+1))
+			if err != nil {
+				return fmt.Errorf("tsqtest: %s: %s row %d: %s: %w", row.file, tableKey(table), row.index, field, err)
+			}
+
+			pkGiven = pkGiven || slices.Contains(table.PrimaryKeys(), column)
+			cols = append(cols, dialect.QuoteField(column))
+			binds = append(binds, dialect.BindVar(len(args)))
+			args = append(args, value)
+		}
+
+		query := "INSERT INTO " + quoteTable(dialect, table) +
+			" (" + strings.Join(cols, ", ") + ") VALUES (" + strings.Join(binds, ", ") + ")"
+		if len(cols) == 0 {
+			query = "INSERT INTO " + quoteTable(dialect, table) + " DEFAULT VALUES"
+			if dialect.Name() == tsqdialect.MySQL {
+				query = "INSERT INTO " + quoteTable(dialect, table) + " () VALUES ()"
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("tsqtest: %s: %s row %d: %w", row.file, tableKey(table), row.index, err)
+		}
+	}
+
+	// PostgreSQL 的自增序列不会因为显式插入主键而前进，之后的普通 INSERT 会撞上 fixture 的行。
+	pks := table.PrimaryKeys()
+	if pkGiven && table.AutoIncrement() && len(pks) == 1 && dialect.Name() == tsqdialect.Postgres {
+		query := fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence(%s, %s), MAX(%s)) FROM %s",
+			dialect.BindVar(0), dialect.BindVar(1), dialect.QuoteField(pks[0]), quoteTable(dialect, table),
+		)
+		if _, err := tx.ExecContext(ctx, query, quoteTable(dialect, table), pks[0]); err != nil {
+			return fmt.Errorf("tsqtest: reset %s sequence: %w", tableKey(table), err)
+		}
+	}
+
+	return nil
+}
+
+// fixtureValue 把解码出的值转成驱动能接受的参数：字符串展开模板；列表和对象编码成 JSON，
+// 二进制列（如 json.RawMessage 字段）传 []byte，其余传文本，和生成的 Insert 写入的一致。
+func fixtureValue(value any, kind tsqdialect.DDLColumnKind, now time.Time, sequence int64) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return expandFixtureTemplates(v, now, sequence)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+
+		return v.Float64()
+	case []any, map[string]any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if kind == tsqdialect.DDLColumnKindBytes {
+			return encoded, nil
+		}
+
+		return string(encoded), nil
+	default:
+		return v, nil
+	}
+}
+
+// expandFixtureTemplates 展开字符串里的模板；整个值只有一个模板时返回带类型的值。
+func expandFixtureTemplates(value string, now time.Time, sequence int64) (any, error) {
+	matches := fixtureTemplatePattern.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value, nil
+	}
+
+	var out strings.Builder
+
+	last := 0
+
+	for _, m := range matches {
+		expanded, err := fixtureTemplateValue(value[m[2]:m[3]], value[m[4]:m[5]], now, sequence)
+		if err != nil {
+			return nil, err
+		}
+
+		if m[0] == 0 && m[1] == len(value) {
+			return expanded, nil
+		}
+
+		out.WriteString(value[last:m[0]])
+
+		switch v := expanded.(type) {
+		case time.Time:
+			out.WriteString(v.Format(time.RFC3339))
+		default:
+			out.WriteString(fmt.Sprint(v))
+		}
+
+		last = m[1]
+	}
+
+	out.WriteString(value[last:])
+
+	return out.String(), nil
+}
+
+func fixtureTemplateValue(name, arg string, now time.Time, sequence int64) (any, error) {
+	switch name {
+	case "now":
+		if arg == "" {
+			return now, nil
+		}
+
+		offset, err := time.ParseDuration(strings.ReplaceAll(arg, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid {{ now }} offset %q: %w", arg, err)
+		}
+
+		return now.Add(offset), nil
+	case "sequence":
+		if arg != "" {
+			return nil, fmt.Errorf("{{ sequence }} takes no argument, got %q", arg)
+		}
+
+		return sequence, nil
+	default:
+		return nil, fmt.Errorf("unknown fixture template {{ %s }}; use now or sequence", name)
+	}
+}
+
+// orderTables 给出 LoadFixtures 的插入顺序：有显式顺序时照它排，否则按列名推断；
+// 有推断不出指向的 <x>_id 列时报错，让调用方给出显式顺序。
+func orderTables(tables, registered []tsq.Table, order []string) ([]tsq.Table, error) {
+	if order != nil {
+		return explicitOrder(tables, order)
+	}
+
+	if unresolved := unresolvedIDColumns(tables, registered); len(unresolved) > 0 {
+		return nil, fmt.Errorf(
+			"tsqtest: cannot infer the table order: columns %s name no registered table; list the tables with WithOrder or _order",
+			strings.Join(unresolved, ", "),
+		)
+	}
+
+	return dependencyOrder(tables)
+}
+
+// explicitOrder 按给定的表名（fixture 文件里的写法）排列 tables；没列出的表报错，
+// 列出但不在 tables 里的名字忽略。
+func explicitOrder(tables []tsq.Table, order []string) ([]tsq.Table, error) {
+	byKey := make(map[string]tsq.Table, len(tables))
+	for _, table := range tables {
+		byKey[tableKey(table)] = table
+	}
+
+	ordered := make([]tsq.Table, 0, len(tables))
+
+	for _, key := range order {
+		if table, ok := byKey[key]; ok {
+			ordered = append(ordered, table)
+			delete(byKey, key)
+		}
+	}
+
+	if len(byKey) > 0 {
+		return nil, fmt.Errorf("tsqtest: tables %s are missing from the explicit order", strings.Join(slices.Sorted(maps.Keys(byKey)), ", "))
+	}
+
+	return ordered, nil
+}
+
+// referencedTableNames 返回 <x>_id 列可能指向的表名：x、xs、xes，x 以 y 结尾时还有 ies 结尾的复数。
+func referencedTableNames(prefix string) []string {
+	names := []string{prefix, prefix + "s", prefix + "es"}
+	if stem, ok := strings.CutSuffix(prefix, "y"); ok && stem != "" {
+		names = append(names, stem+"ies")
+	}
+
+	return names
+}
+
+// unresolvedIDColumns 返回 tables 里指向的表在 registered 里找不到的 <x>_id 列，写成 table.column 并排序。
+func unresolvedIDColumns(tables, registered []tsq.Table) []string {
+	known := make(map[string]bool, len(registered))
+	for _, table := range registered {
+		known[table.Table()] = true
+	}
+
+	var unresolved []string
+
+	for _, table := range tables {
+		for _, col := range table.Cols() {
+			prefix, ok := strings.CutSuffix(col.Name(), "_id")
+			if ok && prefix != "" && !slices.ContainsFunc(referencedTableNames(prefix), func(name string) bool { return known[name] }) {
+				unresolved = append(unresolved, table.Table()+"."+col.Name())
+			}
+		}
+	}
+
+	slices.Sort(unresolved)
+
+	return unresolved
+}
+
+// dependencyOrder 按列名推断的依赖对表做拓扑排序：名为 <x>_id 的列指向 referencedTableNames 里的表；
+// 指向已注册但不在 tables 里的表、或者找不到对应表的列都不算依赖。没有依赖关系的表按名字排序，
+// 结果是确定的；依赖成环时报错。
+func dependencyOrder(tables []tsq.Table) ([]tsq.Table, error) {
+	byName := make(map[string]tsq.Table, len(tables))
+	for _, table := range tables {
+		byName[table.Table()] = table
+	}
+
+	deps := make(map[string][]string, len(tables))
+
+	for _, table := range tables {
+		for _, col := range table.Cols() {
+			prefix, ok := strings.CutSuffix(col.Name(), "_id")
+			if !ok || prefix == "" {
+				continue
+			}
+
+			for _, name := range referencedTableNames(prefix) {
+				if _, ok := byName[name]; ok && name != table.Table() {
+					deps[table.Table()] = append(deps[table.Table()], name)
+				}
+			}
+		}
+	}
+
+	ordered := make([]tsq.Table, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+
+	for len(ordered) < len(byName) {
+		var ready []string
+
+		for name := range byName {
+			if !done[name] && !slices.ContainsFunc(deps[name], func(dep string) bool { return !done[dep] }) {
+				ready = append(ready, name)
+			}
+		}
+
+		if len(ready) == 0 {
+			var pending []string
+
+			for name := range byName {
+				if !done[name] {
+					pending = append(pending, name)
+				}
+			}
+
+			slices.Sort(pending)
+
+			return nil, fmt.Errorf("tsqtest: tables %s reference each other through <table>_id columns", strings.Join(pending, ", "))
+		}
+
+		slices.Sort(ready)
+
+		for _, name := range ready {
+			done[name] = true
+			ordered = append(ordered, byName[name])
+		}
+	}
+
+	return ordered, nil
+}
+
+// tableKey 是 fixture 文件里的表名：有 schema 的表写成 schema.table。
+func tableKey(table tsq.Table) string {
+	if schema := tableSchema(table); schema != "" {
+		return schema + "." + table.Table()
+	}
+
+	return table.Table()
+}
+
+func quoteTable(dialect tsqdialect.Dialect, table tsq.Table) string {
+	if schema := tableSchema(table); schema != "" {
+		return dialect.QuoteField(schema) + "." + dialect.QuoteField(table.Table())
+	}
+
+	return dialect.QuoteField(table.Table())
+}
+
+func tableSchema(table tsq.Table) string {
+	if schemaTable, ok := table.(interface{ Schema() string }); ok {
+		return strings.TrimSpace(schemaTable.Schema())
+	}
+
+	return ""
+}
+
+// invalidateTables 让读过这些表的结果缓存失效：这里绕开了 tsq 的写入路径。
+func invalidateTables(runtime *tsq.Runtime, tables []tsq.Table) {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.Table())
+	}
+
+	runtime.InvalidateResultCache(names...)
+}
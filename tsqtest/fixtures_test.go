@@ -0,0 +1,231 @@
+package tsqtest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/tmoeish/tsq/v4"
+	"github.com/tmoeish/tsq/v4/examples/academy"
+)
+
+var academyOrder = []string{"instructor", "track", "course", "learner", "enrollment"}
+
+func TestLoadFixturesReplacesSeedData(t *testing.T) {
+	ctx := context.Background()
+
+	rt, cleanup, err := academy.OpenSQLiteExampleDB()
+	if err != nil {
+		t.Fatalf("open example db: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	// course.prerequisite_id 不对应任何表：Truncate 不因此失败，LoadFixtures 用 fixture 文件里的 _order。
+	if err := Truncate(ctx, rt); err != nil {
+		t.Fatalf("Truncate() error = %v", err)
+	}
+
+	if n, err := academy.QueryLearner.Count(ctx, rt); err != nil || n != 0 {
+		t.Fatalf("expected no learners after Truncate, got %d, %v", n, err)
+	}
+
+	before := time.Now().UTC().Truncate(time.Second)
+
+	if err := LoadFixtures(ctx, rt, os.DirFS("testdata/academy")); err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+
+	learner, err := academy.QueryLearnerByEmail.GetOrErr(ctx, rt, "learner2@acme.test")
+	if err != nil || learner.ID != 2 || learner.Name != "Learner 2" {
+		t.Fatalf("expected the second templated learner, got %+v, %v", learner, err)
+	}
+
+	track, err := academy.QueryTrackByID.GetOrErr(ctx, rt, 1)
+	if err != nil || !strings.Contains(string(track.SkillItems), `"focus":"service boundaries"`) {
+		t.Fatalf("expected skill items stored as JSON, got %+v, %v", track, err)
+	}
+
+	enrollments, err := academy.QueryEnrollmentByCourseID.List(ctx, rt, 1)
+	if err != nil || len(enrollments) != 2 {
+		t.Fatalf("expected two enrollments, got %+v, %v", enrollments, err)
+	}
+
+	if got := before.Sub(enrollments[0].CreatedAt); got < 23*time.Hour || got > 25*time.Hour {
+		t.Fatalf("expected {{ now -24h }} to be a day ago, got %v", enrollments[0].CreatedAt)
+	}
+
+	if enrollments[0].Version != 1 || enrollments[0].UID == 0 {
+		t.Fatalf("expected omitted columns to take their defaults, got %+v", enrollments[0])
+	}
+
+	// 再插一行不带主键的学员：自增主键不和 fixture 的主键冲突。SQLite 的 DELETE FROM 不重置计数，
+	// 所以这里不断言具体的值。
+	extra := &academy.Learner{Name: "Extra", Email: "extra@acme.test"}
+	if err := extra.Insert(ctx, rt); err != nil || extra.ID <= 2 {
+		t.Fatalf("expected the next auto-increment id, got %d, %v", extra.ID, err)
+	}
+
+	if err := Truncate(ctx, rt, academy.TableEnrollment); err != nil {
+		t.Fatalf("Truncate(enrollment) error = %v", err)
+	}
+
+	if n, err := academy.QueryEnrollment.Count(ctx, rt); err != nil || n != 0 {
+		t.Fatalf("expected no enrollments, got %d, %v", n, err)
+	}
+
+	if n, err := academy.QueryLearner.Count(ctx, rt); err != nil || n != 3 {
+		t.Fatalf("expected learners to be kept, got %d, %v", n, err)
+	}
+}
+
+func TestLoadFixturesRejectsBadInput(t *testing.T) {
+	ctx := context.Background()
+
+	rt, cleanup, err := academy.OpenSQLiteExampleDB()
+	if err != nil {
+		t.Fatalf("open example db: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	for name, tc := range map[string]struct {
+		content string
+		want    string
+	}{
+		"unknown table": {
+			content: "teacher:\n  - name: Nora\n",
+			want:    "bad.yaml: table teacher is not registered with the runtime",
+		},
+		"unknown field": {
+			content: "learner:\n  - nickname: Al\n",
+			want:    "bad.yaml: learner row 1: unknown field nickname",
+		},
+		"unknown template": {
+			content: "learner:\n  - name: \"{{ uuid }}\"\n",
+			want:    "unknown fixture template {{ uuid }}",
+		},
+		"bad offset": {
+			content: "learner:\n  - created_at: \"{{ now -1 day }}\"\n",
+			want:    `invalid {{ now }} offset "-1 day"`,
+		},
+		"unresolved id column": {
+			content: "course:\n  - title: Go\n",
+			want:    "columns course.prerequisite_id name no registered table",
+		},
+		"bad order": {
+			content: "_order: course\n",
+			want:    "bad.yaml: _order must be a list of table names",
+		},
+		"incomplete order": {
+			content: "_order: [learner]\ncourse:\n  - title: Go\n",
+			want:    "tables course are missing from the explicit order",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{"bad.yaml": {Data: []byte(tc.content)}}
+
+			err := LoadFixtures(ctx, rt, fsys)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// baseTable 让 fakeTable 嵌入 tsq.Table 的同时能定义自己的 Table 方法。
+type baseTable interface{ tsq.Table }
+
+// fakeTable 只实现 dependencyOrder 用到的表名和列名。
+type fakeTable struct {
+	baseTable
+	name string
+	cols []string
+}
+
+func (t fakeTable) Table() string { return t.name }
+
+func (t fakeTable) Cols() []tsq.SQLColumn {
+	cols := make([]tsq.SQLColumn, 0, len(t.cols))
+	for _, name := range t.cols {
+		cols = append(cols, fakeColumn{name: name})
+	}
+
+	return cols
+}
+
+type fakeColumn struct {
+	tsq.SQLColumn
+	name string
+}
+
+func (c fakeColumn) Name() string { return c.name }
+
+func tableNames(tables []tsq.Table) string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.Table())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func TestDependencyOrderFollowsIDColumns(t *testing.T) {
+	tables := []tsq.Table{
+		fakeTable{name: "product", cols: []string{"id", "category_id", "class_id", "company_id"}},
+		fakeTable{name: "categories", cols: []string{"id", "parent_category_id", "category_id"}},
+		fakeTable{name: "classes", cols: []string{"id"}},
+		fakeTable{name: "company", cols: []string{"id"}},
+	}
+
+	// 只排 product 时，其余三张表已注册但不在本次排序里，不算依赖。
+	if got := unresolvedIDColumns(tables[:1], tables); len(got) != 0 {
+		t.Fatalf("unresolvedIDColumns(product) = %v", got)
+	}
+
+	ordered, err := dependencyOrder(tables[:1])
+	if err != nil || tableNames(ordered) != "product" {
+		t.Fatalf("dependencyOrder(product) = %s, %v", tableNames(ordered), err)
+	}
+
+	if got := unresolvedIDColumns(tables, tables); strings.Join(got, ",") != "categories.parent_category_id" {
+		t.Fatalf("unresolvedIDColumns() = %v, want categories.parent_category_id", got)
+	}
+
+	// category_id → categories，class_id → classes；categories.category_id 指向自己，
+	// parent_category_id 找不到表，都不算依赖。
+	ordered, err = dependencyOrder(tables)
+	if err != nil {
+		t.Fatalf("dependencyOrder() error = %v", err)
+	}
+
+	if got, want := tableNames(ordered), "categories,classes,company,product"; got != want {
+		t.Fatalf("dependencyOrder() = %s, want %s", got, want)
+	}
+}
+
+func TestExplicitOrderOverridesInference(t *testing.T) {
+	academyTables := []tsq.Table{
+		academy.TableEnrollment,
+		academy.TableCourse,
+		academy.TableLearner,
+		academy.TableTrack,
+		academy.TableInstructor,
+	}
+
+	// course.prerequisite_id 没有对应的表，推断会报错而不是悄悄忽略。
+	if _, err := orderTables(academyTables, academyTables, nil); err == nil ||
+		!strings.Contains(err.Error(), "columns course.prerequisite_id name no registered table") {
+		t.Fatalf("expected prerequisite_id to be reported, got %v", err)
+	}
+
+	ordered, err := orderTables(academyTables[:2], academyTables, append([]string{"teacher"}, academyOrder...))
+	if err != nil {
+		t.Fatalf("orderTables() error = %v", err)
+	}
+
+	if got, want := tableNames(ordered), "course,enrollment"; got != want {
+		t.Fatalf("orderTables() = %s, want %s", got, want)
+	}
+}
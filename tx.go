@@ -72,6 +72,12 @@ func IsOptimisticLockError(err error) bool {
 	return errors.Is(err, &ErrOptimisticLockConflict{})
 }
 
+// IsDuplicateKeyError reports whether err is an ErrDuplicateKey or a MySQL,
+// PostgreSQL or SQLite primary-key / unique-index violation.
+func IsDuplicateKeyError(err error) bool {
+	return errors.Is(err, &ErrDuplicateKey{}) || isDuplicateKeyError(err)
+}
+
 // IsRetryableNetworkError reports whether err looks like a transient connection failure.
 func IsRetryableNetworkError(err error) bool {
 	if err == nil {
@@ -298,7 +304,16 @@ func (r *Runtime) executeTxAttempt[T any](
 		}
 	}()
 
-	result, err := fn(ctx, wrapExecutor(tx, r.dialect, r))
+	// Reads issued through the runtime with the callback context still belong
+	// to the transaction's view of the data, so keep them off the replicas.
+	resultTx := r.results.begin()
+
+	result, err := fn(UsePrimary(ctx), wrappedExecutor{
+		SQLExecutor: r.txExecutor(tx),
+		dialect:     r.dialect,
+		runtime:     r,
+		resultTx:    resultTx,
+	})
 	if err != nil {
 		var zero T
 		return zero, txRetryStageBody, err
@@ -311,6 +326,8 @@ func (r *Runtime) executeTxAttempt[T any](
 
 	committed = true
 
+	r.results.commit(resultTx)
+
 	return result, 0, nil
 }
 